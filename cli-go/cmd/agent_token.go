@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentTokenAgent  string
+	agentTokenKeys   []string
+	agentTokenTTL    string
+	agentTokenOutput string
+	agentTokenRotate bool
+	agentTokenAll    bool
+	agentTokensJSON  bool
+	agentTokenForce  bool
+)
+
+// agentToken is an agent token record as the API returns it; the signed
+// token itself is only returned by issue.
+type agentToken struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"project_id"`
+	AgentID     string     `json:"agent_id"`
+	Environment *string    `json:"environment"`
+	Keys        []string   `json:"keys"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	Status      string     `json:"status"`
+}
+
+type agentTokenListResponse struct {
+	Tokens []agentToken `json:"tokens"`
+}
+
+type agentTokenIssueResponse struct {
+	Token   string     `json:"token"`
+	Record  agentToken `json:"tokenRecord"`
+	Rotated []string   `json:"rotated"`
+}
+
+// agentTokenJSON is the --json shape of an agent token and, after issue,
+// the token itself.
+type agentTokenJSON struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"projectId"`
+	AgentID     string     `json:"agentId"`
+	Environment *string    `json:"environment"`
+	Keys        []string   `json:"keys"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	Token       string     `json:"token,omitempty"`
+	Rotated     []string   `json:"rotated,omitempty"`
+}
+
+func (t agentToken) toJSON() agentTokenJSON {
+	return agentTokenJSON{
+		ID:          t.ID,
+		ProjectID:   t.ProjectID,
+		AgentID:     t.AgentID,
+		Environment: t.Environment,
+		Keys:        t.Keys,
+		Status:      t.Status,
+		CreatedAt:   t.CreatedAt,
+		ExpiresAt:   t.ExpiresAt,
+		RevokedAt:   t.RevokedAt,
+		LastUsedAt:  t.LastUsedAt,
+	}
+}
+
+var agentTokenCmd = &cobra.Command{
+	Use:     "agent-token",
+	Aliases: []string{"agent-tokens"},
+	Short:   "Issue, list and revoke tokens for AI agents",
+	Long: `Issue short-lived agent tokens (envault_agt_...) that let an AI agent or
+local tool propose secret changes for human approval. Each token names its
+agent, so approvals and the audit log show which agent acted, and can be
+fenced to one environment and a set of keys.
+
+Agent tokens last at most 24h. Rotate them on a schedule by re-issuing with
+--rotate, which revokes your earlier tokens for the agent once the new one
+exists:
+
+  # crontab: refresh my-bot's token every 30 minutes
+  */30 * * * * envault agent-token issue --agent my-bot -p <project> --ttl 1h --rotate --output env > ~/.config/my-bot/envault.env`,
+}
+
+var agentTokenIssueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "Issue an agent token and print it",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := strings.ToLower(strings.TrimSpace(agentTokenOutput))
+		if output != "text" && output != "env" && output != "json" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --output %q: expected text, env or json.", agentTokenOutput)))
+			os.Exit(1)
+		}
+		agentID := strings.TrimSpace(agentTokenAgent)
+		if agentID == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Pass the agent's name with --agent."))
+			os.Exit(1)
+		}
+		ttl, err := offlinecache.ParseMaxAge(agentTokenTTL)
+		if err != nil || ttl < time.Minute || ttl > 24*time.Hour {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --ttl %q: expected a duration between 1m and 24h, such as 1h.", agentTokenTTL)))
+			os.Exit(1)
+		}
+
+		resp := issueAgentTokenOrExit(tokensProjectIDOrExit(), agentID, strings.TrimSpace(envFlag), agentTokenKeys, ttl, agentTokenRotate)
+		switch output {
+		case "json":
+			out := resp.Record.toJSON()
+			out.Token = resp.Token
+			out.Rotated = resp.Rotated
+			printTokensJSON(out)
+		case "env":
+			fmt.Printf("export ENVAULT_TOKEN=%s\n", resp.Token)
+		default:
+			fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Issued agent token for %q (%s), %s.", resp.Record.AgentID, describeAgentTokenScope(resp.Record), describeTokenExpiry(&resp.Record.ExpiresAt))))
+			if len(resp.Rotated) > 0 {
+				fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Revoked %d earlier token(s) for this agent.", len(resp.Rotated))))
+			}
+			fmt.Println(resp.Token)
+		}
+	},
+}
+
+var agentTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a project's agent tokens",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens := listAgentTokensOrExit(tokensProjectIDOrExit(), strings.TrimSpace(agentTokenAgent), agentTokenAll)
+
+		if agentTokensJSON {
+			out := make([]agentTokenJSON, 0, len(tokens))
+			for _, t := range tokens {
+				out = append(out, t.toJSON())
+			}
+			printTokensJSON(out)
+			return
+		}
+		if len(tokens) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("No agent tokens."))
+			return
+		}
+
+		now := time.Now()
+		rows := [][]string{{"ID", "AGENT", "SCOPE", "STATUS", "CREATED", "LAST USED", "EXPIRES"}}
+		for _, t := range tokens {
+			lastUsed := "never"
+			if t.LastUsedAt != nil {
+				lastUsed = humanizeDuration(now.Sub(*t.LastUsedAt)) + " ago"
+			}
+			expires := "-"
+			if t.Status == "active" {
+				expires = "in " + humanizeDuration(t.ExpiresAt.Sub(now))
+			}
+			rows = append(rows, []string{
+				t.ID,
+				t.AgentID,
+				describeAgentTokenScope(t),
+				t.Status,
+				t.CreatedAt.Local().Format("2006-01-02 15:04"),
+				lastUsed,
+				expires,
+			})
+		}
+		printCacheTable(rows)
+	},
+}
+
+var agentTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <id-or-agent>",
+	Short: "Revoke an agent token, or every live token of an agent",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectID := tokensProjectIDOrExit()
+		ref := strings.TrimSpace(args[0])
+		var targets []agentToken
+		for _, t := range listAgentTokensOrExit(projectID, "", false) {
+			if t.ID == ref {
+				targets = []agentToken{t}
+				break
+			}
+			if t.AgentID == ref {
+				targets = append(targets, t)
+			}
+		}
+		if len(targets) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("No live agent token matches %q.", ref)))
+			os.Exit(1)
+		}
+
+		if !agentTokenForce {
+			if Headless {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Error: revoking a token needs --force in headless mode."))
+				os.Exit(1)
+			}
+			confirm := false
+			prompt := &survey.Confirm{Message: fmt.Sprintf("Revoke %d agent token(s) for %q? The agent stops working until it gets a new token.", len(targets), targets[0].AgentID)}
+			if err := survey.AskOne(prompt, &confirm); err != nil || !confirm {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+				return
+			}
+		}
+
+		// Revoking by agent name covers tokens other members issued; the server
+		// only lets the owner revoke those, so they are skipped, not fatal.
+		byName := len(targets) > 1 || targets[0].ID != ref
+		client := api.NewClient()
+		revoked := make([]agentTokenJSON, 0, len(targets))
+		skipped, failed := 0, 0
+		for _, t := range targets {
+			if _, err := client.Delete(agentTokensPath(projectID) + "/" + url.PathEscape(t.ID)); err != nil {
+				var apiErr *api.APIError
+				if byName && errors.As(err, &apiErr) && (apiErr.StatusCode == 404 || apiErr.StatusCode == 409) {
+					skipped++
+					continue
+				}
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to revoke agent token %s.", t.ID)))
+				fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+				if !byName {
+					os.Exit(1)
+				}
+				failed++
+				continue
+			}
+			t.Status = "revoked"
+			revoked = append(revoked, t.toJSON())
+		}
+
+		if agentTokensJSON {
+			printTokensJSON(revoked)
+		} else {
+			fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Revoked %d agent token(s) for %q.", len(revoked), targets[0].AgentID)))
+			if skipped > 0 {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Skipped %d token(s) issued by other members; only the project owner can revoke those.", skipped)))
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func agentTokensPath(projectID string) string {
+	return "/projects/" + url.PathEscape(projectID) + "/agent-tokens"
+}
+
+// issueAgentTokenOrExit issues a token for agentID; an empty environment or
+// key list leaves that fence open.
+func issueAgentTokenOrExit(projectID, agentID, environment string, keys []string, ttl time.Duration, rotate bool) agentTokenIssueResponse {
+	resp, err := issueAgentToken(context.Background(), api.NewClient(), projectID, agentID, environment, keys, ttl, rotate)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to issue agent token."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+		os.Exit(1)
+	}
+	return resp
+}
+
+func issueAgentToken(ctx context.Context, client *api.Client, projectID, agentID, environment string, keys []string, ttl time.Duration, rotate bool) (agentTokenIssueResponse, error) {
+	payload := map[string]interface{}{
+		"agent_id":    agentID,
+		"ttl_seconds": int(ttl / time.Second),
+		"rotate":      rotate,
+	}
+	if environment != "" {
+		payload["environment"] = environment
+	}
+	cleaned := []string{}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			cleaned = append(cleaned, key)
+		}
+	}
+	if len(cleaned) > 0 {
+		payload["keys"] = cleaned
+	}
+
+	var resp agentTokenIssueResponse
+	respBytes, err := client.PostWithContext(ctx, agentTokensPath(projectID), payload)
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(respBytes, &resp); err != nil || !strings.HasPrefix(resp.Token, "envault_agt_") {
+		return resp, fmt.Errorf("failed to parse the issued agent token")
+	}
+	return resp, nil
+}
+
+func listAgentTokensOrExit(projectID, agentID string, all bool) []agentToken {
+	query := url.Values{}
+	if agentID != "" {
+		query.Set("agentId", agentID)
+	}
+	if all {
+		query.Set("all", "true")
+	}
+	path := agentTokensPath(projectID)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	client := api.NewClient()
+	respBytes, err := client.Get(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to list agent tokens."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+		os.Exit(1)
+	}
+	var resp agentTokenListResponse
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to parse agent tokens: %v", err)))
+		os.Exit(1)
+	}
+	return resp.Tokens
+}
+
+// agentTokenClaims are the claims an agent token carries. Jti is the ID of
+// the token's record, used to revoke it.
+type agentTokenClaims struct {
+	Sub      string   `json:"sub"`
+	Act      string   `json:"act"`
+	Projects []string `json:"projects"`
+	Env      string   `json:"env"`
+	Keys     []string `json:"keys"`
+	Jti      string   `json:"jti"`
+	Iat      int64    `json:"iat"`
+	Exp      int64    `json:"exp"`
+}
+
+// parseAgentTokenClaims decodes an agent token's claims without verifying
+// its signature; only the server can tell whether the token is valid.
+func parseAgentTokenClaims(token string) (agentTokenClaims, bool) {
+	var claims agentTokenClaims
+	if !strings.HasPrefix(token, "envault_agt_") {
+		return claims, false
+	}
+	parts := strings.Split(strings.TrimPrefix(token, "envault_agt_"), ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+func describeAgentTokenScope(t agentToken) string {
+	environment := "all environments"
+	if t.Environment != nil {
+		environment = *t.Environment
+	}
+	if len(t.Keys) == 0 {
+		return environment
+	}
+	return environment + ": " + strings.Join(t.Keys, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(agentTokenCmd)
+	agentTokenCmd.AddCommand(agentTokenIssueCmd, agentTokenListCmd, agentTokenRevokeCmd)
+	for _, c := range []*cobra.Command{agentTokenIssueCmd, agentTokenListCmd, agentTokenRevokeCmd} {
+		c.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+	}
+	agentTokenIssueCmd.Flags().StringVar(&agentTokenAgent, "agent", "", "Name the agent acts under in approvals and the audit log")
+	agentTokenIssueCmd.Flags().StringSliceVar(&agentTokenKeys, "keys", nil, "Only allow changes to these keys (comma-separated)")
+	agentTokenIssueCmd.Flags().StringVar(&agentTokenTTL, "ttl", "1h", "Lifetime, at most 24h")
+	agentTokenIssueCmd.Flags().StringVarP(&agentTokenOutput, "output", "o", "text", "Output format: text, env or json")
+	agentTokenIssueCmd.Flags().BoolVar(&agentTokenRotate, "rotate", false, "Revoke your earlier tokens for the agent once the new one is issued")
+	agentTokenListCmd.Flags().StringVar(&agentTokenAgent, "agent", "", "Only list this agent's tokens")
+	agentTokenListCmd.Flags().BoolVar(&agentTokenAll, "all", false, "Include expired and revoked tokens")
+	agentTokenListCmd.Flags().BoolVar(&agentTokensJSON, "json", false, "Print JSON")
+	agentTokenRevokeCmd.Flags().BoolVar(&agentTokensJSON, "json", false, "Print JSON")
+	agentTokenRevokeCmd.Flags().BoolVarP(&agentTokenForce, "force", "f", false, "Revoke without confirmation")
+}
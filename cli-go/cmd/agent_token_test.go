@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgentTokenCmd_IssueListRevoke(t *testing.T) {
+	const projectID = "11111111-1111-4111-8111-111111111111"
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	record := `{"id":"t1","project_id":"` + projectID + `","agent_id":"my-bot","environment":"development","keys":["OPENAI_API_KEY"],"created_by":"u1","created_at":"2026-10-18T10:00:00Z","expires_at":"` + expires + `","revoked_at":null,"last_used_at":null,"status":"active"}`
+	// Another member's token for the same agent, which this user may not revoke.
+	others := strings.NewReplacer(`"id":"t1"`, `"id":"t2"`, `"created_by":"u1"`, `"created_by":"u2"`).Replace(record)
+	base := "/api/cli/projects/" + projectID + "/agent-tokens"
+	api := newMockAPI(t, "envault_at_me", func(w http.ResponseWriter, req recordedRequest) {
+		switch {
+		case req.Method == http.MethodPost && req.Path == base:
+			_, _ = w.Write([]byte(`{"token":"envault_agt_header.claims.sig","tokenRecord":` + record + `,"rotated":["t0"]}`))
+		case req.Method == http.MethodGet && req.Path == base:
+			if req.Query.Get("agentId") == "other" {
+				_, _ = w.Write([]byte(`{"tokens":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"tokens":[` + record + `,` + others + `]}`))
+		case req.Method == http.MethodDelete && req.Path == base+"/t2":
+			w.WriteHeader(http.StatusNotFound)
+		case req.Method == http.MethodDelete && strings.HasPrefix(req.Path, base+"/"):
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	run := newSessionCLI(t, api, "envault_at_me")
+
+	stdout, stderr, err := run("agent-token", "issue", "--agent", "my-bot", "-p", projectID, "--env", "development", "--keys", "OPENAI_API_KEY", "--ttl", "2h", "--rotate", "--output", "env")
+	if err != nil || stdout != "export ENVAULT_TOKEN=envault_agt_header.claims.sig\n" {
+		t.Fatalf("unexpected issue output (%v):\n%q\n%s", err, stdout, stderr)
+	}
+	issued := api.Requests(http.MethodPost)[0].Body
+	if issued["agent_id"] != "my-bot" || issued["environment"] != "development" || issued["ttl_seconds"] != float64(7200) || issued["rotate"] != true {
+		t.Fatalf("unexpected issue payload: %v", issued)
+	}
+	if keys, _ := issued["keys"].([]interface{}); len(keys) != 1 || keys[0] != "OPENAI_API_KEY" {
+		t.Fatalf("expected the key fence in the payload, got %v", issued["keys"])
+	}
+
+	stdout, _, err = run("agent-token", "issue", "--agent", "my-bot", "-p", projectID, "--output", "json")
+	var out agentTokenJSON
+	if err != nil || json.Unmarshal([]byte(stdout), &out) != nil || out.Token == "" || out.AgentID != "my-bot" || len(out.Rotated) != 1 {
+		t.Fatalf("unexpected issue --output json (%v):\n%s", err, stdout)
+	}
+
+	if _, stderr, err = run("agent-token", "issue", "--agent", "my-bot", "-p", projectID, "--ttl", "48h"); err == nil || !strings.Contains(stderr, "Invalid --ttl") {
+		t.Fatalf("expected a ttl over 24h to be refused, got err=%v stderr=%s", err, stderr)
+	}
+
+	stdout, stderr, err = run("agent-token", "list", "-p", projectID)
+	if err != nil || !strings.Contains(stdout, "my-bot") || !strings.Contains(stdout, "development: OPENAI_API_KEY") {
+		t.Fatalf("unexpected list output (%v):\n%s\n%s", err, stdout, stderr)
+	}
+
+	stdout, stderr, err = run("agent-token", "revoke", "my-bot", "-p", projectID, "--force")
+	if deleted := api.Requests(http.MethodDelete); err != nil || len(deleted) != 2 || deleted[0].Path != base+"/t1" || !strings.Contains(stdout, "Revoked 1 agent token") || !strings.Contains(stderr, "Skipped 1 token") {
+		t.Fatalf("expected the other member's token to be skipped (%v):\n%s\n%s", err, stdout, stderr)
+	}
+}
@@ -0,0 +1,545 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const approvalsPath = "/approvals"
+
+var (
+	approvalsJSON bool
+	denyReason    string
+	waitTimeout   time.Duration
+	waitInterval  time.Duration
+)
+
+// approvalGrant is the time-boxed window an approval given with
+// `envault approve --for` keeps open for the agent's follow-up requests.
+type approvalGrant struct {
+	ExpiresAt   time.Time  `json:"expires_at"`
+	Keys        []string   `json:"keys"`
+	Environment string     `json:"environment"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	Active      bool       `json:"active"`
+}
+
+type approvalGrantJSON struct {
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	Keys        []string   `json:"keys"`
+	Environment string     `json:"environment"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	Active      bool       `json:"active"`
+}
+
+type approvalChange struct {
+	Key    string `json:"key"`
+	Action string `json:"action"`
+}
+
+// approval is an agent request as the CLI API describes it. Proposed secret
+// values are never sent to the CLI.
+type approval struct {
+	ID          string           `json:"id"`
+	ProjectID   string           `json:"project_id"`
+	ProjectName string           `json:"project_name"`
+	AgentID     string           `json:"agent_id"`
+	Status      string           `json:"status"`
+	Consumed    bool             `json:"consumed"`
+	Environment string           `json:"environment"`
+	Changes     []approvalChange `json:"changes"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	DecidedBy   string           `json:"decided_by"`
+	DecidedAt   *time.Time       `json:"decided_at"`
+	Reason      string           `json:"reason"`
+	Grant       *approvalGrant   `json:"grant"`
+	GrantID     string           `json:"grant_id"`
+}
+
+// approvalJSON is the --json shape of a request.
+type approvalJSON struct {
+	ID          string             `json:"id"`
+	ProjectID   string             `json:"projectId"`
+	ProjectName string             `json:"projectName"`
+	AgentID     string             `json:"agentId"`
+	Status      string             `json:"status"`
+	Consumed    bool               `json:"consumed"`
+	Environment string             `json:"environment"`
+	Changes     []approvalChange   `json:"changes"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	ExpiresAt   time.Time          `json:"expiresAt"`
+	DecidedBy   string             `json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time         `json:"decidedAt"`
+	Reason      string             `json:"reason,omitempty"`
+	Grant       *approvalGrantJSON `json:"grant"`
+	GrantID     string             `json:"grantId,omitempty"`
+}
+
+func (a approval) toJSON() approvalJSON {
+	changes := a.Changes
+	if changes == nil {
+		changes = []approvalChange{}
+	}
+	var grant *approvalGrantJSON
+	if a.Grant != nil {
+		grant = &approvalGrantJSON{
+			ExpiresAt:   a.Grant.ExpiresAt,
+			Keys:        a.Grant.Keys,
+			Environment: a.Grant.Environment,
+			RevokedAt:   a.Grant.RevokedAt,
+			Active:      a.Grant.Active,
+		}
+	}
+	return approvalJSON{
+		ID:          a.ID,
+		ProjectID:   a.ProjectID,
+		ProjectName: a.ProjectName,
+		AgentID:     a.AgentID,
+		Status:      a.Status,
+		Consumed:    a.Consumed,
+		Environment: a.Environment,
+		Changes:     changes,
+		CreatedAt:   a.CreatedAt,
+		ExpiresAt:   a.ExpiresAt,
+		DecidedBy:   a.DecidedBy,
+		DecidedAt:   a.DecidedAt,
+		Reason:      a.Reason,
+		Grant:       grant,
+		GrantID:     a.GrantID,
+	}
+}
+
+type approveResponse struct {
+	Success     bool           `json:"success"`
+	Message     string         `json:"message"`
+	Error       string         `json:"error"`
+	Environment string         `json:"environment"`
+	Keys        []string       `json:"keys"`
+	Reason      string         `json:"reason"`
+	Grant       *approvalGrant `json:"grant"`
+}
+
+var approvalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "Review pending agent requests",
+	Long: `List, inspect, deny and wait on the requests AI agents make to change
+secrets, and revoke time-boxed grants early. Reviewing needs a personal
+login as a project owner or editor. Approve a request with
+'envault approve <id>'.`,
+}
+
+var approvalsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pending agent requests and active grants in your projects",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := approvalClientOrExit()
+		path := approvalsPath
+		if projectFlag != "" {
+			path += "?projectId=" + url.QueryEscape(projectFlag)
+		}
+		respBytes, err := client.Get(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to list approval requests."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(approvalErrorMessage(err)))
+			os.Exit(1)
+		}
+		var resp struct {
+			Approvals []approval `json:"approvals"`
+			Grants    []approval `json:"grants"`
+		}
+		if err := json.Unmarshal(respBytes, &resp); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to parse approval requests: %v", err)))
+			os.Exit(1)
+		}
+
+		if approvalsJSON {
+			out := struct {
+				Approvals []approvalJSON `json:"approvals"`
+				Grants    []approvalJSON `json:"grants"`
+			}{[]approvalJSON{}, []approvalJSON{}}
+			for _, a := range resp.Approvals {
+				out.Approvals = append(out.Approvals, a.toJSON())
+			}
+			for _, a := range resp.Grants {
+				out.Grants = append(out.Grants, a.toJSON())
+			}
+			printTokensJSON(out)
+			return
+		}
+
+		if len(resp.Approvals) == 0 {
+			fmt.Println("No pending approval requests.")
+		} else {
+			rows := [][]string{{"ID", "PROJECT", "AGENT", "ENVIRONMENT", "CHANGES", "EXPIRES"}}
+			for _, a := range resp.Approvals {
+				rows = append(rows, []string{
+					a.ID,
+					a.ProjectName,
+					a.AgentID,
+					valueOrDefault(a.Environment, "default"),
+					describeApprovalChanges(a.Changes),
+					"in " + humanizeDuration(time.Until(a.ExpiresAt)),
+				})
+			}
+			printCacheTable(rows)
+		}
+
+		if len(resp.Grants) > 0 {
+			fmt.Println()
+			fmt.Println(ui.ColorBold("Active grants:"))
+			rows := [][]string{{"ID", "PROJECT", "AGENT", "ENVIRONMENT", "KEYS", "REMAINING"}}
+			for _, a := range resp.Grants {
+				if a.Grant == nil {
+					continue
+				}
+				rows = append(rows, []string{
+					a.ID,
+					a.ProjectName,
+					a.AgentID,
+					a.Grant.Environment,
+					describeKeys(a.Grant.Keys),
+					humanizeDuration(time.Until(a.Grant.ExpiresAt)),
+				})
+			}
+			printCacheTable(rows)
+		}
+	},
+}
+
+var approvalsShowCmd = &cobra.Command{
+	Use:   "show <approval_id>",
+	Short: "Show an agent request",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		a := fetchApprovalOrExit(approvalClientOrExit(), args[0])
+		if approvalsJSON {
+			printTokensJSON(a.toJSON())
+			return
+		}
+
+		field := func(label, value string) {
+			if value != "" {
+				fmt.Printf("%s %s\n", ui.ColorBold(label+":"), value)
+			}
+		}
+		field("Request", a.ID)
+		field("Agent", a.AgentID)
+		field("Project", strings.TrimSpace(fmt.Sprintf("%s (%s)", a.ProjectName, a.ProjectID)))
+		field("Environment", valueOrDefault(a.Environment, "project default"))
+		status := a.Status
+		if a.Consumed {
+			status += " (applied by the agent)"
+		}
+		field("Status", status)
+		field("Requested", a.CreatedAt.Local().Format(time.RFC3339))
+		if a.Status == "pending" {
+			field("Expires", fmt.Sprintf("%s (in %s)", a.ExpiresAt.Local().Format(time.RFC3339), humanizeDuration(time.Until(a.ExpiresAt))))
+		}
+		if a.DecidedAt != nil {
+			field("Decided", a.DecidedAt.Local().Format(time.RFC3339))
+		}
+		field("Reason", a.Reason)
+		if a.Grant != nil {
+			field("Grant", describeGrant(*a.Grant))
+		}
+		field("Applied Under Grant", a.GrantID)
+		fmt.Printf("%s\n", ui.ColorBold("Requested Changes:"))
+		for _, c := range a.Changes {
+			fmt.Printf("  %-7s %s\n", c.Action, c.Key)
+		}
+	},
+}
+
+var approvalsDenyCmd = &cobra.Command{
+	Use:   "deny <approval_id>",
+	Short: "Reject a pending agent request",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		body := map[string]interface{}{"action": "reject"}
+		if strings.TrimSpace(denyReason) != "" {
+			body["reason"] = strings.TrimSpace(denyReason)
+		}
+		out := submitApprovalDecisionOrExit(approvalClientOrExit(), args[0], body, "Submitting rejection...")
+		message := strings.TrimSpace(out.Message)
+		if message == "" {
+			message = "Request has been rejected"
+		}
+		fmt.Println(ui.ColorGreen("[OK] " + message))
+	},
+}
+
+var approvalsWaitCmd = &cobra.Command{
+	Use:   "wait <approval_id>",
+	Short: "Block until an agent request is decided",
+	Long: `Poll a request until it is approved or rejected. Exits 0 when it is
+approved and 1 when it is rejected, expires or --timeout passes.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		approvalID := strings.TrimSpace(args[0])
+		client := approvalClientOrExit()
+		ctx, cancel := approvalContext()
+		defer cancel()
+
+		if waitInterval <= 0 {
+			waitInterval = 3 * time.Second
+		}
+		deadline := time.Now().Add(waitTimeout)
+		loader := ui.NewLoader(ui.LoaderThemeSync, "Waiting for a decision...")
+		loader.Start()
+		status, a, err := waitForApprovalDecision(ctx, client, approvalID, deadline)
+		loader.Stop()
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+				os.Exit(130)
+			}
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to check the approval request."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(approvalErrorMessage(err)))
+			os.Exit(1)
+		}
+
+		switch status {
+		case "approved":
+			fmt.Println(ui.ColorGreen("[OK] Request " + approvalID + " was approved."))
+		case "rejected":
+			message := "Request " + approvalID + " was rejected."
+			if a.Reason != "" {
+				message += " Reason: " + a.Reason
+			}
+			fmt.Fprintln(os.Stderr, ui.ColorRed(message))
+			os.Exit(1)
+		case "expired":
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Request "+approvalID+" expired without a decision."))
+			os.Exit(1)
+		default:
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Timed out after %s waiting for request %s.", waitTimeout, approvalID)))
+			os.Exit(1)
+		}
+	},
+}
+
+var approvalsRevokeCmd = &cobra.Command{
+	Use:   "revoke <approval_id>",
+	Short: "End a time-boxed grant early",
+	Long: `End the grant an approval given with 'envault approve --for' keeps open.
+Changes already applied stay applied; the agent's next request goes back to
+review.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		approvalID := strings.TrimSpace(args[0])
+		client := approvalClientOrExit()
+		respBytes, err := client.Post(approvalsPath+"/"+url.PathEscape(approvalID)+"/revoke", nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to revoke the grant."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(approvalErrorMessage(err)))
+			os.Exit(1)
+		}
+		var resp struct {
+			Approval approval `json:"approval"`
+		}
+		_ = json.Unmarshal(respBytes, &resp)
+		message := "[OK] Grant from approval " + approvalID + " revoked."
+		if resp.Approval.Grant != nil {
+			message = fmt.Sprintf("[OK] Grant from approval %s revoked with %s left.", approvalID, humanizeDuration(time.Until(resp.Approval.Grant.ExpiresAt)))
+		}
+		fmt.Println(ui.ColorGreen(message))
+	},
+}
+
+// waitForApprovalDecision polls the SDK status route as the reviewer, which
+// reports the decision without consuming the agent's payload. It returns
+// "pending" when the deadline passes first.
+func waitForApprovalDecision(ctx context.Context, client *api.Client, approvalID string, deadline time.Time) (string, approval, error) {
+	path := "/api/sdk/approvals/" + url.PathEscape(approvalID) + "/status"
+	for {
+		respBytes, err := client.GetWithContext(ctx, path)
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == 403 || apiErr.StatusCode == 410) {
+			// Rejected and expired requests answer with an error status but
+			// still describe the request.
+			respBytes, err = []byte(apiErr.Body), nil
+		}
+		if err != nil {
+			return "", approval{}, err
+		}
+
+		var resp struct {
+			Status   string   `json:"status"`
+			Approval approval `json:"approval"`
+		}
+		if err := json.Unmarshal(respBytes, &resp); err != nil || resp.Status == "" {
+			return "", approval{}, fmt.Errorf("unexpected status response: %s", strings.TrimSpace(string(respBytes)))
+		}
+		if resp.Status != "pending" {
+			return resp.Status, resp.Approval, nil
+		}
+		if time.Now().Add(waitInterval).After(deadline) {
+			return "pending", resp.Approval, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", approval{}, ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// approvalClientOrExit returns an API client authenticated with a personal
+// CLI session; service and agent tokens cannot review agent requests.
+func approvalClientOrExit() *api.Client {
+	client := api.NewClient()
+	token := strings.TrimSpace(client.Token)
+	if token == "" {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("No local access token found in the credential store."))
+		fmt.Fprintln(os.Stderr, ui.ColorYellow("Run `envault login` and retry."))
+		os.Exit(1)
+	}
+	if !strings.HasPrefix(token, "envault_at_") {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Reviewing agent requests needs a local CLI access token (envault_at_)."))
+		fmt.Fprintln(os.Stderr, ui.ColorYellow("Run `envault login` to refresh your local access token and retry."))
+		os.Exit(1)
+	}
+	return client
+}
+
+func fetchApprovalOrExit(client *api.Client, approvalID string) approval {
+	respBytes, err := client.Get(approvalsPath + "/" + url.PathEscape(strings.TrimSpace(approvalID)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to load the approval request."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(approvalErrorMessage(err)))
+		os.Exit(1)
+	}
+	var resp struct {
+		Approval approval `json:"approval"`
+	}
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to parse the approval request: %v", err)))
+		os.Exit(1)
+	}
+	return resp.Approval
+}
+
+// submitApprovalDecisionOrExit posts an approve or reject decision to the
+// same route the web approval page uses.
+func submitApprovalDecisionOrExit(client *api.Client, approvalID string, body map[string]interface{}, progress string) approveResponse {
+	approvalID = strings.TrimSpace(approvalID)
+	if approvalID == "" {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Approval ID is required."))
+		os.Exit(1)
+	}
+
+	ctx, cancel := approvalContext()
+	defer cancel()
+
+	loader := ui.NewLoader(ui.LoaderThemeSync, progress)
+	loader.Start()
+	respBytes, err := client.PostWithContext(ctx, "/api/approve/"+url.PathEscape(approvalID), body)
+	loader.Stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+			os.Exit(130)
+		}
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Decision failed."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(approvalErrorMessage(err)))
+		os.Exit(1)
+	}
+
+	var out approveResponse
+	_ = json.Unmarshal(respBytes, &out)
+	return out
+}
+
+// approvalContext is cancelled by SIGINT or SIGTERM.
+func approvalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigCh)
+	}()
+	return ctx, cancel
+}
+
+// approvalErrorMessage prefers the server's {"error": ...} message.
+func approvalErrorMessage(err error) string {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 401 {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal([]byte(apiErr.Body), &body) == nil && strings.TrimSpace(body.Error) != "" {
+			return strings.TrimSpace(body.Error)
+		}
+	}
+	return classifyAPIError(err)
+}
+
+func describeGrant(g approvalGrant) string {
+	scope := fmt.Sprintf("%s in %s", describeKeys(g.Keys), g.Environment)
+	switch {
+	case g.RevokedAt != nil:
+		return fmt.Sprintf("%s, revoked %s", scope, g.RevokedAt.Local().Format(time.RFC3339))
+	case !g.Active:
+		return fmt.Sprintf("%s, ended %s", scope, g.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s until %s (%s left)", scope, g.ExpiresAt.Local().Format(time.RFC3339), humanizeDuration(time.Until(g.ExpiresAt)))
+}
+
+func describeKeys(keys []string) string {
+	if len(keys) > 3 {
+		return fmt.Sprintf("%s +%d more", strings.Join(keys[:3], ", "), len(keys)-3)
+	}
+	return strings.Join(keys, ", ")
+}
+
+func describeApprovalChanges(changes []approvalChange) string {
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.Action == "delete" {
+			keys = append(keys, "-"+c.Key)
+			continue
+		}
+		keys = append(keys, c.Key)
+	}
+	return describeKeys(keys)
+}
+
+func valueOrDefault(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+func init() {
+	rootCmd.AddCommand(approvalsCmd)
+	approvalsCmd.AddCommand(approvalsListCmd, approvalsShowCmd, approvalsDenyCmd, approvalsWaitCmd, approvalsRevokeCmd)
+	approvalsListCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Only list requests in this project")
+	for _, c := range []*cobra.Command{approvalsListCmd, approvalsShowCmd} {
+		c.Flags().BoolVar(&approvalsJSON, "json", false, "Print JSON")
+	}
+	approvalsDenyCmd.Flags().StringVar(&denyReason, "reason", "", "Why the request is rejected, recorded in the audit log")
+	approvalsWaitCmd.Flags().DurationVar(&waitTimeout, "timeout", 15*time.Minute, "Give up after this long")
+	approvalsWaitCmd.Flags().DurationVar(&waitInterval, "interval", 3*time.Second, "Time between status checks")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApprovalsCmd_ListShowDenyWaitRevoke(t *testing.T) {
+	expires := time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339)
+	request := `{"id":"a1","project_id":"p1","project_name":"Shop","agent_id":"mcp-cli","status":"pending","consumed":false,"environment":"development","changes":[{"key":"OPENAI_API_KEY","action":"upsert"},{"key":"OLD_KEY","action":"delete"}],"created_at":"2026-10-18T10:00:00Z","expires_at":"` + expires + `","decided_by":null,"decided_at":null,"reason":null}`
+	grant := `{"id":"g1","project_id":"p1","project_name":"Shop","agent_id":"pairing-bot","status":"approved","consumed":true,"environment":"development","changes":[],"created_at":"2026-10-18T09:00:00Z","expires_at":"2026-10-18T09:15:00Z","grant":{"expires_at":"` + time.Now().Add(90*time.Minute).UTC().Format(time.RFC3339) + `","keys":["OPENAI_API_KEY"],"environment":"development","revoked_at":null,"active":true}}`
+	var denied map[string]interface{}
+	revoked := false
+	statusChecks := 0
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer envault_at_reviewer" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/cli/approvals":
+			if r.URL.Query().Get("projectId") != "p1" {
+				t.Errorf("expected the project filter, got %q", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"approvals":[` + request + `],"grants":[` + grant + `]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/cli/approvals/a1":
+			_, _ = w.Write([]byte(`{"approval":` + request + `}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/cli/approvals/g1/revoke":
+			revoked = true
+			_, _ = w.Write([]byte(`{"approval":` + grant + `}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/approve/a1":
+			_ = json.NewDecoder(r.Body).Decode(&denied)
+			_, _ = w.Write([]byte(`{"success":true,"message":"Request has been rejected"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/sdk/approvals/a1/status":
+			statusChecks++
+			if statusChecks == 1 {
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"status":"pending","approval":` + request + `}`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"status":"rejected","approval":{"id":"a1","status":"rejected","reason":"not today"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	bin := buildBinary(t)
+	run := func(args ...string) (string, string, error) {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = tmp
+		cmd.Env = append(os.Environ(),
+			"HOME="+tmp,
+			"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+			"ENVAULT_ALLOW_INSECURE_HTTP=1",
+			"ENVAULT_TOKEN=",
+			"ENVAULT_CREDENTIAL_STORE=env",
+			"ENVAULT_ACCESS_TOKEN=envault_at_reviewer",
+			"NO_COLOR=1",
+		)
+		var outBuf, errBuf bytes.Buffer
+		cmd.Stdout = &outBuf
+		cmd.Stderr = &errBuf
+		err := cmd.Run()
+		return outBuf.String(), errBuf.String(), err
+	}
+
+	stdout, stderr, err := run("approvals", "list", "-p", "p1")
+	if err != nil || !strings.Contains(stdout, "mcp-cli") || !strings.Contains(stdout, "OPENAI_API_KEY, -OLD_KEY") {
+		t.Fatalf("unexpected list output (%v):\n%s\n%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Active grants:") || !strings.Contains(stdout, "pairing-bot") || !strings.Contains(stdout, "1h30m") {
+		t.Fatalf("expected the active grant with its remaining time:\n%s", stdout)
+	}
+
+	stdout, stderr, err = run("approvals", "revoke", "g1")
+	if err != nil || !revoked || !strings.Contains(stdout, "revoked") {
+		t.Fatalf("revoke failed (%v):\n%s\n%s", err, stdout, stderr)
+	}
+
+	stdout, _, err = run("approvals", "show", "a1", "--json")
+	var shown approvalJSON
+	if err != nil || json.Unmarshal([]byte(stdout), &shown) != nil || shown.AgentID != "mcp-cli" || len(shown.Changes) != 2 || shown.Environment != "development" {
+		t.Fatalf("unexpected show --json output (%v):\n%s", err, stdout)
+	}
+
+	stdout, stderr, err = run("approvals", "deny", "a1", "--reason", "not today")
+	if err != nil || !strings.Contains(stdout, "rejected") {
+		t.Fatalf("deny failed (%v):\n%s\n%s", err, stdout, stderr)
+	}
+	if denied["action"] != "reject" || denied["reason"] != "not today" {
+		t.Fatalf("unexpected deny payload: %v", denied)
+	}
+
+	_, stderr, err = run("approvals", "wait", "a1", "--interval", "10ms")
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 || !strings.Contains(stderr, "Reason: not today") {
+		t.Fatalf("expected wait to exit 1 on rejection, got err=%v stderr=%s", err, stderr)
+	}
+	if statusChecks != 2 {
+		t.Fatalf("expected wait to poll until the decision, got %d checks", statusChecks)
+	}
+}
@@ -1,115 +1,68 @@
 package cmd
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
+	"time"
 
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-type approveResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Error   string `json:"error"`
-}
+var (
+	approveFor    string
+	approveKeys   []string
+	approveEnv    string
+	approveReason string
+)
 
 var approveCmd = &cobra.Command{
 	Use:   "approve <approval_id>",
 	Short: "Approve a pending agent request without opening the browser",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		approvalID := strings.TrimSpace(args[0])
-		if approvalID == "" {
-			fmt.Fprintln(os.Stderr, ui.ColorRed("Approval ID is required."))
-			os.Exit(1)
-		}
+	Long: `Approve a pending agent request.
+
+--keys and --env narrow what the approval covers: it fails if the request
+changes other keys or targets another environment. --for turns the approval
+into a time-boxed grant, so the same agent's later requests for those keys
+in that environment are applied without asking again until the grant ends
+or is revoked with 'envault approvals revoke'.
 
-		token := strings.TrimSpace(viper.GetString("auth.token"))
-		if token == "" {
-			fmt.Fprintln(os.Stderr, ui.ColorRed("No local access token found in config.toml."))
-			fmt.Fprintln(os.Stderr, ui.ColorYellow("Run `envault login` and retry."))
-			os.Exit(1)
+  envault approve <id> --for 2h --keys OPENAI_API_KEY,DB_URL --env development --reason "pairing session"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		body := map[string]interface{}{"action": "approve"}
+		constraints := map[string]interface{}{}
+		if strings.TrimSpace(approveFor) != "" {
+			duration, err := offlinecache.ParseMaxAge(approveFor)
+			if err != nil || duration < time.Minute {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --for %q: expected a duration of at least a minute, such as 30m or 2h.", approveFor)))
+				os.Exit(1)
+			}
+			constraints["duration_seconds"] = int(duration / time.Second)
 		}
-		if !strings.HasPrefix(token, "envault_at_") {
-			fmt.Fprintln(os.Stderr, ui.ColorRed("The stored token is not a local CLI access token (envault_at_)."))
-			fmt.Fprintln(os.Stderr, ui.ColorYellow("Run `envault login` to refresh your local access token and retry."))
-			os.Exit(1)
+		keys := []string{}
+		for _, key := range approveKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
 		}
-
-		baseURL := strings.TrimSpace(os.Getenv("NEXT_PUBLIC_APP_URL"))
-		if baseURL == "" {
-			baseURL = "https://envault.tech"
+		if len(keys) > 0 {
+			constraints["keys"] = keys
 		}
-		baseURL = strings.TrimRight(baseURL, "/")
-
-		payloadBytes, err := json.Marshal(map[string]string{"action": "approve"})
-		if err != nil {
-			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to encode approval payload: %v", err)))
-			os.Exit(1)
+		if env := strings.TrimSpace(approveEnv); env != "" {
+			constraints["environment"] = env
 		}
-
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-		defer signal.Stop(sigCh)
-		go func() {
-			select {
-			case <-sigCh:
-				cancel()
-			case <-ctx.Done():
-			}
-		}()
-
-		req, err := http.NewRequestWithContext(
-			ctx,
-			http.MethodPost,
-			fmt.Sprintf("%s/api/approve/%s", baseURL, approvalID),
-			bytes.NewReader(payloadBytes),
-		)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to create request: %v", err)))
-			os.Exit(1)
+		if len(constraints) > 0 {
+			body["constraints"] = constraints
 		}
-
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+token)
-
-		loader := ui.NewLoader(ui.LoaderThemeSync, "Submitting approval...")
-		loader.Start()
-		resp, err := (&http.Client{}).Do(req)
-		loader.Stop()
-		if err != nil {
-			if ctx.Err() != nil {
-				fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
-				os.Exit(130)
-			}
-			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Approval failed: %v", err)))
-			os.Exit(1)
+		if reason := strings.TrimSpace(approveReason); reason != "" {
+			body["reason"] = reason
 		}
-		defer resp.Body.Close()
 
-		var out approveResponse
-		_ = json.NewDecoder(resp.Body).Decode(&out)
-
-		if resp.StatusCode >= 400 {
-			errMessage := strings.TrimSpace(out.Error)
-			if errMessage == "" {
-				errMessage = fmt.Sprintf("request failed with status %d", resp.StatusCode)
-			}
-			fmt.Fprintln(os.Stderr, ui.ColorRed("Approval failed."))
-			fmt.Fprintln(os.Stderr, ui.ColorRed(errMessage))
-			os.Exit(1)
-		}
+		client := approvalClientOrExit()
+		out := submitApprovalDecisionOrExit(client, args[0], body, "Submitting approval...")
 
 		successMessage := strings.TrimSpace(out.Message)
 		if successMessage == "" {
@@ -117,9 +70,24 @@ var approveCmd = &cobra.Command{
 		}
 
 		fmt.Println(ui.ColorGreen("[OK] " + successMessage))
+		field := func(label, value string) {
+			if value != "" {
+				fmt.Printf("%s %s\n", ui.ColorBold(label+":"), value)
+			}
+		}
+		field("Environment", out.Environment)
+		field("Keys", strings.Join(out.Keys, ", "))
+		field("Reason", out.Reason)
+		if out.Grant != nil {
+			field("Grant", describeGrant(*out.Grant))
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(approveCmd)
+	approveCmd.Flags().StringVar(&approveFor, "for", "", "Keep approving this agent's matching requests for this long, such as 2h (max 24h)")
+	approveCmd.Flags().StringSliceVar(&approveKeys, "keys", nil, "Only approve changes to these keys (comma-separated)")
+	approveCmd.Flags().StringVar(&approveEnv, "env", "", "Only approve changes in this environment")
+	approveCmd.Flags().StringVar(&approveReason, "reason", "", "Why the request is approved, recorded in the audit log")
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestApproveCmd_Success(t *testing.T) {
@@ -41,7 +43,11 @@ func TestApproveCmd_Success(t *testing.T) {
 	cmd := exec.Command(bin, "approve", "approval-123")
 	cmd.Env = append(os.Environ(),
 		"HOME="+home,
-		"NEXT_PUBLIC_APP_URL="+mockSrv.URL,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_TOKEN=",
+		// Keep the legacy token out of the developer's real keyring.
+		"ENVAULT_CREDENTIAL_STORE=env",
 	)
 
 	var outBuf, errBuf bytes.Buffer
@@ -73,7 +79,7 @@ func TestApproveCmd_RejectsNonAccessToken(t *testing.T) {
 
 	bin := buildBinary(t)
 	cmd := exec.Command(bin, "approve", "approval-123")
-	cmd.Env = append(os.Environ(), "HOME="+home)
+	cmd.Env = append(os.Environ(), "HOME="+home, "ENVAULT_CREDENTIAL_STORE=env", "ENVAULT_TOKEN=")
 
 	var outBuf, errBuf bytes.Buffer
 	cmd.Stdout = &outBuf
@@ -87,3 +93,45 @@ func TestApproveCmd_RejectsNonAccessToken(t *testing.T) {
 		t.Fatalf("expected token-format validation error, got stderr:\n%s", errBuf.String())
 	}
 }
+
+func TestApproveCmd_SendsConstraints(t *testing.T) {
+	var body map[string]interface{}
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/approve/approval-123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"message":"Request has been approved","environment":"development","keys":["OPENAI_API_KEY","DB_URL"],"reason":"pairing session","grant":{"expires_at":"` + time.Now().Add(2*time.Hour).UTC().Format(time.RFC3339) + `","keys":["OPENAI_API_KEY","DB_URL"],"environment":"development","revoked_at":null,"active":true}}`))
+	}))
+	defer mockSrv.Close()
+
+	home := t.TempDir()
+	bin := buildBinary(t)
+	cmd := exec.Command(bin, "approve", "approval-123", "--for", "2h", "--keys", "OPENAI_API_KEY,DB_URL", "--env", "development", "--reason", "pairing session")
+	cmd.Env = append(os.Environ(),
+		"HOME="+home,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_TOKEN=",
+		"ENVAULT_CREDENTIAL_STORE=env",
+		"ENVAULT_ACCESS_TOKEN=envault_at_test-token",
+		"NO_COLOR=1",
+	)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("approve command failed: %v\nstderr:\n%s", err, errBuf.String())
+	}
+
+	constraints, _ := body["constraints"].(map[string]interface{})
+	keys, _ := constraints["keys"].([]interface{})
+	if body["action"] != "approve" || body["reason"] != "pairing session" || constraints["duration_seconds"] != float64(7200) || len(keys) != 2 || constraints["environment"] != "development" {
+		t.Fatalf("unexpected approval payload: %v", body)
+	}
+	if out := outBuf.String(); !strings.Contains(out, "Grant: OPENAI_API_KEY, DB_URL in development until") || !strings.Contains(out, "Reason: pairing session") {
+		t.Fatalf("expected the constraints to be shown back, got:\n%s", out)
+	}
+}
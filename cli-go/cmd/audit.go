@@ -104,15 +104,10 @@ func installPreCommitHook() (alreadyInstalled bool, binPath string, err error) {
 	// (/opt/homebrew/Cellar/envault/1.20.0/bin/envault).  Using the symlink
 	// means the hook survives `brew upgrade --formula envault` without needing
 	// re-installation.  Fall back to os.Executable() when not on PATH.
-	execPath, lookErr := exec.LookPath("envault")
-	if lookErr != nil {
-		var execErr error
-		execPath, execErr = os.Executable()
-		if execErr != nil {
-			return false, "", fmt.Errorf("could not determine executable path: %w", execErr)
-		}
+	execPath, err := resolveEnvaultBinary()
+	if err != nil {
+		return false, "", err
 	}
-	execPath, _ = filepath.Abs(execPath)
 
 	hooksDir := ".git/hooks"
 	hookPath := hooksDir + "/pre-commit"
@@ -160,6 +155,21 @@ func installPreCommitHook() (alreadyInstalled bool, binPath string, err error) {
 	return false, execPath, nil
 }
 
+// resolveEnvaultBinary returns the path other programs should use to run
+// this CLI, preferring the one on PATH.
+func resolveEnvaultBinary() (string, error) {
+	execPath, lookErr := exec.LookPath("envault")
+	if lookErr != nil {
+		var execErr error
+		execPath, execErr = os.Executable()
+		if execErr != nil {
+			return "", fmt.Errorf("could not determine executable path: %w", execErr)
+		}
+	}
+	execPath, _ = filepath.Abs(execPath)
+	return execPath, nil
+}
+
 // runInstallHook is the CLI-facing wrapper for `envault audit --install-hook`.
 // It calls installPreCommitHook and handles printing / os.Exit behaviour.
 func runInstallHook() {
@@ -189,18 +199,39 @@ func runInstallHook() {
 func runAudit() {
 	loader := ui.NewLoader(ui.LoaderThemeCheck, "ScanGrid running audit checks...")
 	loader.Start()
+	result := collectAuditResult(auditStrict, loader.SetMessage)
+	loader.Stop()
+
+	switch auditFormat {
+	case "json":
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+	default:
+		printAuditResult(result)
+	}
+
+	if !result.Passed {
+		os.Exit(1)
+	}
+}
+
+// collectAuditResult runs every check against auditEnvFile and
+// auditTemplate. progress, when non-nil, is told which check is running.
+func collectAuditResult(strict bool, progress func(string)) AuditResult {
+	if progress == nil {
+		progress = func(string) {}
+	}
 	var issues []AuditIssue
 
-	loader.SetMessage("ScanGrid verifying .gitignore coverage...")
+	progress("ScanGrid verifying .gitignore coverage...")
 	issues = append(issues, checkGitignore()...)
-	loader.SetMessage("ScanGrid checking tracked env files...")
+	progress("ScanGrid checking tracked env files...")
 	issues = append(issues, checkTrackedEnvFiles()...)
-	loader.SetMessage("ScanGrid validating key parity...")
+	progress("ScanGrid validating key parity...")
 	issues = append(issues, checkParity()...)
-	loader.Stop()
 
 	// Strict mode: promote all warnings to errors.
-	if auditStrict {
+	if strict {
 		for i := range issues {
 			if issues[i].Level == "warning" {
 				issues[i].Level = "error"
@@ -220,23 +251,11 @@ func runAudit() {
 		}
 	}
 
-	result := AuditResult{
+	return AuditResult{
 		Passed:  errCount == 0,
 		Issues:  issues,
 		Summary: AuditSummary{Errors: errCount, Warnings: warnCount},
 	}
-
-	switch auditFormat {
-	case "json":
-		data, _ := json.MarshalIndent(result, "", "  ")
-		fmt.Println(string(data))
-	default:
-		printAuditResult(result)
-	}
-
-	if !result.Passed {
-		os.Exit(1)
-	}
 }
 
 // --- Check: .gitignore --------------------------------------------------------
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/bundle"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	bundlePassphraseEnv = "ENVAULT_BUNDLE_PASSPHRASE"
+	bundleIdentityEnv   = "ENVAULT_BUNDLE_IDENTITY"
+)
+
+var (
+	bundleRecipients []string
+	bundlePassphrase bool
+	bundleExpires    string
+	bundleOutput     string
+	bundleForce      bool
+	bundleIdentity   string
+	bundleSigners    []string
+	bundleSkipSigner bool
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Carry secrets to machines with no network access",
+	Long: `Create and import encrypted, signed bundles of one environment's secrets for
+air-gapped machines.
+
+A bundle is signed with this machine's bundle signing key, kept in the
+credential store, and encrypted with age to one or more X25519 recipients
+(age1..., see age-keygen) or to a passphrase. It carries the project,
+environment, creation time and an expiry that import and run --bundle
+enforce.
+
+On the receiving side, --identity (or ENVAULT_BUNDLE_IDENTITY) names an age
+identity file; without one, the passphrase is read from
+ENVAULT_BUNDLE_PASSPHRASE or prompted for. --signer, or bundle.trusted_signers
+in config.toml, pins which signing keys are accepted; a bundle is refused
+when none are pinned unless --insecure-skip-signer is given.`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Write an encrypted bundle of one environment's secrets",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (len(bundleRecipients) > 0) == bundlePassphrase {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Pass either --recipient (repeatable) or --passphrase."))
+			os.Exit(1)
+		}
+		validFor, err := offlinecache.ParseMaxAge(bundleExpires)
+		if err != nil || validFor <= 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --expires %q: expected a positive duration such as 72h or 7d.", bundleExpires)))
+			os.Exit(1)
+		}
+
+		var recipients []age.Recipient
+		if bundlePassphrase {
+			passphrase := readBundlePassphraseOrExit(true)
+			recipient, err := bundle.PassphraseRecipient(passphrase)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid passphrase: %v", err)))
+				os.Exit(1)
+			}
+			recipients = []age.Recipient{recipient}
+		} else {
+			recipients, err = bundle.ParseRecipients(bundleRecipients)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+				os.Exit(1)
+			}
+		}
+
+		projectID := strings.TrimSpace(projectFlag)
+		if projectID == "" {
+			projectID = ensureProjectID()
+		}
+		if !isValidProjectID(projectID) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("No valid project. Pass --project or run inside a linked project."))
+			os.Exit(1)
+		}
+		environment, err := resolveTargetEnvironmentForProject(projectID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(1)
+		}
+		environment = strings.ToLower(environment)
+
+		output := strings.TrimSpace(bundleOutput)
+		if output == "" {
+			output = fmt.Sprintf("%s-%s.bundle", projectID[:8], environment)
+		}
+		if _, err := os.Stat(output); err == nil && !bundleForce {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("%s already exists. Pass --force to overwrite it.", output)))
+			os.Exit(1)
+		}
+
+		key, err := bundle.SigningKey()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(1)
+		}
+		if store, err := credstore.Default(); err == nil && !store.Persistent() {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("Warning: the signing key is not persisted, so this bundle's signer cannot be pinned for later bundles. Set ENVAULT_BUNDLE_SIGNING_KEY or use a persistent credential store."))
+		}
+
+		client := api.NewClient()
+		loader := ui.NewLoader(ui.LoaderThemeFetch, fmt.Sprintf("Fetching %s secrets for the bundle...", environment))
+		loader.Start()
+		warnings := []string{}
+		secrets, err := fetchEnvironmentSecrets(context.Background(), client, projectID, environment, 0, func(key string, err error) {
+			warnings = append(warnings, fmt.Sprintf("Warning: failed to decrypt secret '%s': %v", key, err))
+		})
+		loader.Stop()
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(w))
+		}
+		if err != nil {
+			if handleEnvironmentAccessDenied(err, environment) {
+				os.Exit(1)
+			}
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Bundle failed."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+		if len(warnings) > 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Refusing to bundle secrets that could not be decrypted."))
+			os.Exit(1)
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		payload := bundle.Payload{
+			ProjectID:   projectID,
+			Environment: environment,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(validFor),
+			Secrets:     secrets,
+		}
+		data, err := bundle.Seal(payload, key, recipients)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(1)
+		}
+		if err := os.WriteFile(output, data, 0600); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to write bundle: %v", err)))
+			os.Exit(1)
+		}
+		_ = os.Chmod(output, 0600)
+
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Wrote %s: %d secret%s from %s (%s), expires %s.",
+			output, len(secrets), pluralSuffix(len(secrets), "", "s"), projectID, environment, payload.ExpiresAt.Local().Format(time.RFC3339))))
+		fmt.Printf("Signed by %s\n", bundle.FormatSigner(key.Public().(ed25519.PublicKey)))
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Load a bundle into the offline cache",
+	Long: `Verify a bundle and store its secrets in the offline cache, where run finds
+them with --offline or when the API is unreachable. The entry keeps the
+bundle's creation time and is refused once the bundle expires. An entry that
+is newer than the bundle is only replaced with --force.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		payload := openBundleOrExit(args[0])
+		err := offlinecache.Import(payload.ProjectID, payload.Environment, payload.Secrets, payload.CreatedAt, payload.ExpiresAt, bundleForce)
+		if errors.Is(err, offlinecache.ErrNewerEntry) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Import refused: %v. Pass --force to replace it with the bundle.", err)))
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to import bundle: %v", err)))
+			os.Exit(1)
+		}
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Imported %d secret%s for %s (%s) into the offline cache.",
+			len(payload.Secrets), pluralSuffix(len(payload.Secrets), "", "s"), payload.ProjectID, payload.Environment)))
+	},
+}
+
+var bundleSignerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Print this machine's bundle signing key",
+	Long: `Print the public half of this machine's bundle signing key, to pin it on
+receiving machines with --signer or bundle.trusted_signers.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := bundle.SigningKey()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(bundle.FormatSigner(key.Public().(ed25519.PublicKey)))
+	},
+}
+
+// openBundleOrExit decrypts and verifies a bundle, checks its signer against
+// the pinned signers and enforces its expiry.
+func openBundleOrExit(path string) bundle.Payload {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to read bundle: %v", err)))
+		os.Exit(1)
+	}
+
+	identities, err := bundleIdentities()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+		os.Exit(1)
+	}
+	payload, signer, err := bundle.Open(data, identities)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+		os.Exit(1)
+	}
+
+	trusted, err := trustedBundleSigners()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+		os.Exit(1)
+	}
+	// The bundle carries its own signer key, so a signature alone proves
+	// nothing: anyone holding the passphrase or a recipient key could have
+	// made it. Only a pinned signer makes it trustworthy.
+	switch {
+	case len(trusted) > 0 && !slices.Contains(trusted, signer):
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Bundle is signed by %s, which is not a trusted signer.", signer)))
+		os.Exit(1)
+	case len(trusted) == 0 && !bundleSkipSigner:
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Bundle is signed by %s, but no trusted signers are pinned.", signer)))
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Check the key with its creator ('envault bundle signer'), then pass --signer or set bundle.trusted_signers."))
+		os.Exit(1)
+	case len(trusted) == 0:
+		fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: accepting bundle signed by unpinned signer %s.", signer)))
+	}
+
+	if err := payload.CheckExpiry(time.Now()); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Bundle for %s (%s), created %s, expires %s.",
+		payload.ProjectID, payload.Environment, payload.CreatedAt.Local().Format(time.RFC3339), payload.ExpiresAt.Local().Format(time.RFC3339))))
+	return payload
+}
+
+// bundleIdentities returns the age identities from --identity or
+// ENVAULT_BUNDLE_IDENTITY, or a passphrase identity when neither is set.
+func bundleIdentities() ([]age.Identity, error) {
+	path := strings.TrimSpace(bundleIdentity)
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv(bundleIdentityEnv))
+	}
+	if path == "" {
+		identity, err := bundle.PassphraseIdentity(readBundlePassphraseOrExit(false))
+		if err != nil {
+			return nil, fmt.Errorf("invalid passphrase: %w", err)
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file: %w", err)
+	}
+	defer f.Close()
+	return bundle.ParseIdentities(f)
+}
+
+// trustedBundleSigners returns the signers pinned with --signer and
+// bundle.trusted_signers.
+func trustedBundleSigners() ([]string, error) {
+	trusted := []string{}
+	for _, s := range append(slices.Clone(bundleSigners), viper.GetStringSlice("bundle.trusted_signers")...) {
+		if _, err := bundle.ParseSigner(s); err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, strings.TrimSpace(s))
+	}
+	return trusted, nil
+}
+
+// readBundlePassphraseOrExit reads ENVAULT_BUNDLE_PASSPHRASE, or prompts for
+// the passphrase, twice when confirm is set.
+func readBundlePassphraseOrExit(confirm bool) string {
+	if passphrase := os.Getenv(bundlePassphraseEnv); passphrase != "" {
+		return passphrase
+	}
+	if Headless {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Error: set %s or pass --identity in headless mode.", bundlePassphraseEnv)))
+		os.Exit(1)
+	}
+
+	passphrase := ""
+	if err := survey.AskOne(&survey.Password{Message: "Bundle passphrase:"}, &passphrase, survey.WithValidator(survey.Required)); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+		os.Exit(1)
+	}
+	if confirm {
+		again := ""
+		if err := survey.AskOne(&survey.Password{Message: "Confirm passphrase:"}, &again); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+			os.Exit(1)
+		}
+		if again != passphrase {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Passphrases do not match."))
+			os.Exit(1)
+		}
+	}
+	return passphrase
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleImportCmd)
+	bundleCmd.AddCommand(bundleSignerCmd)
+
+	bundleCreateCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+	bundleCreateCmd.Flags().StringArrayVar(&bundleRecipients, "recipient", nil, "age X25519 recipient (age1...); repeatable")
+	bundleCreateCmd.Flags().BoolVar(&bundlePassphrase, "passphrase", false, "Encrypt to a passphrase instead of recipients")
+	bundleCreateCmd.Flags().StringVar(&bundleExpires, "expires", "7d", "How long the bundle stays valid (e.g. 72h, 7d)")
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Bundle file to write (default <project>-<env>.bundle)")
+	bundleCreateCmd.Flags().BoolVarP(&bundleForce, "force", "f", false, "Overwrite an existing bundle file")
+
+	bundleImportCmd.Flags().BoolVarP(&bundleForce, "force", "f", false, "Replace a cache entry newer than the bundle")
+	bundleImportCmd.Flags().StringVar(&bundleIdentity, "identity", "", "age identity file that decrypts the bundle")
+	bundleImportCmd.Flags().StringArrayVar(&bundleSigners, "signer", nil, "Trusted bundle signer (envault-signer:...); repeatable")
+	bundleImportCmd.Flags().BoolVar(&bundleSkipSigner, "insecure-skip-signer", false, "Accept a bundle from any signer when none are pinned")
+	runCmd.Flags().StringVar(&bundleIdentity, "bundle-identity", "", "age identity file that decrypts --bundle")
+	runCmd.Flags().StringArrayVar(&bundleSigners, "bundle-signer", nil, "Trusted --bundle signer (envault-signer:...); repeatable")
+	runCmd.Flags().BoolVar(&bundleSkipSigner, "bundle-insecure-skip-signer", false, "Accept a --bundle from any signer when none are pinned")
+}
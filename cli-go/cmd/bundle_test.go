@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestBundleCmd_CreateThenRunAndImportWithoutNetwork(t *testing.T) {
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/environments"):
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"staging","isDefault":true}]}`))
+		case strings.Contains(r.URL.Path, "/secrets"):
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"RIG_TOKEN","value":"rig-token-value"}]}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := buildBinary(t)
+	creator := t.TempDir()
+	bundlePath := filepath.Join(creator, "rig.bundle")
+
+	create := exec.Command(bin, "bundle", "create", "-p", testAppProject, "--env", "staging",
+		"--recipient", identity.Recipient().String(), "--expires", "2h", "-o", bundlePath)
+	create.Dir = creator
+	create.Env = append(os.Environ(),
+		"HOME="+creator,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_CREDENTIAL_STORE=file",
+		"ENVAULT_PASSPHRASE=creator-passphrase",
+		"NO_COLOR=1",
+	)
+	var createOut, createErr bytes.Buffer
+	create.Stdout = &createOut
+	create.Stderr = &createErr
+	if err := create.Run(); err != nil {
+		t.Fatalf("bundle create failed: %v\nstderr:\n%s", err, createErr.String())
+	}
+	mockSrv.Close()
+
+	var signer string
+	for _, line := range strings.Split(createOut.String(), "\n") {
+		if after, ok := strings.CutPrefix(line, "Signed by "); ok {
+			signer = strings.TrimSpace(after)
+		}
+	}
+	if signer == "" {
+		t.Fatalf("expected the signer in the output:\n%s", createOut.String())
+	}
+	raw, _ := os.ReadFile(bundlePath)
+	if bytes.Contains(raw, []byte("rig-token-value")) {
+		t.Fatal("bundle file contains a plaintext value")
+	}
+
+	// The rig has no network: the API URL points at the closed server.
+	rig := t.TempDir()
+	identityPath := filepath.Join(rig, "rig.key")
+	_ = os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0600)
+	rigEnv := append(os.Environ(),
+		"HOME="+rig,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_CREDENTIAL_STORE=file",
+		"ENVAULT_PASSPHRASE=rig-passphrase",
+		"NO_COLOR=1",
+	)
+
+	run := exec.Command(bin, "run", "--bundle", bundlePath, "--bundle-identity", identityPath, "--bundle-signer", signer,
+		"--", "sh", "-c", `printf '%s' "$RIG_TOKEN"`)
+	run.Dir = rig
+	run.Env = rigEnv
+	var runOut, runErr bytes.Buffer
+	run.Stdout = &runOut
+	run.Stderr = &runErr
+	if err := run.Run(); err != nil {
+		t.Fatalf("run --bundle failed: %v\nstderr:\n%s", err, runErr.String())
+	}
+	if runOut.String() != "rig-token-value" {
+		t.Fatalf("expected the bundled secret, got %q\nstderr:\n%s", runOut.String(), runErr.String())
+	}
+
+	untrusted := exec.Command(bin, "bundle", "import", bundlePath, "--identity", identityPath,
+		"--signer", "envault-signer:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+	untrusted.Dir = rig
+	untrusted.Env = rigEnv
+	if out, err := untrusted.CombinedOutput(); err == nil || !strings.Contains(string(out), "not a trusted signer") {
+		t.Fatalf("expected an untrusted signer to be refused, got err=%v\n%s", err, out)
+	}
+
+	unpinned := exec.Command(bin, "bundle", "import", bundlePath, "--identity", identityPath)
+	unpinned.Dir = rig
+	unpinned.Env = rigEnv
+	if out, err := unpinned.CombinedOutput(); err == nil || !strings.Contains(string(out), "no trusted signers are pinned") {
+		t.Fatalf("expected a bundle without pinned signers to be refused, got err=%v\n%s", err, out)
+	}
+
+	skipped := exec.Command(bin, "bundle", "import", bundlePath, "--identity", identityPath, "--insecure-skip-signer")
+	skipped.Dir = rig
+	skipped.Env = rigEnv
+	if out, err := skipped.CombinedOutput(); err != nil || !strings.Contains(string(out), "unpinned signer") {
+		t.Fatalf("expected --insecure-skip-signer to accept the bundle with a warning, got err=%v\n%s", err, out)
+	}
+
+	imp := exec.Command(bin, "bundle", "import", bundlePath, "--identity", identityPath, "--signer", signer, "--force")
+	imp.Dir = rig
+	imp.Env = rigEnv
+	if out, err := imp.CombinedOutput(); err != nil {
+		t.Fatalf("bundle import failed: %v\n%s", err, out)
+	}
+
+	_ = os.WriteFile(filepath.Join(rig, "envault.json"), []byte(`{"projectId":"`+testAppProject+`","defaultEnvironment":"staging"}`), 0644)
+	offline := exec.Command(bin, "run", "--offline", "--", "sh", "-c", `printf '%s' "$RIG_TOKEN"`)
+	offline.Dir = rig
+	offline.Env = rigEnv
+	var offOut, offErr bytes.Buffer
+	offline.Stdout = &offOut
+	offline.Stderr = &offErr
+	if err := offline.Run(); err != nil || offOut.String() != "rig-token-value" {
+		t.Fatalf("expected run --offline to use the imported bundle, got %q, err=%v\nstderr:\n%s", offOut.String(), err, offErr.String())
+	}
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var forcePurge bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the encrypted offline cache",
+	Long: `Inspect and manage the encrypted offline cache that run falls back to when
+the Envault API is unreachable. Entry ages, key counts and sizes are shown;
+secret values never are.
+
+A maximum age can be set in ~/.envault/config.toml:
+
+  [cache]
+  max_age = "72h"
+  max_age_mode = "hard"
+
+and per project in envault.json:
+
+  "cache": { "maxAge": "7d", "maxAgeMode": "soft" }
+
+A hard policy refuses older entries; a soft one warns. When both are set,
+both apply.`,
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List offline cache entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		policies := loadCachePoliciesOrExit()
+		entries, unreadable, err := offlinecache.List()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to read offline cache: %v", err)))
+			os.Exit(1)
+		}
+		warnUnreadableCacheEntries(unreadable)
+		entries = filterCacheEntries(entries, projectFlag, envFlag)
+		if len(entries) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("No offline cache entries."))
+			return
+		}
+
+		now := time.Now()
+		rows := [][]string{{"PROJECT", "ENVIRONMENT", "CACHED AT", "AGE", "KEYS", "SIZE", "STATUS"}}
+		for _, e := range entries {
+			status, _ := cacheEntryInfoStatus(e, now, policies)
+			rows = append(rows, []string{
+				e.ProjectID,
+				e.Environment,
+				e.CachedAt.Local().Format(time.RFC3339),
+				humanizeDuration(now.Sub(e.CachedAt)),
+				fmt.Sprint(len(e.Keys)),
+				formatByteSize(e.Size),
+				status,
+			})
+		}
+		printCacheTable(rows)
+	},
+}
+
+var cacheInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Show one offline cache entry's keys, age and size",
+	Long: `Show one offline cache entry's key names, age and size. Defaults to the
+linked project and the target environment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		policies := loadCachePoliciesOrExit()
+		projectID := strings.TrimSpace(projectFlag)
+		if projectID == "" {
+			projectID = ensureProjectID()
+		}
+		if !isValidProjectID(projectID) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("No valid project. Pass --project or run inside a linked project."))
+			os.Exit(1)
+		}
+		environment := strings.ToLower(resolveTargetEnvironment())
+
+		entries, unreadable, err := offlinecache.List()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to read offline cache: %v", err)))
+			os.Exit(1)
+		}
+		warnUnreadableCacheEntries(unreadable)
+		entries = filterCacheEntries(entries, projectID, environment)
+		if len(entries) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("No offline cache entry for %s (%s).", projectID, environment)))
+			os.Exit(1)
+		}
+
+		e := entries[0]
+		now := time.Now()
+		status, detail := cacheEntryInfoStatus(e, now, policies)
+		fmt.Println(ui.ColorBold(fmt.Sprintf("%s (%s)", e.ProjectID, e.Environment)))
+		fmt.Printf("Cached at: %s (%s ago)\n", e.CachedAt.Local().Format(time.RFC3339), humanizeDuration(now.Sub(e.CachedAt)))
+		if !e.ExpiresAt.IsZero() {
+			fmt.Printf("Expires:   %s (imported from a bundle)\n", e.ExpiresAt.Local().Format(time.RFC3339))
+		}
+		fmt.Printf("Size:      %s\n", formatByteSize(e.Size))
+		if detail != "" {
+			fmt.Printf("Status:    %s (%s)\n", status, detail)
+		} else {
+			fmt.Printf("Status:    %s\n", status)
+		}
+		fmt.Printf("Keys (%d):\n", len(e.Keys))
+		for _, key := range e.Keys {
+			fmt.Printf("  %s\n", key)
+		}
+	},
+}
+
+var cachePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete offline cache entries",
+	Long: `Delete offline cache entries. --project and --env narrow the selection;
+without either, every entry is deleted after confirmation.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectID := strings.TrimSpace(projectFlag)
+		environment := strings.TrimSpace(envFlag)
+
+		if projectID == "" && environment == "" && !forcePurge {
+			if Headless {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Error: purging the whole cache needs --force in headless mode, or narrow it with --project/--env."))
+				os.Exit(1)
+			}
+			confirm := false
+			prompt := &survey.Confirm{Message: "Delete every offline cache entry?"}
+			if err := survey.AskOne(prompt, &confirm); err != nil || !confirm {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+				return
+			}
+		}
+
+		purged, unreadable, err := offlinecache.Purge(projectID, environment)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to purge offline cache: %v", err)))
+			os.Exit(1)
+		}
+		kept := []offlinecache.UnreadableEntry{}
+		deleted := len(purged)
+		for _, e := range unreadable {
+			if !e.Removed {
+				kept = append(kept, e)
+				continue
+			}
+			fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Deleted unreadable entry %s", e.File)))
+			deleted++
+		}
+		warnUnreadableCacheEntries(kept)
+		if deleted == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("No matching offline cache entries."))
+			return
+		}
+		for _, e := range purged {
+			fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Deleted %s (%s)", e.ProjectID, e.Environment)))
+		}
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Purged %d offline cache entr%s.", deleted, pluralSuffix(deleted, "y", "ies"))))
+	},
+}
+
+// warnUnreadableCacheEntries reports cache files that were skipped because
+// they could not be decrypted or do not match their name.
+func warnUnreadableCacheEntries(entries []offlinecache.UnreadableEntry) {
+	for _, e := range entries {
+		fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: skipped unreadable offline cache entry %s: %v", e.File, e.Err)))
+	}
+	if len(entries) > 0 {
+		fmt.Fprintln(os.Stderr, ui.ColorDim("Run 'envault cache purge' to remove unreadable entries."))
+	}
+}
+
+// loadCachePolicies returns the max age policies from the user config and the
+// project's envault.json, in that order. Either may be absent.
+func loadCachePolicies() ([]offlinecache.Policy, error) {
+	policies := []offlinecache.Policy{}
+
+	if maxAge := strings.TrimSpace(viper.GetString("cache.max_age")); maxAge != "" {
+		policy, err := offlinecache.ParsePolicy(maxAge, viper.GetString("cache.max_age_mode"), "config.toml")
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	cfg, err := project.ReadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read envault.json: %w", err)
+	}
+	if cfg.Cache != nil && strings.TrimSpace(cfg.Cache.MaxAge) != "" {
+		policy, err := offlinecache.ParsePolicy(cfg.Cache.MaxAge, cfg.Cache.MaxAgeMode, "envault.json")
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func loadCachePoliciesOrExit() []offlinecache.Policy {
+	policies, err := loadCachePolicies()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid cache policy: %v", err)))
+		os.Exit(1)
+	}
+	return policies
+}
+
+// checkCachePolicies returns an error when a hard policy refuses an entry
+// cached at cachedAt, and warnings for each soft policy it exceeds.
+func checkCachePolicies(cachedAt time.Time, now time.Time, policies []offlinecache.Policy) ([]string, error) {
+	warnings := []string{}
+	for _, p := range policies {
+		if !p.Exceeded(cachedAt, now) {
+			continue
+		}
+		msg := fmt.Sprintf("entry is %s old, older than the %s max age of %s", humanizeDuration(now.Sub(cachedAt)), p.Origin, p.MaxAge)
+		if p.Hard {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		warnings = append(warnings, "Warning: offline cache "+msg+".")
+	}
+	return warnings, nil
+}
+
+// cacheEntryStatus summarizes how the policies treat an entry: "ok",
+// "stale" (a soft limit is exceeded) or "expired" (run would refuse it).
+func cacheEntryStatus(cachedAt, now time.Time, policies []offlinecache.Policy) (string, string) {
+	warnings, err := checkCachePolicies(cachedAt, now, policies)
+	if err != nil {
+		return "expired", err.Error()
+	}
+	if len(warnings) > 0 {
+		return "stale", strings.TrimSuffix(strings.TrimPrefix(warnings[0], "Warning: offline cache "), ".")
+	}
+	return "ok", ""
+}
+
+// cacheEntryInfoStatus is cacheEntryStatus that also honors the expiry of an
+// entry imported from a bundle.
+func cacheEntryInfoStatus(e offlinecache.EntryInfo, now time.Time, policies []offlinecache.Policy) (string, string) {
+	if !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt) {
+		return "expired", fmt.Sprintf("bundle expired at %s", e.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	return cacheEntryStatus(e.CachedAt, now, policies)
+}
+
+func filterCacheEntries(entries []offlinecache.EntryInfo, projectID, environment string) []offlinecache.EntryInfo {
+	projectID = strings.TrimSpace(projectID)
+	environment = strings.ToLower(strings.TrimSpace(environment))
+	filtered := []offlinecache.EntryInfo{}
+	for _, e := range entries {
+		if projectID != "" && e.ProjectID != projectID {
+			continue
+		}
+		if environment != "" && e.Environment != environment {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+func printCacheTable(rows [][]string) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := lipgloss.Width(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+		}
+		line := strings.TrimRight(strings.Join(cells, "  "), " ")
+		if r == 0 {
+			line = ui.ColorBold(line)
+		}
+		fmt.Println(line)
+	}
+}
+
+func formatByteSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+	}
+}
+
+func pluralSuffix(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheLsCmd)
+	cacheCmd.AddCommand(cacheInspectCmd)
+	cacheCmd.AddCommand(cachePurgeCmd)
+
+	for _, c := range []*cobra.Command{cacheLsCmd, cacheInspectCmd, cachePurgeCmd} {
+		c.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+	}
+	cachePurgeCmd.Flags().BoolVarP(&forcePurge, "force", "f", false, "Purge every entry without confirmation")
+}
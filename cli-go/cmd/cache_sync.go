@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// cacheSyncWorkers is the default bound on concurrent requests during sync.
+const cacheSyncWorkers = 4
+
+// cacheSyncHookMarker identifies hook lines written by --schedule.
+const cacheSyncHookMarker = "# envault cache sync"
+
+// cacheSyncHooks are the git hooks --schedule registers.
+var cacheSyncHooks = []string{"post-merge", "post-checkout"}
+
+var (
+	cacheSyncAllProjects bool
+	cacheSyncSchedule    bool
+	cacheSyncConcurrency int
+)
+
+type cacheSyncJob struct {
+	projectID   string
+	environment string
+}
+
+type cacheSyncResult struct {
+	job cacheSyncJob
+	err error
+}
+
+var cacheSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Fetch every authorized environment into the offline cache",
+	Long: `Fetch every environment you are authorized for and store it in the offline
+cache, so run keeps working before a flight or a planned outage. Defaults to
+the linked project; --all-projects syncs every project you can see.
+
+--schedule also registers post-merge and post-checkout git hooks that run the
+same sync in the background, keeping the cache warm.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cacheSyncConcurrency < 1 {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--concurrency must be at least 1."))
+			os.Exit(1)
+		}
+		if cacheSyncAllProjects && strings.TrimSpace(projectFlag) != "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--all-projects and --project cannot be used together."))
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigCh)
+		go func() {
+			select {
+			case <-sigCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if cacheSyncSchedule {
+			scheduleCacheSyncOrExit()
+		}
+
+		client := api.NewClient()
+		var projectIDs []string
+		if cacheSyncAllProjects {
+			ids, err := listProjectIDs(ctx, client)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to list projects."))
+				fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+				os.Exit(1)
+			}
+			projectIDs = ids
+		} else {
+			projectID := strings.TrimSpace(projectFlag)
+			if projectID == "" {
+				projectID = ensureProjectID()
+			}
+			if !isValidProjectID(projectID) {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("No valid project. Pass --project, --all-projects, or run inside a linked project."))
+				os.Exit(1)
+			}
+			projectIDs = []string{projectID}
+		}
+		if len(projectIDs) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("No projects found."))
+			return
+		}
+
+		loader := ui.NewLoader(ui.LoaderThemeSync, fmt.Sprintf("Syncing offline cache for %d project(s)...", len(projectIDs)))
+		loader.Start()
+		jobs, listFailures := listCacheSyncJobs(ctx, client, projectIDs, cacheSyncConcurrency)
+		results := runCacheSyncJobs(ctx, client, jobs, cacheSyncConcurrency)
+		loader.Stop()
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("\nOperation cancelled."))
+			os.Exit(130)
+		}
+
+		results = append(results, listFailures...)
+		if !printCacheSyncSummary(results) {
+			os.Exit(1)
+		}
+	},
+}
+
+// listProjectIDs returns the IDs of every project the caller can see.
+func listProjectIDs(ctx context.Context, client *api.Client) ([]string, error) {
+	respBytes, err := client.GetWithContext(ctx, "/projects")
+	if err != nil {
+		return nil, err
+	}
+	var resp ProjectResponse
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("invalid projects response: %w", err)
+	}
+	ids := make([]string, 0, len(resp.Projects))
+	for _, p := range resp.Projects {
+		if isValidProjectID(p.ID) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids, nil
+}
+
+// listCacheSyncJobs lists the authorized environments of each project. A
+// project whose environments cannot be listed is returned as a failed result
+// with an empty environment.
+func listCacheSyncJobs(ctx context.Context, client *api.Client, projectIDs []string, workers int) ([]cacheSyncJob, []cacheSyncResult) {
+	environments := make([][]cliEnvironment, len(projectIDs))
+	errs := make([]error, len(projectIDs))
+	runBounded(len(projectIDs), workers, func(i int) {
+		environments[i], errs[i] = requestAuthorizedEnvironments(ctx, client, projectIDs[i])
+	})
+
+	jobs := []cacheSyncJob{}
+	failures := []cacheSyncResult{}
+	for i, projectID := range projectIDs {
+		if errs[i] != nil {
+			failures = append(failures, cacheSyncResult{job: cacheSyncJob{projectID: projectID}, err: errs[i]})
+			continue
+		}
+		for _, env := range environments[i] {
+			jobs = append(jobs, cacheSyncJob{projectID: projectID, environment: strings.ToLower(env.Slug)})
+		}
+	}
+	return jobs, failures
+}
+
+// runCacheSyncJobs fetches each environment and saves it to the offline
+// cache. Results are returned in the order of jobs.
+func runCacheSyncJobs(ctx context.Context, client *api.Client, jobs []cacheSyncJob, workers int) []cacheSyncResult {
+	results := make([]cacheSyncResult, len(jobs))
+	timeout := resolveRunTimeout(client.BaseURL)
+	runBounded(len(jobs), workers, func(i int) {
+		job := jobs[i]
+		results[i] = cacheSyncResult{job: job}
+		secrets, err := fetchEnvironmentSecrets(ctx, client, job.projectID, job.environment, timeout, nil)
+		if err != nil {
+			results[i].err = err
+			return
+		}
+		if err := offlinecache.Save(job.projectID, job.environment, secrets); err != nil {
+			results[i].err = fmt.Errorf("failed to save offline cache: %w", err)
+		}
+	})
+	return results
+}
+
+// printCacheSyncSummary prints what is available offline after the sync and
+// how old it is. It reports whether every environment synced.
+func printCacheSyncSummary(results []cacheSyncResult) bool {
+	entries, unreadable, err := offlinecache.List()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to read offline cache: %v", err)))
+	}
+	warnUnreadableCacheEntries(unreadable)
+	cached := map[cacheSyncJob]offlinecache.EntryInfo{}
+	for _, e := range entries {
+		cached[cacheSyncJob{projectID: e.ProjectID, environment: e.Environment}] = e
+	}
+
+	now := time.Now()
+	rows := [][]string{{"PROJECT", "ENVIRONMENT", "KEYS", "AGE", "STATUS"}}
+	synced := 0
+	for _, r := range results {
+		environment := r.job.environment
+		if environment == "" {
+			environment = "-"
+		}
+		keys, age, status := "-", "-", "synced"
+		if entry, ok := cached[r.job]; ok {
+			keys = fmt.Sprint(len(entry.Keys))
+			age = humanizeDuration(now.Sub(entry.CachedAt))
+		}
+		if r.err != nil {
+			status = "failed: " + strings.TrimPrefix(classifyAPIError(r.err), "Error: ")
+			if _, ok := cached[r.job]; ok {
+				status += " (older entry kept)"
+			}
+		} else {
+			synced++
+		}
+		rows = append(rows, []string{r.job.projectID, environment, keys, age, status})
+	}
+	if len(rows) == 1 {
+		fmt.Fprintln(os.Stderr, ui.ColorYellow("No accessible environments found."))
+		return true
+	}
+	printCacheTable(rows)
+
+	failed := len(results) - synced
+	fmt.Println()
+	if failed == 0 {
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] %d environment%s available offline.", synced, pluralSuffix(synced, "", "s"))))
+		return true
+	}
+	fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Synced %d environment%s; %d failed.", synced, pluralSuffix(synced, "", "s"), failed)))
+	return false
+}
+
+// scheduleCacheSyncOrExit registers the git hooks that keep the cache warm.
+func scheduleCacheSyncOrExit() {
+	out, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Error: --schedule must be run inside a Git repository."))
+		os.Exit(1)
+	}
+	hooksDir := strings.TrimSpace(string(out))
+
+	command := "envault cache sync"
+	if cacheSyncAllProjects {
+		command += " --all-projects"
+	} else if projectID := strings.TrimSpace(projectFlag); projectID != "" {
+		if !isValidProjectID(projectID) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Invalid --project. Expected a UUID."))
+			os.Exit(1)
+		}
+		command += " --project " + projectID
+	}
+	if cacheSyncConcurrency != cacheSyncWorkers {
+		command += fmt.Sprintf(" --concurrency %d", cacheSyncConcurrency)
+	}
+	for _, hook := range cacheSyncHooks {
+		added, err := installCacheSyncHook(filepath.Join(hooksDir, hook), command)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Error installing %s hook: %v", hook, err)))
+			os.Exit(1)
+		}
+		if added {
+			fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Registered %s hook.", hook)))
+		} else {
+			fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("%s hook already syncs the cache.", hook)))
+		}
+	}
+}
+
+// installCacheSyncHook adds a background cache sync to the hook at path,
+// creating it if needed. Existing hook content is kept; a hook that already
+// has the sync is left alone. When the hook ends in exit or exec, the sync is
+// inserted before that line so it still runs.
+func installCacheSyncHook(path, command string) (bool, error) {
+	snippet := fmt.Sprintf(`%s
+if command -v envault >/dev/null 2>&1; then
+    (%s >/dev/null 2>&1 &)
+fi
+`, cacheSyncHookMarker, command)
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if strings.Contains(string(existing), cacheSyncHookMarker) {
+			return false, nil
+		}
+		if err := os.WriteFile(path, []byte(insertHookSnippet(string(existing), snippet)), 0755); err != nil {
+			return false, err
+		}
+		return true, os.Chmod(path, 0755)
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return false, err
+		}
+		return true, os.WriteFile(path, []byte("#!/bin/sh\n"+snippet), 0755)
+	default:
+		return false, err
+	}
+}
+
+// insertHookSnippet appends snippet to a hook script, or places it before the
+// last command when that command is a top-level exit or exec, which would
+// otherwise make anything after it unreachable.
+func insertHookSnippet(content, snippet string) string {
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	lines := strings.SplitAfter(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimRight(lines[i], "\r\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line == trimmed && (trimmed == "exit" || strings.HasPrefix(trimmed, "exit ") || strings.HasPrefix(trimmed, "exec ")) {
+			return strings.Join(lines[:i], "") + snippet + "\n" + strings.Join(lines[i:], "")
+		}
+		break
+	}
+	return content + "\n" + snippet
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheSyncCmd)
+	cacheSyncCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+	cacheSyncCmd.Flags().BoolVar(&cacheSyncAllProjects, "all-projects", false, "Sync every project you can see")
+	cacheSyncCmd.Flags().BoolVar(&cacheSyncSchedule, "schedule", false, "Also register post-merge and post-checkout hooks that sync in the background")
+	cacheSyncCmd.Flags().IntVar(&cacheSyncConcurrency, "concurrency", cacheSyncWorkers, "Maximum concurrent requests")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallCacheSyncHook(t *testing.T) {
+	dir := t.TempDir()
+
+	fresh := filepath.Join(dir, "hooks", "post-checkout")
+	added, err := installCacheSyncHook(fresh, "envault cache sync")
+	if err != nil || !added {
+		t.Fatalf("expected a new hook, got added=%v err=%v", added, err)
+	}
+	content, _ := os.ReadFile(fresh)
+	if !strings.HasPrefix(string(content), "#!/bin/sh\n") || !strings.Contains(string(content), "(envault cache sync >/dev/null 2>&1 &)") {
+		t.Fatalf("unexpected hook content:\n%s", content)
+	}
+	if added, err := installCacheSyncHook(fresh, "envault cache sync"); err != nil || added {
+		t.Fatalf("expected the second install to be a no-op, got added=%v err=%v", added, err)
+	}
+
+	existing := filepath.Join(dir, "post-merge")
+	_ = os.WriteFile(existing, []byte("#!/bin/sh\nnpm install"), 0755)
+	if added, err := installCacheSyncHook(existing, "envault cache sync --all-projects"); err != nil || !added {
+		t.Fatalf("expected the hook to be appended, got added=%v err=%v", added, err)
+	}
+	content, _ = os.ReadFile(existing)
+	if !strings.HasPrefix(string(content), "#!/bin/sh\nnpm install\n") || !strings.Contains(string(content), "envault cache sync --all-projects") {
+		t.Fatalf("existing hook content was not preserved:\n%s", content)
+	}
+
+	exiting := filepath.Join(dir, "post-rewrite")
+	_ = os.WriteFile(exiting, []byte("#!/bin/sh\nnpm install\nexit 0\n"), 0755)
+	if added, err := installCacheSyncHook(exiting, "envault cache sync --project p"); err != nil || !added {
+		t.Fatalf("expected the hook to be updated, got added=%v err=%v", added, err)
+	}
+	content, _ = os.ReadFile(exiting)
+	if sync, exit := strings.Index(string(content), "envault cache sync --project p"), strings.Index(string(content), "exit 0"); sync < 0 || exit < sync || !strings.HasSuffix(string(content), "exit 0\n") {
+		t.Fatalf("expected the sync before the trailing exit:\n%s", content)
+	}
+}
+
+func TestCacheSyncCmd_AllProjects(t *testing.T) {
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/projects"):
+			_, _ = w.Write([]byte(`{"projects":[{"id":"` + testAppProject + `","name":"app"},{"id":"` + testPlatformProject + `","name":"platform"}]}`))
+		case strings.Contains(r.URL.Path, testAppProject+"/environments"):
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"development","isDefault":true},{"slug":"production"}]}`))
+		case strings.Contains(r.URL.Path, testPlatformProject+"/environments"):
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"development","isDefault":true}]}`))
+		case strings.Contains(r.URL.Path, testAppProject+"/secrets") && r.URL.Query().Get("environment") == "production":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"ENVIRONMENT_ACCESS_DENIED"}`))
+		case strings.Contains(r.URL.Path, "/secrets"):
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"API_URL","value":"https://api.example.com"},{"key":"LOG_LEVEL","value":"debug"}]}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	env := append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_CREDENTIAL_STORE=file",
+		"ENVAULT_PASSPHRASE=test-passphrase",
+		"NO_COLOR=1",
+	)
+
+	cmd := exec.Command(buildBinary(t), "cache", "sync", "--all-projects", "--concurrency", "2")
+	cmd.Dir = tmp
+	cmd.Env = env
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	err := cmd.Run()
+	if err == nil {
+		t.Fatalf("expected a non-zero exit when one environment fails\nstdout:\n%s", outBuf.String())
+	}
+
+	out := outBuf.String()
+	synced := 0
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		switch {
+		case fields[1] == "production":
+			if !strings.Contains(line, "failed: Forbidden (403)") {
+				t.Errorf("expected the production row to fail, got %q", line)
+			}
+		case fields[len(fields)-1] == "synced":
+			if fields[2] != "2" {
+				t.Errorf("expected 2 keys, got line %q", line)
+			}
+			synced++
+		}
+	}
+	if synced != 2 {
+		t.Fatalf("expected 2 synced environments, got %d\nstdout:\n%s\nstderr:\n%s", synced, out, errBuf.String())
+	}
+	if !strings.Contains(errBuf.String(), "Synced 2 environments; 1 failed.") {
+		t.Fatalf("expected a failure count, got stderr:\n%s", errBuf.String())
+	}
+	if strings.Contains(out, "https://api.example.com") {
+		t.Fatalf("sync summary leaked a value:\n%s", out)
+	}
+
+	ls := exec.Command(buildBinary(t), "cache", "ls")
+	ls.Dir = tmp
+	ls.Env = env
+	lsOut, err := ls.Output()
+	if err != nil {
+		t.Fatalf("cache ls failed: %v", err)
+	}
+	if !strings.Contains(string(lsOut), testPlatformProject) || strings.Count(string(lsOut), "development") != 2 {
+		t.Fatalf("expected both development entries in the cache:\n%s", lsOut)
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+func TestCheckCachePolicies(t *testing.T) {
+	now := time.Now()
+	soft := offlinecache.Policy{MaxAge: time.Hour, Origin: "config.toml"}
+	hard := offlinecache.Policy{MaxAge: 24 * time.Hour, Hard: true, Origin: "envault.json"}
+
+	warnings, err := checkCachePolicies(now.Add(-30*time.Minute), now, []offlinecache.Policy{soft, hard})
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("fresh entry: expected no warnings or error, got %v, %v", warnings, err)
+	}
+
+	warnings, err = checkCachePolicies(now.Add(-2*time.Hour), now, []offlinecache.Policy{soft, hard})
+	if err != nil || len(warnings) != 1 || !strings.Contains(warnings[0], "config.toml") {
+		t.Fatalf("stale entry: expected one soft warning, got %v, %v", warnings, err)
+	}
+	if status, _ := cacheEntryStatus(now.Add(-2*time.Hour), now, []offlinecache.Policy{soft, hard}); status != "stale" {
+		t.Fatalf("expected status stale, got %s", status)
+	}
+
+	_, err = checkCachePolicies(now.Add(-48*time.Hour), now, []offlinecache.Policy{soft, hard})
+	if err == nil || !strings.Contains(err.Error(), "envault.json") {
+		t.Fatalf("expired entry: expected a hard policy error, got %v", err)
+	}
+	if status, _ := cacheEntryStatus(now.Add(-48*time.Hour), now, []offlinecache.Policy{soft, hard}); status != "expired" {
+		t.Fatalf("expected status expired, got %s", status)
+	}
+}
+
+func TestFilterCacheEntries(t *testing.T) {
+	entries := []offlinecache.EntryInfo{
+		{ProjectID: testAppProject, Environment: "development"},
+		{ProjectID: testAppProject, Environment: "production"},
+		{ProjectID: testPlatformProject, Environment: "production"},
+	}
+	if got := filterCacheEntries(entries, "", "Production"); len(got) != 2 {
+		t.Fatalf("expected 2 production entries, got %+v", got)
+	}
+	if got := filterCacheEntries(entries, testAppProject, "production"); len(got) != 1 || got[0].ProjectID != testAppProject {
+		t.Fatalf("expected the app production entry, got %+v", got)
+	}
+	if got := filterCacheEntries(entries, "", ""); len(got) != 3 {
+		t.Fatalf("expected no filtering, got %+v", got)
+	}
+}
+
+func TestRunCmd_OfflineNeverContactsAPI(t *testing.T) {
+	var requests int32
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	_ = os.WriteFile(tmp+"/envault.json", []byte(`{"projectId":"`+testAppProject+`","defaultEnvironment":"development"}`), 0644)
+
+	cmd := exec.Command(buildBinary(t), "run", "--offline", "--", "true")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+	)
+	var errBuf bytes.Buffer
+	cmd.Stderr = &errBuf
+	err := cmd.Run()
+
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Fatalf("expected no API requests with --offline, got %d", n)
+	}
+	// The temp HOME has no cache, so the run must fail on the cache alone.
+	if err == nil || !strings.Contains(errBuf.String(), "Offline cache unavailable") || strings.Contains(errBuf.String(), "Network error") {
+		t.Fatalf("expected an offline cache failure, got err=%v\nstderr:\n%s", err, errBuf.String())
+	}
+}
+
+func TestRunCmd_OfflineIgnoresAPIConfiguration(t *testing.T) {
+	tmp := t.TempDir()
+	_ = os.WriteFile(tmp+"/envault.json", []byte(`{"projectId":"`+testAppProject+`","defaultEnvironment":"development"}`), 0644)
+
+	// Neither a refused token nor a plain HTTP URL matters without the API.
+	cmd := exec.Command(buildBinary(t), "run", "--offline", "--", "true")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL=http://127.0.0.1:1/api/cli",
+		"ENVAULT_TOKEN=envault_at_personal",
+		"ENVAULT_ALLOW_INSECURE_HTTP=",
+	)
+	var errBuf bytes.Buffer
+	cmd.Stderr = &errBuf
+	err := cmd.Run()
+
+	if err == nil || !strings.Contains(errBuf.String(), "Offline cache unavailable") {
+		t.Fatalf("expected the run to reach the offline cache, got err=%v\nstderr:\n%s", err, errBuf.String())
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -88,8 +89,15 @@ func fetchAuthorizedEnvironments(projectID string) ([]cliEnvironment, error) {
 	client := api.NewClient()
 	loader := ui.NewLoader(ui.LoaderThemeFetch, "Fetching environment access...")
 	loader.Start()
-	respBytes, err := client.Get(fmt.Sprintf("/projects/%s/environments", projectID))
+	environments, err := requestAuthorizedEnvironments(context.Background(), client, projectID)
 	loader.Stop()
+	return environments, err
+}
+
+// requestAuthorizedEnvironments lists the environments of a project that the
+// caller may read.
+func requestAuthorizedEnvironments(ctx context.Context, client *api.Client, projectID string) ([]cliEnvironment, error) {
+	respBytes, err := client.GetWithContext(ctx, fmt.Sprintf("/projects/%s/environments", projectID))
 	if err != nil {
 		return nil, err
 	}
@@ -297,7 +305,7 @@ func isValidProjectID(projectID string) bool {
 }
 
 func selectProjectAndPersistOrExit() string {
-	selected, err := project.SelectProject()
+	selected, err := selectProjectAndPersist()
 	if err != nil {
 		if err == project.ErrUserCancelled {
 			fmt.Fprintln(os.Stderr, "\nOperation cancelled.")
@@ -306,13 +314,22 @@ func selectProjectAndPersistOrExit() string {
 		fmt.Fprintf(os.Stderr, "Error selecting project: %v\n", err)
 		os.Exit(1)
 	}
+	return selected
+}
+
+// selectProjectAndPersist runs the interactive project picker and links the
+// chosen project. An empty selection is reported as project.ErrUserCancelled.
+func selectProjectAndPersist() (string, error) {
+	selected, err := project.SelectProject()
+	if err != nil {
+		return "", err
+	}
 	if selected == "" {
-		fmt.Fprintln(os.Stderr, "Operation cancelled.")
-		os.Exit(0)
+		return "", project.ErrUserCancelled
 	}
 
 	persistProjectSelection(selected)
-	return selected
+	return selected, nil
 }
 
 func httpStatusText(code int) string {
@@ -13,10 +13,10 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/DinanathDash/Envault/cli-go/internal/api"
 	"github.com/DinanathDash/Envault/cli-go/internal/crypto"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var forceDeploy bool
@@ -34,8 +34,8 @@ var deployCmd = &cobra.Command{
 			token = os.Getenv("ENVAULT_SERVICE_TOKEN")
 		}
 		if token == "" {
-			// Fallback to check token stored in global config
-			token = viper.GetString("auth.token")
+			// Fallback to check token stored in the credential store
+			token = credstore.AccessToken()
 		}
 		
 		if strings.HasPrefix(token, "envault_svc_") {
@@ -147,7 +147,7 @@ var deployCmd = &cobra.Command{
 		}
 
 		var hasPruned bool
-		if diff, err := computeDiff(ctx, projectId, targetEnv, targetFile); err == nil {
+		if diff, err := computeDiff(ctx, api.NewClient(), projectId, targetEnv, targetFile); err == nil {
 			fmt.Printf(
 				"%s %d additions, %d deletions, %d modifications, %d unchanged\n",
 				ui.ColorBold("Diff Summary:"),
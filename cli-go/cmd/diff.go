@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,9 +15,11 @@ import (
 	"sort"
 	"strings"
 	"syscall"
+	"unicode/utf8"
 
 	"github.com/DinanathDash/Envault/cli-go/internal/api"
 	"github.com/DinanathDash/Envault/cli-go/internal/crypto"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
@@ -26,12 +32,88 @@ type diffResult struct {
 	Unchanged     int
 	LocalCount    int
 	RemoteCount   int
+
+	localValues  map[string]string
+	remoteValues map[string]string
+}
+
+// diffKeyEntry is one changed key in machine-readable diff output. Values are
+// never included; only an optional fingerprint of each side.
+type diffKeyEntry struct {
+	Key    string `json:"key"`
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
 }
 
+type diffSummary struct {
+	Additions     int `json:"additions"`
+	Deletions     int `json:"deletions"`
+	Modifications int `json:"modifications"`
+	Unchanged     int `json:"unchanged"`
+}
+
+type diffReport struct {
+	ProjectID     string         `json:"projectId"`
+	Environment   string         `json:"environment"`
+	LocalFile     string         `json:"localFile"`
+	Fingerprint   string         `json:"fingerprint"`
+	HasChanges    bool           `json:"hasChanges"`
+	Additions     []diffKeyEntry `json:"additions"`
+	Deletions     []diffKeyEntry `json:"deletions"`
+	Modifications []diffKeyEntry `json:"modifications"`
+	Summary       diffSummary    `json:"summary"`
+}
+
+var (
+	diffFormat          string
+	diffExitCode        bool
+	diffFingerprint     string
+	diffFingerprintSalt string
+)
+
+// diffFingerprinter renders a value as something a reviewer can compare
+// without the plaintext being printed.
+type diffFingerprinter func(value string) string
+
 var diffCmd = &cobra.Command{
 	Use:   "diff",
 	Short: "Compare local env file with remote vault secrets",
+	Long: `Compare a local env file with the remote secrets of the target environment.
+
+Output formats:
+  text      coloured +/-/~ lines (default)
+  json      machine-readable report for bots and pipelines
+  markdown  table suitable for PR comments
+  unified   unified-diff style lines (remote as "---", local as "+++")
+
+Values are never printed. Use --fingerprint to show a salted hash prefix
+(hash) or a masked preview with length (mask) for each side of a change.
+
+With --exit-code the command exits 1 when differences are found and 2 on
+errors, so it can gate a pipeline.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		failCode := 1
+		if diffExitCode {
+			failCode = 2
+		}
+
+		format := strings.ToLower(strings.TrimSpace(diffFormat))
+		if !isValidDiffFormat(format) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --format %q. Expected text, json, markdown or unified.", diffFormat)))
+			os.Exit(failCode)
+		}
+		fingerprint, err := newDiffFingerprinter(diffFingerprint, diffFingerprintSalt)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(failCode)
+		}
+
+		client, err := api.LoadClient()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Error: %v", err)))
+			os.Exit(failCode)
+		}
+
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 		sigCh := make(chan os.Signal, 1)
@@ -48,23 +130,34 @@ var diffCmd = &cobra.Command{
 		projectID := ensureProjectID()
 		if projectID == "" {
 			fmt.Fprintln(os.Stderr, ui.ColorYellow("No project linked."))
-			projectID = selectProjectAndPersistOrExit()
+			projectID, err = selectProjectAndPersist()
+			if err == project.ErrUserCancelled {
+				fmt.Fprintln(os.Stderr, "\nOperation cancelled.")
+				if diffExitCode {
+					os.Exit(failCode)
+				}
+				os.Exit(0)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error selecting project: %v\n", err)
+				os.Exit(failCode)
+			}
 			fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Project linked! (ID: %s)\n", projectID)))
 		}
 		if !isValidProjectID(projectID) {
 			fmt.Fprintln(os.Stderr, ui.ColorRed("Invalid project ID. Expected a UUID."))
-			os.Exit(1)
+			os.Exit(failCode)
 		}
 
 		targetEnv, err := resolveTargetEnvironmentForProject(projectID)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, ui.ColorRed("Diff failed."))
 			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
-			os.Exit(1)
+			os.Exit(failCode)
 		}
 		targetFile := resolveEnvFile(targetEnv, fileFlag)
 
-		result, err := computeDiff(ctx, projectID, targetEnv, targetFile)
+		result, err := computeDiff(ctx, client, projectID, targetEnv, targetFile)
 		if err != nil {
 			if ctx.Err() != nil {
 				fmt.Fprintln(os.Stderr, ui.ColorYellow("\nOperation cancelled."))
@@ -72,43 +165,258 @@ var diffCmd = &cobra.Command{
 			}
 			fmt.Fprintln(os.Stderr, ui.ColorRed("Diff failed."))
 			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(failCode)
+		}
+
+		report := buildDiffReport(result, projectID, targetEnv, targetFile, strings.ToLower(strings.TrimSpace(diffFingerprint)), fingerprint)
+		switch format {
+		case "json":
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		case "markdown":
+			fmt.Print(renderDiffMarkdown(report))
+		case "unified":
+			fmt.Print(renderDiffUnified(report))
+		default:
+			printDiffText(report)
+		}
+
+		if diffExitCode && report.HasChanges {
 			os.Exit(1)
 		}
+	},
+}
 
-		fmt.Printf("%s %s (%s)\n", ui.ColorBold("Environment:"), targetEnv, projectID)
-		fmt.Printf("%s %s\n", ui.ColorBold("Local file:"), targetFile)
+func isValidDiffFormat(format string) bool {
+	switch format {
+	case "text", "json", "markdown", "unified":
+		return true
+	default:
+		return false
+	}
+}
 
-		for _, k := range result.Additions {
-			fmt.Println(ui.ColorGreen("+ " + k))
+// newDiffFingerprinter resolves the --fingerprint mode. "hash" uses an
+// HMAC-SHA256 keyed by the salt; without an explicit salt a random one is
+// generated per run so that hashes cannot be matched against other runs or
+// precomputed tables.
+func newDiffFingerprinter(mode, salt string) (diffFingerprinter, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		return nil, nil
+	case "hash":
+		key := []byte(salt)
+		if salt == "" {
+			key = make([]byte, 16)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("failed to generate fingerprint salt: %w", err)
+			}
 		}
-		for _, k := range result.Deletions {
-			fmt.Println(ui.ColorRed("- " + k))
+		return func(value string) string {
+			return hashFingerprint(value, key)
+		}, nil
+	case "mask":
+		return maskFingerprint, nil
+	default:
+		return nil, fmt.Errorf("invalid --fingerprint %q. Expected none, hash or mask", mode)
+	}
+}
+
+func hashFingerprint(value string, salt []byte) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(value))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// maskFingerprint reports a value's length and, for long values only, a few
+// of its edge characters: none below 16 characters, one at each end below
+// 32 and two from there on, so at most an eighth of the value is shown.
+func maskFingerprint(value string) string {
+	n := utf8.RuneCountInString(value)
+	if n == 0 {
+		return "(empty)"
+	}
+	reveal := 0
+	switch {
+	case n >= 32:
+		reveal = 2
+	case n >= 16:
+		reveal = 1
+	}
+	if reveal == 0 {
+		return fmt.Sprintf("%s (len %d)", strings.Repeat("*", 4), n)
+	}
+	runes := []rune(value)
+	return fmt.Sprintf("%s%s%s (len %d)", string(runes[:reveal]), strings.Repeat("*", 4), string(runes[n-reveal:]), n)
+}
+
+func buildDiffReport(result diffResult, projectID, targetEnv, targetFile, mode string, fingerprint diffFingerprinter) diffReport {
+	if mode == "" {
+		mode = "none"
+	}
+	fp := func(values map[string]string, key string) string {
+		if fingerprint == nil {
+			return ""
 		}
-		for _, k := range result.Modifications {
-			fmt.Println(ui.ColorYellow("~ " + k))
+		v, ok := values[key]
+		if !ok {
+			return ""
 		}
+		return fingerprint(v)
+	}
+
+	report := diffReport{
+		ProjectID:     projectID,
+		Environment:   targetEnv,
+		LocalFile:     targetFile,
+		Fingerprint:   mode,
+		Additions:     []diffKeyEntry{},
+		Deletions:     []diffKeyEntry{},
+		Modifications: []diffKeyEntry{},
+		Summary: diffSummary{
+			Additions:     len(result.Additions),
+			Deletions:     len(result.Deletions),
+			Modifications: len(result.Modifications),
+			Unchanged:     result.Unchanged,
+		},
+	}
+	for _, k := range result.Additions {
+		report.Additions = append(report.Additions, diffKeyEntry{Key: k, Local: fp(result.localValues, k)})
+	}
+	for _, k := range result.Deletions {
+		report.Deletions = append(report.Deletions, diffKeyEntry{Key: k, Remote: fp(result.remoteValues, k)})
+	}
+	for _, k := range result.Modifications {
+		report.Modifications = append(report.Modifications, diffKeyEntry{
+			Key:    k,
+			Local:  fp(result.localValues, k),
+			Remote: fp(result.remoteValues, k),
+		})
+	}
+	report.HasChanges = len(report.Additions)+len(report.Deletions)+len(report.Modifications) > 0
+	return report
+}
+
+func printDiffText(report diffReport) {
+	fmt.Printf("%s %s (%s)\n", ui.ColorBold("Environment:"), report.Environment, report.ProjectID)
+	fmt.Printf("%s %s\n", ui.ColorBold("Local file:"), report.LocalFile)
+
+	for _, e := range report.Additions {
+		fmt.Println(ui.ColorGreen("+ " + e.Key + describeFingerprints(e)))
+	}
+	for _, e := range report.Deletions {
+		fmt.Println(ui.ColorRed("- " + e.Key + describeFingerprints(e)))
+	}
+	for _, e := range report.Modifications {
+		fmt.Println(ui.ColorYellow("~ " + e.Key + describeFingerprints(e)))
+	}
+
+	if !report.HasChanges {
+		fmt.Println(ui.ColorGreen("No differences found."))
+	}
+
+	fmt.Printf("\n%s %d additions, %d deletions, %d modifications, %d unchanged\n",
+		ui.ColorBold("Summary:"),
+		report.Summary.Additions,
+		report.Summary.Deletions,
+		report.Summary.Modifications,
+		report.Summary.Unchanged,
+	)
+}
+
+func describeFingerprints(e diffKeyEntry) string {
+	switch {
+	case e.Local != "" && e.Remote != "":
+		return fmt.Sprintf("  (remote %s -> local %s)", e.Remote, e.Local)
+	case e.Local != "":
+		return fmt.Sprintf("  (local %s)", e.Local)
+	case e.Remote != "":
+		return fmt.Sprintf("  (remote %s)", e.Remote)
+	default:
+		return ""
+	}
+}
 
-		if len(result.Additions) == 0 && len(result.Deletions) == 0 && len(result.Modifications) == 0 {
-			fmt.Println(ui.ColorGreen("No differences found."))
+func renderDiffMarkdown(report diffReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### Envault diff: `%s` vs `%s`\n\n", report.LocalFile, report.Environment)
+	if !report.HasChanges {
+		sb.WriteString("No differences found.\n")
+	} else {
+		withValues := report.Fingerprint != "none"
+		if withValues {
+			sb.WriteString("| Change | Key | Remote | Local |\n|---|---|---|---|\n")
+		} else {
+			sb.WriteString("| Change | Key |\n|---|---|\n")
+		}
+		row := func(change string, e diffKeyEntry) {
+			if withValues {
+				fmt.Fprintf(&sb, "| %s | `%s` | %s | %s |\n", change, e.Key, markdownCell(e.Remote), markdownCell(e.Local))
+				return
+			}
+			fmt.Fprintf(&sb, "| %s | `%s` |\n", change, e.Key)
+		}
+		for _, e := range report.Additions {
+			row("added", e)
 		}
+		for _, e := range report.Deletions {
+			row("removed", e)
+		}
+		for _, e := range report.Modifications {
+			row("modified", e)
+		}
+	}
+	fmt.Fprintf(&sb, "\n**Summary:** %d additions, %d deletions, %d modifications, %d unchanged\n",
+		report.Summary.Additions,
+		report.Summary.Deletions,
+		report.Summary.Modifications,
+		report.Summary.Unchanged,
+	)
+	return sb.String()
+}
 
-		fmt.Printf("\n%s %d additions, %d deletions, %d modifications, %d unchanged\n",
-			ui.ColorBold("Summary:"),
-			len(result.Additions),
-			len(result.Deletions),
-			len(result.Modifications),
-			result.Unchanged,
-		)
-	},
+func markdownCell(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return "`" + strings.ReplaceAll(v, "|", "\\|") + "`"
+}
+
+// renderDiffUnified prints changes the way `diff -u` would, treating the
+// remote environment as the old side and the local file as the new side.
+func renderDiffUnified(report diffReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- envault/%s/%s\n", report.ProjectID, report.Environment)
+	fmt.Fprintf(&sb, "+++ %s\n", report.LocalFile)
+	if !report.HasChanges {
+		return sb.String()
+	}
+	line := func(prefix, key, fp string) {
+		if fp != "" {
+			fmt.Fprintf(&sb, "%s%s=%s\n", prefix, key, fp)
+			return
+		}
+		fmt.Fprintf(&sb, "%s%s\n", prefix, key)
+	}
+	for _, e := range report.Deletions {
+		line("-", e.Key, e.Remote)
+	}
+	for _, e := range report.Modifications {
+		line("-", e.Key, e.Remote)
+		line("+", e.Key, e.Local)
+	}
+	for _, e := range report.Additions {
+		line("+", e.Key, e.Local)
+	}
+	return sb.String()
 }
 
-func computeDiff(ctx context.Context, projectID, targetEnv, targetFile string) (diffResult, error) {
+func computeDiff(ctx context.Context, client *api.Client, projectID, targetEnv, targetFile string) (diffResult, error) {
 	localEnv, err := readEnvFile(targetFile)
 	if err != nil {
 		return diffResult{}, err
 	}
 
-	client := api.NewClient()
 	path := fmt.Sprintf("/projects/%s/secrets?environment=%s", projectID, url.QueryEscape(targetEnv))
 	loader := ui.NewLoader(ui.LoaderThemeCheck, "ScanGrid comparing local vs remote secrets...")
 	loader.Start()
@@ -138,7 +446,12 @@ func computeDiff(ctx context.Context, projectID, targetEnv, targetFile string) (
 		remoteMap[s.Key] = plaintext
 	}
 
-	res := diffResult{LocalCount: len(localEnv), RemoteCount: len(remoteMap)}
+	res := diffResult{
+		LocalCount:   len(localEnv),
+		RemoteCount:  len(remoteMap),
+		localValues:  localEnv,
+		remoteValues: remoteMap,
+	}
 
 	for k, lv := range localEnv {
 		rv, exists := remoteMap[k]
@@ -196,4 +509,8 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 	diffCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
 	diffCmd.Flags().StringVar(&fileFlag, "file", "", "Local .env file path override")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, json, markdown or unified")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit 1 when differences are found (errors exit 2)")
+	diffCmd.Flags().StringVar(&diffFingerprint, "fingerprint", "none", "Value fingerprints for changed keys: none, hash or mask")
+	diffCmd.Flags().StringVar(&diffFingerprintSalt, "fingerprint-salt", "", "Salt for --fingerprint hash (random per run when empty)")
 }
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -75,6 +76,8 @@ var doctorCmd = &cobra.Command{
 			})
 		}
 
+		checks = append(checks, runCredentialStoreChecks()...)
+
 		if isHomebrewPath(normalizedPath) {
 			loader := ui.NewLoader(ui.LoaderThemeCheck, "ScanGrid checking Homebrew metadata...")
 			loader.Start()
@@ -120,6 +123,53 @@ var doctorCmd = &cobra.Command{
 	},
 }
 
+// runCredentialStoreChecks reports which credential store backend is active
+// and flags an access token left in plaintext in config.toml.
+func runCredentialStoreChecks() []doctorCheck {
+	selected := credstore.SelectedBackend()
+	store, err := credstore.Default()
+	if err != nil {
+		return []doctorCheck{{
+			name:   "credential store",
+			status: "error",
+			detail: fmt.Sprintf("%s backend: %v", selected, err),
+			fix: []string{
+				"export ENVAULT_CREDENTIAL_STORE=auto",
+				"export ENVAULT_PASSPHRASE=<passphrase>  # or ENVAULT_KEY_FILE=<path>",
+			},
+		}}
+	}
+
+	check := doctorCheck{
+		name:   "credential store",
+		status: "ok",
+		detail: fmt.Sprintf("%s (%s)", store.Name(), store.Describe()),
+	}
+	if selected == credstore.BackendAuto {
+		check.detail += ", selected automatically"
+		if !store.Persistent() {
+			check.status = "warn"
+			check.detail += "; no OS keyring or passphrase is available, so logins and cache keys last one process"
+			check.fix = []string{"export ENVAULT_PASSPHRASE=<passphrase>  # or ENVAULT_KEY_FILE=<path>"}
+		}
+	}
+	checks := []doctorCheck{check}
+
+	if credstore.HasPlaintextToken() {
+		detail := "config.toml still holds auth.token in plaintext; it moves to the credential store on next use."
+		if !store.Persistent() {
+			detail = fmt.Sprintf("config.toml still holds auth.token in plaintext; the %s store cannot take it over.", store.Name())
+		}
+		checks = append(checks, doctorCheck{
+			name:   "plaintext access token",
+			status: "warn",
+			detail: detail,
+			fix:    []string{"envault login"},
+		})
+	}
+	return checks
+}
+
 func runHomebrewChecks(currentVersion string) []doctorCheck {
 	checks := []doctorCheck{}
 
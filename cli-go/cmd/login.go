@@ -1,26 +1,158 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/DinanathDash/Envault/cli-go/internal/auth"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	loginWithToken     bool
+	loginNoBrowser     bool
+	loginBrowser       bool
+	loginOIDC          bool
+	loginOIDCTokenFile string
+	loginOIDCAudience  string
 )
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Authenticate with Envault",
+	Long: `Authenticate with Envault using the device flow: approve the displayed
+code in a browser on any machine.
+
+--browser signs in through a page opened in this computer's browser instead,
+with no code to type. It falls back to the device flow when no browser or
+local port is available.
+
+--no-browser only prints the URL and code, and is implied in SSH sessions.
+--with-token skips the device flow and reads an access token and an optional
+refresh token from stdin, separated by whitespace or as JSON with
+access_token and refresh_token fields.
+
+--oidc is for CI: it exchanges the job's OIDC identity token (GitHub Actions,
+GitLab CI, or --oidc-token-file) for a short-lived token scoped to the
+project. In GitHub Actions the token is exported as ENVAULT_TOKEN to later
+steps; elsewhere an export line is printed for eval "$(envault login --oidc)".`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if loginOIDC {
+			runOIDCLogin()
+			return
+		}
+		if loginWithToken {
+			if term.IsTerminal(int(os.Stdin.Fd())) {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Error: --with-token reads the tokens from stdin; pipe them in, e.g. envault login --with-token < tokens.txt"))
+				os.Exit(1)
+			}
+			if err := auth.LoginWithToken(os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if loginBrowser && loginNoBrowser {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Error: --browser and --no-browser cannot be used together."))
+			os.Exit(1)
+		}
+
 		ui.ShowLogo()
-		if err := auth.Login(); err != nil {
+		opts := auth.LoginOptions{
+			NoBrowser: loginNoBrowser || os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "",
+		}
+		if loginBrowser {
+			if opts.NoBrowser {
+				fmt.Println(ui.ColorYellow("No local browser in an SSH session; using the device flow."))
+			} else {
+				err := auth.BrowserLogin()
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, auth.ErrBrowserUnavailable) {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(ui.ColorYellow(fmt.Sprintf("%v; using the device flow.", err)))
+			}
+		}
+		if err := auth.Login(opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 	},
 }
 
+// runOIDCLogin exchanges the CI job's identity token and hands the result to
+// the rest of the job through ENVAULT_TOKEN. Only the export goes to stdout.
+func runOIDCLogin() {
+	projectID := strings.TrimSpace(projectFlag)
+	if projectID == "" {
+		projectID, _ = project.GetProjectId()
+	}
+
+	token, provider, err := auth.OIDCLogin(auth.OIDCOptions{
+		TokenFile:   loginOIDCTokenFile,
+		Audience:    loginOIDCAudience,
+		ProjectID:   projectID,
+		Environment: strings.TrimSpace(envFlag),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("OIDC login failed: %v", err)))
+		os.Exit(1)
+	}
+
+	scope := "a short-lived token"
+	if token.ProjectID != "" {
+		scope += " for project " + token.ProjectID
+	}
+	if token.Environment != "" {
+		scope += " (" + token.Environment + ")"
+	}
+	expiry := ""
+	if token.ExpiresIn > 0 {
+		expiry = ", expires in " + humanizeDuration(time.Duration(token.ExpiresIn)*time.Second)
+	}
+
+	if githubEnv := os.Getenv("GITHUB_ENV"); githubEnv != "" {
+		// Mask the token in the job log before it can appear anywhere.
+		fmt.Println("::add-mask::" + token.AccessToken)
+		f, err := os.OpenFile(githubEnv, os.O_APPEND|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "ENVAULT_TOKEN=%s\n", token.AccessToken)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to write GITHUB_ENV: %v", err)))
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Exchanged the %s OIDC token for %s%s; ENVAULT_TOKEN is set for the following steps.", provider, scope, expiry)))
+		return
+	}
+
+	fmt.Printf("export ENVAULT_TOKEN=%s\n", token.AccessToken)
+	fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Exchanged the %s OIDC token for %s%s.", provider, scope, expiry)))
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprintln(os.Stderr, ui.ColorDim(`Run eval "$(envault login --oidc)" to use it in this shell.`))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
+	loginCmd.Flags().BoolVar(&loginWithToken, "with-token", false, "Read an access token and optional refresh token from stdin")
+	loginCmd.Flags().BoolVar(&loginBrowser, "browser", false, "Sign in through this computer's browser instead of typing a device code")
+	loginCmd.Flags().BoolVar(&loginOIDC, "oidc", false, "Exchange the CI job's OIDC token for a short-lived token")
+	loginCmd.Flags().StringVar(&loginOIDCTokenFile, "oidc-token-file", "", "Read the OIDC token from this file")
+	loginCmd.Flags().StringVar(&loginOIDCAudience, "oidc-audience", auth.DefaultOIDCAudience, "Audience to request from GitHub Actions")
+	loginCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project to scope the OIDC token to (default from envault.json)")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Print the verification URL instead of opening a browser")
 }
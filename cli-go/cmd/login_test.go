@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoginCmd_OIDCExportsFederatedToken(t *testing.T) {
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/cli/auth/oidc/exchange" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"envault_oidc_job","expires_in":900,"project_id":"` + testAppProject + `"}`))
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	tokenFile := filepath.Join(tmp, "oidc-token")
+	_ = os.WriteFile(tokenFile, []byte("ci-jwt"), 0600)
+	githubEnv := filepath.Join(tmp, "github_env")
+	_ = os.WriteFile(githubEnv, nil, 0600)
+	bin := buildBinary(t)
+
+	baseEnv := append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_TOKEN=",
+		"NO_COLOR=1",
+	)
+
+	cmd := exec.Command(bin, "login", "--oidc", "--oidc-token-file", tokenFile)
+	cmd.Dir = tmp
+	cmd.Env = append(baseEnv, "GITHUB_ENV="+githubEnv)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("login --oidc failed: %v\nstderr:\n%s", err, errBuf.String())
+	}
+	if strings.TrimSpace(outBuf.String()) != "::add-mask::envault_oidc_job" {
+		t.Fatalf("expected only the mask command on stdout, got %q", outBuf.String())
+	}
+	if raw, _ := os.ReadFile(githubEnv); string(raw) != "ENVAULT_TOKEN=envault_oidc_job\n" {
+		t.Fatalf("unexpected GITHUB_ENV content: %q", raw)
+	}
+
+	cmd = exec.Command(bin, "login", "--oidc", "--oidc-token-file", tokenFile)
+	cmd.Dir = tmp
+	cmd.Env = append(baseEnv, "GITHUB_ENV=")
+	outBuf.Reset()
+	errBuf.Reset()
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("login --oidc failed: %v\nstderr:\n%s", err, errBuf.String())
+	}
+	if outBuf.String() != "export ENVAULT_TOKEN=envault_oidc_job\n" {
+		t.Fatalf("expected an export line on stdout, got %q", outBuf.String())
+	}
+}
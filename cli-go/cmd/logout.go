@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logoutAllDevices bool
+	logoutPurgeCache bool
+)
+
+type revokeResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Sign out and remove stored credentials",
+	Long: `Revoke this device's session on the server, then remove the access and
+refresh tokens from the credential store and any plaintext token left in
+config.toml. --all-devices revokes every CLI session of your account.
+--purge-cache also deletes the offline cache and its master key.
+
+Local credentials are removed even when the server cannot be reached.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		accessToken := credstore.AccessToken()
+		refreshToken, _ := credstore.Get(credstore.AccountRefreshToken)
+
+		if accessToken == "" && refreshToken == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("No session to revoke; not logged in."))
+		} else if revoked, err := revokeSession(accessToken, refreshToken, logoutAllDevices); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to revoke the session on the server: %v", err)))
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("The tokens stay valid until they expire; revoke them from the dashboard to end the session now."))
+		} else {
+			fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Revoked %d token%s on the server", revoked, pluralSuffix(revoked, "", "s"))))
+		}
+
+		removed, err := credstore.RemoveSession()
+		for _, what := range removed {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("Removed "+what))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to remove credentials: %v", err)))
+			os.Exit(1)
+		}
+
+		if logoutPurgeCache {
+			entries, keyRemoved, err := offlinecache.Wipe()
+			if entries > 0 {
+				fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Removed %d offline cache entr%s", entries, pluralSuffix(entries, "y", "ies"))))
+			}
+			if keyRemoved {
+				fmt.Fprintln(os.Stderr, ui.ColorDim("Removed the offline cache master key"))
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to purge offline cache: %v", err)))
+				os.Exit(1)
+			}
+		}
+
+		if os.Getenv("ENVAULT_TOKEN") != "" || os.Getenv("ENVAULT_SERVICE_TOKEN") != "" {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("Note: ENVAULT_TOKEN is still set in this environment; unset it to stop using that token."))
+		}
+		fmt.Println(ui.ColorGreen("[OK] Logged out."))
+	},
+}
+
+// revokeSession asks the server to revoke the tokens held by this device and
+// returns how many tokens it revoked. The server matches them by hash, so
+// other sessions on a machine with the same hostname are left alone.
+func revokeSession(accessToken, refreshToken string, allDevices bool) (int, error) {
+	// Authenticate with the session being revoked, never a token from the
+	// environment; ENVAULT_TOKEN is not even validated, so an unusable value
+	// there cannot stop the local credentials from being removed.
+	baseURL, err := api.ResolveBaseURL()
+	if err != nil {
+		return 0, err
+	}
+	client := &api.Client{BaseURL: baseURL, Token: accessToken, HTTP: &http.Client{}}
+
+	respBytes, err := client.Post("/auth/revoke", map[string]interface{}{
+		"refresh_token": refreshToken,
+		"all_devices":   allDevices,
+	})
+	if err != nil {
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) {
+			return 0, fmt.Errorf("server returned %d: %s", apiErr.StatusCode, strings.TrimSpace(apiErr.Body))
+		}
+		return 0, err
+	}
+
+	var resp revokeResponse
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return 0, fmt.Errorf("failed to parse revoke response: %w", err)
+	}
+	return resp.Revoked, nil
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+	logoutCmd.Flags().BoolVar(&logoutAllDevices, "all-devices", false, "Revoke every CLI session of your account, not just this device")
+	logoutCmd.Flags().BoolVar(&logoutPurgeCache, "purge-cache", false, "Also delete the offline cache and its master key")
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+)
+
+func TestLogoutCmd_RevokesAndRemovesCredentials(t *testing.T) {
+	var authHeader string
+	var revokeBody map[string]interface{}
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/cli/auth/revoke" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		authHeader = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&revokeBody)
+		_, _ = w.Write([]byte(`{"revoked":4}`))
+	}))
+	defer mockSrv.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ENVAULT_PASSPHRASE", "logout-passphrase")
+	store, err := credstore.Open(credstore.BackendFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Set(credstore.AccountRefreshToken, "envault_rt_test")
+	_ = store.Set(credstore.AccountCacheKey, "cache-key")
+
+	configDir := filepath.Join(home, ".envault")
+	configPath := filepath.Join(configDir, "config.toml")
+	_ = os.WriteFile(configPath, []byte("[auth]\ntoken = \"envault_at_legacy\"\n"), 0600)
+	_ = os.MkdirAll(filepath.Join(configDir, "offline_cache"), 0700)
+	_ = os.WriteFile(filepath.Join(configDir, "offline_cache", "entry.enc"), []byte("{}"), 0600)
+
+	cmd := exec.Command(buildBinary(t), "logout", "--all-devices", "--purge-cache")
+	cmd.Dir = home
+	cmd.Env = append(os.Environ(),
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_CREDENTIAL_STORE=file",
+		"NO_COLOR=1",
+	)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("logout failed: %v\nstderr:\n%s", err, errBuf.String())
+	}
+
+	if authHeader != "Bearer envault_at_legacy" {
+		t.Fatalf("expected the session access token, got %q", authHeader)
+	}
+	if revokeBody["refresh_token"] != "envault_rt_test" || revokeBody["all_devices"] != true {
+		t.Fatalf("unexpected revoke request: %v", revokeBody)
+	}
+	stderr := errBuf.String()
+	for _, want := range []string{"Revoked 4 tokens", "Removed access token", "Removed refresh token", "Removed 1 offline cache entry", "master key"} {
+		if !strings.Contains(stderr, want) {
+			t.Fatalf("expected %q in stderr:\n%s", want, stderr)
+		}
+	}
+	if !strings.Contains(outBuf.String(), "Logged out") {
+		t.Fatalf("expected a confirmation on stdout, got %q", outBuf.String())
+	}
+
+	for _, account := range []string{credstore.AccountAccessToken, credstore.AccountRefreshToken, credstore.AccountCacheKey} {
+		if _, err := store.Get(account); !errors.Is(err, credstore.ErrNotFound) {
+			t.Fatalf("expected %s to be removed, got %v", account, err)
+		}
+	}
+	if raw, _ := os.ReadFile(configPath); strings.Contains(string(raw), "envault_at_legacy") {
+		t.Fatalf("expected the plaintext token to be removed:\n%s", raw)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "offline_cache", "entry.enc")); !os.IsNotExist(err) {
+		t.Fatalf("expected the cache entry to be deleted, got %v", err)
+	}
+}
+
+func TestLogoutCmd_RemovesCredentialsWhenServerUnreachable(t *testing.T) {
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mockSrv.Close()
+
+	home := t.TempDir()
+	configDir := filepath.Join(home, ".envault")
+	_ = os.MkdirAll(configDir, 0700)
+	_ = os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("[auth]\ntoken = \"envault_at_legacy\"\n"), 0600)
+
+	cmd := exec.Command(buildBinary(t), "logout")
+	cmd.Dir = home
+	cmd.Env = append(os.Environ(),
+		"HOME="+home,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_CREDENTIAL_STORE=file",
+		"ENVAULT_PASSPHRASE=logout-passphrase",
+		// A personal token in ENVAULT_TOKEN is refused by every other
+		// command; it must not stop logout from cleaning up.
+		"ENVAULT_TOKEN=envault_at_personal",
+		"NO_COLOR=1",
+	)
+	var errBuf bytes.Buffer
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("logout failed: %v\nstderr:\n%s", err, errBuf.String())
+	}
+	if !strings.Contains(errBuf.String(), "failed to revoke") || !strings.Contains(errBuf.String(), "Removed access token") {
+		t.Fatalf("expected a revoke warning and local removal:\n%s", errBuf.String())
+	}
+}
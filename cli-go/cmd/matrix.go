@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// matrixFetchWorkers bounds how many environments are fetched concurrently.
+const matrixFetchWorkers = 4
+
+// matrixUndecryptableGroup labels cells whose value could not be decrypted.
+const matrixUndecryptableGroup = "!"
+
+// matrixCell describes one key in one environment. Cells of the same key that
+// share a Group label hold identical values; the values themselves are never
+// reported.
+type matrixCell struct {
+	Present bool   `json:"present"`
+	Group   string `json:"group,omitempty"`
+}
+
+type matrixRow struct {
+	Key          string                `json:"key"`
+	Drift        bool                  `json:"drift"`
+	Environments map[string]matrixCell `json:"environments"`
+}
+
+type matrixReport struct {
+	ProjectID    string            `json:"projectId"`
+	GeneratedAt  time.Time         `json:"generatedAt"`
+	Environments []string          `json:"environments"`
+	Errors       map[string]string `json:"errors,omitempty"`
+	Keys         []matrixRow       `json:"keys"`
+}
+
+type matrixFetchResult struct {
+	env     string
+	secrets []offlinecache.Secret
+	err     error
+}
+
+var (
+	matrixJSON bool
+	matrixCSV  bool
+)
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Show which keys exist in which environments",
+	Long: `Fetch every environment you are authorized for and show a key x environment
+drift matrix.
+
+Each cell shows whether the key is present. Present cells carry a group letter:
+cells of the same key with the same letter hold identical values. Values are
+compared in memory and never printed; cells whose value could not be
+decrypted are marked "!".
+
+Use --json or --csv to record the matrix and track drift over time.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if matrixJSON && matrixCSV {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--json and --csv cannot be used together."))
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigCh)
+		go func() {
+			select {
+			case <-sigCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		projectID := ensureProjectID()
+		if projectID == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("No project linked."))
+			projectID = selectProjectAndPersistOrExit()
+			fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Project linked! (ID: %s)\n", projectID)))
+		}
+		if !isValidProjectID(projectID) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Invalid project ID. Expected a UUID."))
+			os.Exit(1)
+		}
+
+		environments, err := fetchAuthorizedEnvironments(projectID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Matrix failed."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+		if len(environments) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("No accessible environments found for this project."))
+			os.Exit(1)
+		}
+
+		envNames := make([]string, 0, len(environments))
+		for _, env := range environments {
+			envNames = append(envNames, env.Slug)
+		}
+
+		client := api.NewClient()
+		loader := ui.NewLoader(ui.LoaderThemeFetch, fmt.Sprintf("VaultPulse fetching %d environments...", len(envNames)))
+		loader.Start()
+		results := fetchEnvironmentsConcurrently(ctx, client, projectID, envNames)
+		loader.Stop()
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow("\nOperation cancelled."))
+			os.Exit(130)
+		}
+
+		report := buildMatrixReport(projectID, envNames, results)
+		if len(report.Errors) == len(envNames) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Matrix failed: no environment could be fetched."))
+			for _, env := range envNames {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("  %s: %s", env, report.Errors[env])))
+			}
+			os.Exit(1)
+		}
+
+		switch {
+		case matrixJSON:
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		case matrixCSV:
+			if err := writeMatrixCSV(os.Stdout, report); err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to write CSV: %v", err)))
+				os.Exit(1)
+			}
+		default:
+			printMatrixTable(report)
+		}
+	},
+}
+
+// fetchEnvironmentsConcurrently fetches the secrets of every environment with
+// a bounded worker pool. Results are returned in the order of envs.
+func fetchEnvironmentsConcurrently(ctx context.Context, client *api.Client, projectID string, envs []string) []matrixFetchResult {
+	results := make([]matrixFetchResult, len(envs))
+	runBounded(len(envs), matrixFetchWorkers, func(i int) {
+		secrets, err := fetchEnvironmentSecrets(ctx, client, projectID, envs[i], resolveRunTimeout(client.BaseURL), nil)
+		results[i] = matrixFetchResult{env: envs[i], secrets: secrets, err: err}
+	})
+
+	return results
+}
+
+func buildMatrixReport(projectID string, envs []string, results []matrixFetchResult) matrixReport {
+	report := matrixReport{
+		ProjectID:    projectID,
+		GeneratedAt:  time.Now().UTC(),
+		Environments: envs,
+		Keys:         []matrixRow{},
+	}
+
+	values := make(map[string]map[string]string, len(results))
+	keySet := map[string]struct{}{}
+	for _, r := range results {
+		if r.err != nil {
+			if report.Errors == nil {
+				report.Errors = map[string]string{}
+			}
+			report.Errors[r.env] = classifyAPIError(r.err)
+			continue
+		}
+		envValues := make(map[string]string, len(r.secrets))
+		for _, s := range r.secrets {
+			envValues[s.Key] = s.Value
+			keySet[s.Key] = struct{}{}
+		}
+		values[r.env] = envValues
+	}
+
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		row := matrixRow{Key: key, Environments: map[string]matrixCell{}}
+		groups := map[string]string{}
+		for _, env := range envs {
+			envValues, fetched := values[env]
+			if !fetched {
+				continue
+			}
+			value, ok := envValues[key]
+			if !ok {
+				row.Environments[env] = matrixCell{Present: false}
+				row.Drift = true
+				continue
+			}
+			if value == decryptionFailedMarker {
+				row.Environments[env] = matrixCell{Present: true, Group: matrixUndecryptableGroup}
+				row.Drift = true
+				continue
+			}
+			// Only the group letter is reported; no hash of the value leaves
+			// the process, so the report cannot be brute-forced offline.
+			group, seen := groups[value]
+			if !seen {
+				group = matrixGroupLabel(len(groups))
+				groups[value] = group
+			}
+			row.Environments[env] = matrixCell{Present: true, Group: group}
+		}
+		if len(groups) > 1 {
+			row.Drift = true
+		}
+		report.Keys = append(report.Keys, row)
+	}
+
+	return report
+}
+
+// matrixGroupLabel returns A..Z, then AA, AB, ... for larger indexes.
+func matrixGroupLabel(i int) string {
+	label := ""
+	for {
+		label = string(rune('A'+i%26)) + label
+		i = i/26 - 1
+		if i < 0 {
+			return label
+		}
+	}
+}
+
+func matrixCellText(report matrixReport, row matrixRow, env string) string {
+	if _, failed := report.Errors[env]; failed {
+		return "?"
+	}
+	cell := row.Environments[env]
+	if !cell.Present {
+		return "-"
+	}
+	return cell.Group
+}
+
+func printMatrixTable(report matrixReport) {
+	keyWidth := len("KEY")
+	for _, row := range report.Keys {
+		if len(row.Key) > keyWidth {
+			keyWidth = len(row.Key)
+		}
+	}
+
+	pad := func(s string, width int) string {
+		if n := lipgloss.Width(s); n < width {
+			return s + strings.Repeat(" ", width-n)
+		}
+		return s
+	}
+
+	header := pad("KEY", keyWidth)
+	for _, env := range report.Environments {
+		header += "  " + pad(env, len(env))
+	}
+	fmt.Println(ui.ColorBold(header))
+
+	driftCount := 0
+	for _, row := range report.Keys {
+		if row.Drift {
+			driftCount++
+		}
+		line := pad(row.Key, keyWidth)
+		for _, env := range report.Environments {
+			text := matrixCellText(report, row, env)
+			colorize := ui.ColorGreen
+			switch {
+			case text == "-":
+				colorize = ui.ColorRed
+			case text == "?":
+				colorize = ui.ColorDim
+			case text == matrixUndecryptableGroup:
+				colorize = ui.ColorRed
+			case text != "A":
+				colorize = ui.ColorYellow
+			}
+			line += "  " + colorize(pad(text, len(env)))
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Println(ui.ColorDim("Legend: same letter = identical value, - = missing, ! = could not decrypt, ? = not fetched"))
+	for _, env := range report.Environments {
+		if msg, failed := report.Errors[env]; failed {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: could not fetch %s: %s", env, msg)))
+		}
+	}
+	fmt.Printf("%s %d keys, %d with drift across %d environments\n",
+		ui.ColorBold("Summary:"),
+		len(report.Keys),
+		driftCount,
+		len(report.Environments),
+	)
+}
+
+func writeMatrixCSV(w io.Writer, report matrixReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"key"}, report.Environments...)); err != nil {
+		return err
+	}
+	for _, row := range report.Keys {
+		record := []string{row.Key}
+		for _, env := range report.Environments {
+			switch text := matrixCellText(report, row, env); text {
+			case "-":
+				record = append(record, "missing")
+			case "?":
+				record = append(record, "error")
+			default:
+				record = append(record, text)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(matrixCmd)
+	matrixCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+	matrixCmd.Flags().BoolVar(&matrixJSON, "json", false, "Output the matrix as JSON")
+	matrixCmd.Flags().BoolVar(&matrixCSV, "csv", false, "Output the matrix as CSV")
+}
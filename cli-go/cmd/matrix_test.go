@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+func TestMatrixGroupLabel(t *testing.T) {
+	tests := map[int]string{0: "A", 1: "B", 25: "Z", 26: "AA", 27: "AB"}
+	for in, want := range tests {
+		if got := matrixGroupLabel(in); got != want {
+			t.Fatalf("matrixGroupLabel(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildMatrixReport_GroupsAndDrift(t *testing.T) {
+	envs := []string{"development", "preview", "production"}
+	results := []matrixFetchResult{
+		{env: "development", secrets: []offlinecache.Secret{{Key: "SHARED", Value: "same"}, {Key: "DB_URL", Value: "dev-db"}}},
+		{env: "preview", secrets: []offlinecache.Secret{{Key: "SHARED", Value: "same"}, {Key: "DB_URL", Value: "dev-db"}}},
+		{env: "production", secrets: []offlinecache.Secret{{Key: "SHARED", Value: "same"}, {Key: "DB_URL", Value: "prod-db"}, {Key: "ONLY_PROD", Value: "x"}}},
+	}
+
+	report := buildMatrixReport("p", envs, results)
+	rows := map[string]matrixRow{}
+	for _, r := range report.Keys {
+		rows[r.Key] = r
+	}
+
+	if rows["SHARED"].Drift {
+		t.Fatalf("SHARED should not drift: %+v", rows["SHARED"])
+	}
+	db := rows["DB_URL"]
+	if !db.Drift || db.Environments["development"].Group != "A" || db.Environments["preview"].Group != "A" || db.Environments["production"].Group != "B" {
+		t.Fatalf("unexpected DB_URL groups: %+v", db)
+	}
+	onlyProd := rows["ONLY_PROD"]
+	if !onlyProd.Drift || onlyProd.Environments["development"].Present {
+		t.Fatalf("ONLY_PROD should be missing in development: %+v", onlyProd)
+	}
+}
+
+func TestWriteMatrixCSV_MarksMissingAndErrors(t *testing.T) {
+	envs := []string{"development", "production"}
+	results := []matrixFetchResult{
+		{env: "development", secrets: []offlinecache.Secret{{Key: "A_KEY", Value: "1"}}},
+		{env: "production", err: errors.New("boom")},
+	}
+
+	var buf bytes.Buffer
+	if err := writeMatrixCSV(&buf, buildMatrixReport("p", envs, results)); err != nil {
+		t.Fatalf("writeMatrixCSV: %v", err)
+	}
+
+	want := "key,development,production\nA_KEY,A,error\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestBuildMatrixReport_UndecryptableValuesGetTheirOwnGroup(t *testing.T) {
+	envs := []string{"development", "production"}
+	results := []matrixFetchResult{
+		{env: "development", secrets: []offlinecache.Secret{{Key: "TOKEN", Value: decryptionFailedMarker}}},
+		{env: "production", secrets: []offlinecache.Secret{{Key: "TOKEN", Value: decryptionFailedMarker}}},
+	}
+
+	row := buildMatrixReport("p", envs, results).Keys[0]
+	for _, env := range envs {
+		if cell := row.Environments[env]; cell.Group != matrixUndecryptableGroup {
+			t.Fatalf("expected %s to be marked undecryptable, got %+v", env, cell)
+		}
+	}
+	if !row.Drift {
+		t.Fatalf("undecryptable values must not be reported as identical: %+v", row)
+	}
+}
@@ -1,25 +1,36 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
 )
 
 var globalInstall bool
 var localInstall bool
 var mcpConfigOnly bool
+var mcpClientFlag []string
+var mcpStatusJSON bool
 
-var mcpJwtToken string
+var mcpAgentID string
+var mcpNative bool
+var mcpNativeCommand string
+var mcpNativeArgs []string
+
+var mcpTokenCommand []string
+var mcpTokenTTL string
 var mcpBaseUrl string
 
 var mcpCmd = &cobra.Command{
@@ -29,32 +40,17 @@ var mcpCmd = &cobra.Command{
 
 var mcpInstallCmd = &cobra.Command{
 	Use:   "install",
-	Short: "Install Envault MCP globally (Claude/Cursor) or locally (.vscode)",
+	Short: "Install Envault MCP into detected AI clients, globally and in this project",
 	Run: func(cmd *cobra.Command, args []string) {
+		clients, named := selectMCPClientsOrExit()
+
 		fmt.Println("Configuring AI Clients for Envault MCP...")
 
-		fetchAndSetDelegateToken()
+		prepareMCPServer()
 
-		if !globalInstall && !localInstall {
-			globalInstall = true
-			localInstall = true
-		}
-
-		if globalInstall {
-			fmt.Println("\n--- Global Installations ---")
-			globalLoader := ui.NewLoader(ui.LoaderThemeSync, "Updating global MCP client configs...")
-			globalLoader.Start()
-			installClaudeDesktop()
-			installGlobalCline()
-			globalLoader.Stop()
-		}
-
-		if localInstall {
-			fmt.Println("\n--- Local Workspace Installations ---")
-			localLoader := ui.NewLoader(ui.LoaderThemeSync, "Updating local VS Code MCP config...")
-			localLoader.Start()
-			installLocalVSCodeMCP()
-			localLoader.Stop()
+		if configureMCPClients(clients, named) == 0 {
+			fmt.Println("\n[WARN] No supported MCP clients were detected. Name one with --client (" + strings.Join(mcpClientIDs(), ", ") + ").")
+			return
 		}
 
 		fmt.Println("\n[OK] Installed MCP Server integrations! Please restart your AI client if it's currently open.")
@@ -65,11 +61,13 @@ var mcpUpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update the Envault MCP Server integration",
 	Run: func(cmd *cobra.Command, args []string) {
+		clients, named := selectMCPClientsOrExit()
+
 		fmt.Println("Updating Envault MCP Server...")
 
-		fetchAndSetDelegateToken()
+		prepareMCPServer()
 
-		if !mcpConfigOnly {
+		if !mcpConfigOnly && !mcpNative {
 			pkgLoader := ui.NewLoader(ui.LoaderThemeDeploy, "Updating global MCP package...")
 			pkgLoader.Start()
 			if err := runNpmGlobalInstall("@dinanathdash/envault-mcp-server@latest"); err != nil {
@@ -83,28 +81,106 @@ var mcpUpdateCmd = &cobra.Command{
 		}
 
 		fmt.Println("Refreshing MCP client configurations...")
+		configureMCPClients(clients, named)
 
-		if !globalInstall && !localInstall {
-			globalInstall = true
-			localInstall = true
+		fmt.Println("\n[OK] Envault MCP update completed.")
+	},
+}
+
+var mcpStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which AI clients have Envault MCP configured",
+	Long: `Lists every supported MCP client with its config path, whether Envault is
+configured in it, which server the entry runs, the age of any delegated token
+written into it and whether the entry has drifted from the current release
+or from this binary.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clients, _ := selectMCPClientsOrExit()
+		statuses := collectMCPClientStatuses(clients)
+
+		if mcpStatusJSON {
+			printTokensJSON(statuses)
+			return
 		}
 
-		if globalInstall {
-			globalLoader := ui.NewLoader(ui.LoaderThemeSync, "Refreshing global MCP client configs...")
-			globalLoader.Start()
-			installClaudeDesktop()
-			installGlobalCline()
-			globalLoader.Stop()
+		rows := [][]string{{"CLIENT", "SCOPE", "STATUS", "SERVER", "TOKEN", "VERSION", "CONFIG"}}
+		storedTokens := 0
+		for _, st := range statuses {
+			server, token, versionText := "-", "-", "-"
+			if st.Configured {
+				server, token, versionText = st.Server, st.Token, st.Version
+				if st.Drift != "" {
+					versionText = ui.ColorYellow(versionText + " (" + st.Drift + ")")
+				}
+				if st.TokenInConfig {
+					token = ui.ColorYellow(token)
+					storedTokens++
+				}
+			}
+			rows = append(rows, []string{st.Client, st.Scope, st.Status, server, token, versionText, st.Path})
 		}
+		printCacheTable(rows)
 
-		if localInstall {
-			localLoader := ui.NewLoader(ui.LoaderThemeSync, "Refreshing local MCP config...")
-			localLoader.Start()
-			installLocalVSCodeMCP()
-			localLoader.Stop()
+		if storedTokens > 0 {
+			fmt.Println(ui.ColorYellow(fmt.Sprintf("\n%d config(s) store an Envault token in plain text. Run `envault mcp update --config-only` to replace those tokens with ones fetched at server start.", storedTokens)))
 		}
+	},
+}
 
-		fmt.Println("\n[OK] Envault MCP update completed.")
+var mcpUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove Envault's MCP entry from AI client configs",
+	Long: `Removes only the "envault" server entry from each supported client config,
+leaving every other server and setting untouched. Delegated tokens left in
+old entries expire on their own; revoke them early with
+'envault agent-token revoke mcp-cli'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clients, _ := selectMCPClientsOrExit()
+
+		removed := 0
+		for _, c := range clients {
+			if uninstallMCPClient(c) {
+				removed++
+			}
+		}
+
+		if removed == 0 {
+			fmt.Println("No Envault MCP entries found.")
+			return
+		}
+		fmt.Printf("\n[OK] Removed Envault MCP from %d client config(s). Restart the affected AI clients.\n", removed)
+	},
+}
+
+var mcpTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print a fresh short-lived agent token for an MCP server",
+	Long: `Issues an agent token for the project with your envault login session and
+prints only the token. MCP client configs written by 'envault mcp install'
+run this through ENVAULT_TOKEN_COMMAND when the server starts and again
+before the token expires, so no token is stored in those files.`,
+	Example: `  envault mcp token --project <project-id> --ttl 15m`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, err := offlinecache.ParseMaxAge(mcpTokenTTL)
+		if err != nil || ttl < time.Minute || ttl > 24*time.Hour {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --ttl %q: expected a duration between 1m and 24h, such as 15m.", mcpTokenTTL)))
+			os.Exit(1)
+		}
+		projectID := tokensProjectIDOrExit()
+
+		client := api.NewClient()
+		if !strings.HasPrefix(client.Token, "envault_at_") {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("envault mcp token needs an `envault login` session; ENVAULT_TOKEN cannot issue agent tokens."))
+			os.Exit(1)
+		}
+
+		resp, err := issueAgentToken(context.Background(), client, projectID, strings.TrimSpace(mcpAgentID), strings.TrimSpace(envFlag), nil, ttl, false)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to issue agent token."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+		fmt.Println(resp.Token)
 	},
 }
 
@@ -112,13 +188,32 @@ func init() {
 	rootCmd.AddCommand(mcpCmd)
 	mcpCmd.AddCommand(mcpInstallCmd)
 	mcpCmd.AddCommand(mcpUpdateCmd)
+	mcpCmd.AddCommand(mcpStatusCmd)
+	mcpCmd.AddCommand(mcpUninstallCmd)
+	mcpCmd.AddCommand(mcpTokenCmd)
 
-	mcpInstallCmd.Flags().BoolVarP(&globalInstall, "global", "g", false, "Install globally for Claude Desktop and global VS Code/Cursor")
-	mcpInstallCmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Install locally in the current project (.vscode/mcp.json)")
+	mcpInstallCmd.Flags().BoolVarP(&globalInstall, "global", "g", false, "Install into detected global AI clients (Claude Desktop, Cursor, Windsurf, Zed, ...)")
+	mcpInstallCmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Install locally in the current project (.vscode/mcp.json, .mcp.json)")
 
 	mcpUpdateCmd.Flags().BoolVarP(&globalInstall, "global", "g", false, "Update global installations")
 	mcpUpdateCmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Update local project installations")
+	mcpStatusCmd.Flags().BoolVarP(&globalInstall, "global", "g", false, "Only show global clients")
+	mcpStatusCmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Only show clients configured in the current project")
+	mcpUninstallCmd.Flags().BoolVarP(&globalInstall, "global", "g", false, "Only remove Envault from global client configs")
+	mcpUninstallCmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Only remove Envault from the current project's configs")
+	for _, c := range []*cobra.Command{mcpInstallCmd, mcpUpdateCmd} {
+		c.Flags().StringVar(&mcpAgentID, "agent", "mcp-cli", "Agent name the MCP server acts under in approvals and the audit log")
+		c.Flags().BoolVar(&mcpNative, "native", false, "Run the MCP server built into this binary (`envault mcp serve`) instead of the npm package")
+	}
+	for _, c := range []*cobra.Command{mcpInstallCmd, mcpUpdateCmd, mcpStatusCmd, mcpUninstallCmd} {
+		c.Flags().StringSliceVar(&mcpClientFlag, "client", nil, "Only these clients, configured even if not detected: "+strings.Join(mcpClientIDs(), ", "))
+	}
 	mcpUpdateCmd.Flags().BoolVar(&mcpConfigOnly, "config-only", false, "Only refresh MCP configuration files without npm global package update")
+	mcpStatusCmd.Flags().BoolVar(&mcpStatusJSON, "json", false, "Output as JSON")
+
+	mcpTokenCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID (defaults to the linked project)")
+	mcpTokenCmd.Flags().StringVar(&mcpAgentID, "agent", "mcp-cli", "Agent name the token is issued to")
+	mcpTokenCmd.Flags().StringVar(&mcpTokenTTL, "ttl", "15m", "Lifetime, at most 24h")
 }
 
 func runNpmGlobalInstall(pkg string) error {
@@ -128,59 +223,6 @@ func runNpmGlobalInstall(pkg string) error {
 	return cmd.Run()
 }
 
-func getClaudeConfigPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-	switch runtime.GOOS {
-	case "darwin":
-		return filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json")
-	case "windows":
-		return filepath.Join(os.Getenv("APPDATA"), "Claude", "claude_desktop_config.json")
-	default:
-		return filepath.Join(home, ".config", "Claude", "claude_desktop_config.json")
-	}
-}
-
-func getGlobalClineConfigPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-	switch runtime.GOOS {
-	case "darwin":
-		return filepath.Join(home, "Library", "Application Support", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")
-	case "windows":
-		return filepath.Join(os.Getenv("APPDATA"), "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")
-	default:
-		return filepath.Join(home, ".config", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")
-	}
-}
-
-func getLocalVSCodeMCPPath() string {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return ""
-	}
-	return filepath.Join(cwd, ".vscode", "mcp.json")
-}
-
-func readOrCreateJSON(path string) map[string]interface{} {
-	data := make(map[string]interface{})
-
-	dir := filepath.Dir(path)
-	_ = os.MkdirAll(dir, 0755)
-
-	file, err := os.Open(path)
-	if err == nil {
-		defer file.Close()
-		bytes, _ := io.ReadAll(file)
-		_ = json.Unmarshal(bytes, &data)
-	}
-	return data
-}
-
 func resolveNpxCommand() string {
 	if runtime.GOOS == "windows" {
 		return "npx.cmd"
@@ -188,81 +230,63 @@ func resolveNpxCommand() string {
 	return "npx"
 }
 
-func injectClaudeConfig(config map[string]interface{}) {
-	servers, ok := config["mcpServers"].(map[string]interface{})
-	if !ok || servers == nil {
-		servers = make(map[string]interface{})
-		config["mcpServers"] = servers
-	}
-
-	servers["envault"] = map[string]interface{}{
-		"command": resolveNpxCommand(),
-		"args": []string{
-			"-y",
-			"@dinanathdash/envault-mcp-server@latest",
-		},
-		"env": map[string]string{
-			"ENVAULT_TOKEN":    mcpJwtToken,
-			"ENVAULT_BASE_URL": mcpBaseUrl,
-		},
-	}
-}
-
-func injectVSCodeMCPConfig(config map[string]interface{}) {
-	servers, ok := config["servers"].(map[string]interface{})
-	if !ok || servers == nil {
-		servers = make(map[string]interface{})
-		config["servers"] = servers
-	}
-
-	if _, ok := config["inputs"]; !ok {
-		config["inputs"] = []interface{}{}
+// mcpServerEntry is the server entry written to MCP client configs: the
+// npm package, or with --native this binary's own `mcp serve`. Neither holds
+// a token. The npm package runs ENVAULT_TOKEN_COMMAND (`envault mcp token`)
+// when it starts and again before the token expires; the native server
+// issues its agent tokens itself.
+func mcpServerEntry() map[string]interface{} {
+	if mcpNative {
+		return map[string]interface{}{
+			"command": mcpNativeCommand,
+			"args":    mcpNativeArgs,
+			"env": map[string]string{
+				"ENVAULT_BASE_URL": mcpBaseUrl,
+			},
+		}
 	}
-
-	servers["envault"] = map[string]interface{}{
+	tokenCommand, _ := json.Marshal(mcpTokenCommand)
+	return map[string]interface{}{
 		"command": resolveNpxCommand(),
 		"args": []string{
 			"-y",
-			"@dinanathdash/envault-mcp-server@latest",
+			mcpPackageName + "@latest",
 		},
 		"env": map[string]string{
-			"ENVAULT_TOKEN":    mcpJwtToken,
-			"ENVAULT_BASE_URL": mcpBaseUrl,
+			"ENVAULT_TOKEN_COMMAND": string(tokenCommand),
+			"ENVAULT_BASE_URL":      mcpBaseUrl,
 		},
-		"type": "stdio",
 	}
 }
 
-func writeJSON(path string, config map[string]interface{}) {
-	bytes, err := json.MarshalIndent(config, "", "\t")
-	if err == nil {
-		_ = os.WriteFile(path, bytes, 0644)
+// prepareMCPServer resolves what mcpServerEntry writes. The native server
+// is started with the linked project, when there is one, so that global
+// client configs work from any directory. The npm package needs a project
+// to issue its tokens for.
+func prepareMCPServer() {
+	binPath, err := resolveEnvaultBinary()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Could not locate the envault binary: %v", err)))
+		os.Exit(1)
 	}
-}
+	// Envault MCP server expects root-level ENVAULT_BASE_URL (no /api/cli suffix)
+	mcpBaseUrl = api.AppBaseURL(api.NewClient().BaseURL)
 
-func installClaudeDesktop() {
-	path := getClaudeConfigPath()
-	if path == "" {
-		return
+	var agentArgs []string
+	if agentID := strings.TrimSpace(mcpAgentID); agentID != "" && agentID != "mcp-cli" {
+		agentArgs = []string{"--agent", agentID}
 	}
-	config := readOrCreateJSON(path)
-	injectClaudeConfig(config)
-	writeJSON(path, config)
-	fmt.Printf("[OK] Added Envault MCP to Claude Desktop (%s)\n", path)
-}
 
-func installGlobalCline() {
-	path := getGlobalClineConfigPath()
-	if path == "" {
+	if mcpNative {
+		mcpNativeCommand = binPath
+		mcpNativeArgs = []string{"mcp", "serve"}
+		if projectID := strings.TrimSpace(ensureProjectID()); isValidProjectID(projectID) {
+			mcpNativeArgs = append(mcpNativeArgs, "--project", projectID)
+		}
+		mcpNativeArgs = append(mcpNativeArgs, agentArgs...)
 		return
 	}
-	config := readOrCreateJSON(path)
-	injectClaudeConfig(config)
-	writeJSON(path, config)
-	fmt.Printf("[OK] Added Envault MCP to Global Cline/RooCode (%s)\n", path)
-}
 
-func fetchAndSetDelegateToken() {
 	projectID := ensureProjectID()
 	if projectID == "" {
 		fmt.Fprintln(os.Stderr, ui.ColorYellow("No project linked."))
@@ -275,54 +299,203 @@ func fetchAndSetDelegateToken() {
 		os.Exit(1)
 	}
 
-	fmt.Println("Fetching delegated JWT for local MCP server...")
-	apiClient := api.NewClient()
-	originalBaseURL := apiClient.BaseURL
-
-	// CLI starts at /api/cli, but delegate route is at /api/sdk...
-	apiClient.BaseURL = strings.TrimSuffix(originalBaseURL, "/cli")
-
-	payload := map[string]string{
-		"agentId":   "mcp-cli",
-		"projectId": projectID,
+	// Listing the agent's tokens checks that this session may issue them for
+	// the project without minting one. Nothing is revoked here: other
+	// members and running servers hold live tokens for the same agent name.
+	fmt.Println("Checking that your session can issue agent tokens for the MCP server...")
+	client, _ := mcpLoginUser()
+	if client == nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("The MCP server fetches its token with your `envault login` session; run `envault login` first."))
+		os.Exit(1)
+	}
+	if _, err := client.Get(agentTokensPath(projectID) + "?agentId=" + url.QueryEscape(strings.TrimSpace(mcpAgentID))); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Your session cannot issue agent tokens for this project."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+		os.Exit(1)
 	}
 
-	respBytes, err := apiClient.Post("/sdk/auth/delegate", payload)
-
-	// Restore original base URL immediately
-	apiClient.BaseURL = originalBaseURL
+	mcpTokenCommand = append([]string{binPath, "mcp", "token", "--project", projectID}, agentArgs...)
+}
 
+// selectMCPClientsOrExit resolves --client, --global and --local. Without
+// any of them every client is selected.
+func selectMCPClientsOrExit() ([]mcpClient, bool) {
+	if !globalInstall && !localInstall {
+		globalInstall = true
+		localInstall = true
+	}
+	clients, named, err := selectMCPClients(mcpClientFlag, globalInstall, localInstall)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to fetch delegated JWT: %v", err)))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
 		os.Exit(1)
 	}
+	return clients, named
+}
+
+// configureMCPClients writes the server entry into each client that is
+// installed, or into every named client, and returns how many were written.
+func configureMCPClients(clients []mcpClient, named bool) int {
+	configured := 0
+	for _, local := range []bool{false, true} {
+		var targets []mcpClient
+		for _, c := range clients {
+			if c.Local == local && (named || c.detected()) {
+				targets = append(targets, c)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
 
-	var result struct {
-		Token string `json:"token"`
-		Error string `json:"error"`
+		if local {
+			fmt.Println("\n--- Local Workspace Installations ---")
+		} else {
+			fmt.Println("\n--- Global Installations ---")
+		}
+		for _, c := range targets {
+			installMCPClient(c)
+			configured++
+		}
 	}
+	return configured
+}
 
-	if err := json.Unmarshal(respBytes, &result); err != nil {
-		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to parse delegated response: %v", err)))
-		os.Exit(1)
+type mcpClientStatus struct {
+	Client         string     `json:"client"`
+	Name           string     `json:"name"`
+	Scope          string     `json:"scope"`
+	Path           string     `json:"path"`
+	Detected       bool       `json:"detected"`
+	Configured     bool       `json:"configured"`
+	Status         string     `json:"status"`
+	Server         string     `json:"server,omitempty"`
+	Command        string     `json:"command,omitempty"`
+	Version        string     `json:"version,omitempty"`
+	Drift          string     `json:"drift,omitempty"`
+	Token          string     `json:"token,omitempty"`
+	TokenIssuedAt  *time.Time `json:"tokenIssuedAt,omitempty"`
+	TokenExpiresAt *time.Time `json:"tokenExpiresAt,omitempty"`
+	TokenExpired   bool       `json:"tokenExpired,omitempty"`
+	TokenInConfig  bool       `json:"tokenInConfig,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+func collectMCPClientStatuses(clients []mcpClient) []mcpClientStatus {
+	latest := ""
+	latestFetched := false
+	currentBinary, _ := resolveEnvaultBinary()
+
+	statuses := make([]mcpClientStatus, 0, len(clients))
+	for _, c := range clients {
+		st := mcpClientStatus{Client: c.ID, Name: c.Name, Scope: c.scope(), Path: c.Path(), Detected: c.detected()}
+		config, exists, err := readMCPConfig(st.Path)
+		servers, _ := config[c.ServersKey].(map[string]interface{})
+		raw, configured := servers[mcpServerName]
+		switch {
+		case err != nil:
+			st.Status = "unreadable"
+			st.Error = err.Error()
+		case configured:
+			st.Configured = true
+			st.Status = "configured"
+		case !st.Detected:
+			st.Status = "not installed"
+		case !exists:
+			st.Status = "no config"
+		default:
+			st.Status = "not configured"
+		}
+		if !st.Configured {
+			statuses = append(statuses, st)
+			continue
+		}
+
+		entry := readConfiguredMCPEntry(raw)
+		st.Server = entry.kind()
+		st.Command = entry.Command
+		switch st.Server {
+		case "native":
+			st.Version = version
+			if version != "dev" {
+				st.Version = "v" + version
+			}
+			if _, statErr := os.Stat(entry.Command); statErr != nil {
+				st.Drift = "binary missing"
+			} else if currentBinary != "" && entry.Command != currentBinary {
+				st.Drift = "runs " + entry.Command
+			}
+		case "npm":
+			st.Version = entry.packageVersion()
+			if st.Version == "" {
+				st.Version = "unpinned"
+			}
+			if !latestFetched {
+				latest = fetchLatestMCPVersion()
+				latestFetched = true
+			}
+			if pinned := "v" + st.Version; latest != "" && semver.IsValid(pinned) && semver.Compare(pinned, "v"+latest) < 0 {
+				st.Drift = "latest " + latest
+			}
+		default:
+			st.Version = "unknown"
+		}
+
+		describeMCPEntryToken(&st, entry)
+		statuses = append(statuses, st)
 	}
-	if result.Error != "" {
-		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("API Error: %s", result.Error)))
-		os.Exit(1)
+	return statuses
+}
+
+// describeMCPEntryToken reports the token an entry runs with. Entries
+// written by this version fetch one when the server starts; a token stored
+// in the config file is flagged so that `mcp update` can replace it.
+func describeMCPEntryToken(st *mcpClientStatus, entry configuredMCPEntry) {
+	token := strings.TrimSpace(entry.Env["ENVAULT_TOKEN"])
+	if token == "" {
+		if st.Server == "native" || entry.Env["ENVAULT_TOKEN_COMMAND"] != "" {
+			st.Token = "issued at start"
+		} else {
+			st.Token = "none"
+		}
+		return
+	}
+	st.TokenInConfig = true
+	if !strings.HasPrefix(token, "envault_agt_") {
+		st.Token = "stored in config"
+		return
 	}
 
-	mcpJwtToken = result.Token
-	// Envault MCP server expects root-level ENVAULT_BASE_URL (no /api/cli suffix)
-	mcpBaseUrl = strings.TrimSuffix(apiClient.BaseURL, "/api/cli")
+	info := tokenInfo{Type: "agent"}
+	decodeTokenLocally(token, &info)
+	st.TokenIssuedAt = info.CreatedAt
+	st.TokenExpiresAt = info.ExpiresAt
+	var parts []string
+	if info.CreatedAt != nil {
+		parts = append(parts, "issued "+humanizeDuration(time.Since(*info.CreatedAt))+" ago")
+	}
+	if info.ExpiresAt != nil {
+		if remaining := time.Until(*info.ExpiresAt); remaining > 0 {
+			parts = append(parts, "expires in "+humanizeDuration(remaining))
+		} else {
+			st.TokenExpired = true
+			parts = append(parts, "expired")
+		}
+	}
+	st.Token = strings.Join(append([]string{"stored in config"}, parts...), ", ")
 }
 
-func installLocalVSCodeMCP() {
-	path := getLocalVSCodeMCPPath()
-	if path == "" {
-		return
+// fetchLatestMCPVersion returns the current MCP server release, or "" when
+// the server cannot be reached quickly.
+func fetchLatestMCPVersion() string {
+	body, err := api.NewClient().GetWithTimeout("/api/mcp-version", 3*time.Second)
+	if err != nil {
+		return ""
+	}
+	var resp struct {
+		LatestVersion string `json:"latest_version"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return ""
 	}
-	config := readOrCreateJSON(path)
-	injectVSCodeMCPConfig(config)
-	writeJSON(path, config)
-	fmt.Printf("[OK] Added Envault MCP to Local VS Code Config (%s)\n", path)
+	return strings.TrimPrefix(strings.TrimSpace(resp.LatestVersion), "v")
 }
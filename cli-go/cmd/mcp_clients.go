@@ -0,0 +1,514 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+)
+
+// mcpServerName is the key Envault's entry is stored under in every client
+// config. Nothing else in those files is touched.
+const mcpServerName = "envault"
+
+// mcpPackageName is the npm package the non-native entry runs through npx.
+const mcpPackageName = "@dinanathdash/envault-mcp-server"
+
+// mcpClient describes one MCP client: where its config file lives, which
+// top-level key holds the server map and how a server entry is shaped.
+type mcpClient struct {
+	ID         string
+	Name       string
+	Local      bool
+	ServersKey string
+	// Path returns the config file, or "" when it cannot be resolved.
+	Path func() string
+	// Detect returns a directory whose presence shows the client is
+	// installed. Without it the client is always configured.
+	Detect func() string
+	// Shape adapts the generic command/args/env entry to the client.
+	Shape func(entry map[string]interface{}) map[string]interface{}
+	// Prepare adds any other top-level keys the client requires.
+	Prepare func(config map[string]interface{})
+}
+
+var mcpClients = []mcpClient{
+	{
+		ID:         "claude-desktop",
+		Name:       "Claude Desktop",
+		ServersKey: "mcpServers",
+		Path:       getClaudeConfigPath,
+		Detect:     func() string { return parentDir(getClaudeConfigPath(), 1) },
+	},
+	{
+		ID:         "cline",
+		Name:       "Cline/RooCode",
+		ServersKey: "mcpServers",
+		Path:       getGlobalClineConfigPath,
+		Detect:     func() string { return parentDir(getGlobalClineConfigPath(), 2) },
+	},
+	{
+		ID:         "cursor",
+		Name:       "Cursor",
+		ServersKey: "mcpServers",
+		Path:       func() string { return homePath(".cursor", "mcp.json") },
+		Detect:     func() string { return homePath(".cursor") },
+	},
+	{
+		ID:         "windsurf",
+		Name:       "Windsurf",
+		ServersKey: "mcpServers",
+		Path:       func() string { return homePath(".codeium", "windsurf", "mcp_config.json") },
+		Detect:     func() string { return homePath(".codeium", "windsurf") },
+	},
+	{
+		ID:         "zed",
+		Name:       "Zed",
+		ServersKey: "context_servers",
+		Path:       getZedSettingsPath,
+		Detect:     func() string { return parentDir(getZedSettingsPath(), 1) },
+		Shape: func(entry map[string]interface{}) map[string]interface{} {
+			entry["source"] = "custom"
+			return entry
+		},
+	},
+	{
+		ID:         "jetbrains",
+		Name:       "JetBrains Junie",
+		ServersKey: "mcpServers",
+		Path:       func() string { return homePath(".junie", "mcp", "mcp.json") },
+		Detect:     func() string { return homePath(".junie") },
+	},
+	{
+		ID:         "gemini-cli",
+		Name:       "Gemini CLI",
+		ServersKey: "mcpServers",
+		Path:       func() string { return homePath(".gemini", "settings.json") },
+		Detect:     func() string { return homePath(".gemini") },
+	},
+	{
+		ID:         "opencode",
+		Name:       "opencode",
+		ServersKey: "mcp",
+		Path:       func() string { return homePath(".config", "opencode", "opencode.json") },
+		Detect:     func() string { return homePath(".config", "opencode") },
+		Shape: func(entry map[string]interface{}) map[string]interface{} {
+			command := append([]string{entry["command"].(string)}, entry["args"].([]string)...)
+			return map[string]interface{}{
+				"type":        "local",
+				"command":     command,
+				"environment": entry["env"],
+				"enabled":     true,
+			}
+		},
+	},
+	{
+		ID:         "vscode",
+		Name:       "VS Code (workspace)",
+		Local:      true,
+		ServersKey: "servers",
+		Path:       func() string { return workspacePath(".vscode", "mcp.json") },
+		Shape:      withStdioType,
+		Prepare: func(config map[string]interface{}) {
+			if _, ok := config["inputs"]; !ok {
+				config["inputs"] = []interface{}{}
+			}
+		},
+	},
+	{
+		ID:         "claude-code",
+		Name:       "Claude Code (project)",
+		Local:      true,
+		ServersKey: "mcpServers",
+		Path:       func() string { return workspacePath(".mcp.json") },
+		Detect:     func() string { return homePath(".claude") },
+		Shape:      withStdioType,
+	},
+}
+
+func withStdioType(entry map[string]interface{}) map[string]interface{} {
+	entry["type"] = "stdio"
+	return entry
+}
+
+func (c mcpClient) scope() string {
+	if c.Local {
+		return "local"
+	}
+	return "global"
+}
+
+// detected reports whether the client appears to be installed.
+func (c mcpClient) detected() bool {
+	if c.Detect == nil {
+		return true
+	}
+	dir := c.Detect()
+	if dir == "" {
+		return false
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+func (c mcpClient) entry() map[string]interface{} {
+	entry := mcpServerEntry()
+	if c.Shape != nil {
+		entry = c.Shape(entry)
+	}
+	return entry
+}
+
+// selectMCPClients returns the clients named with --client, or every client
+// in the requested scopes. Named clients are used whether or not they are
+// detected.
+func selectMCPClients(ids []string, global, local bool) ([]mcpClient, bool, error) {
+	if len(ids) == 0 {
+		var selected []mcpClient
+		for _, c := range mcpClients {
+			if (c.Local && local) || (!c.Local && global) {
+				selected = append(selected, c)
+			}
+		}
+		return selected, false, nil
+	}
+
+	var selected []mcpClient
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		found := false
+		for _, c := range mcpClients {
+			if c.ID == id {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, true, fmt.Errorf("unknown MCP client %q (supported: %s)", id, strings.Join(mcpClientIDs(), ", "))
+		}
+	}
+	return selected, true, nil
+}
+
+func mcpClientIDs() []string {
+	ids := make([]string, 0, len(mcpClients))
+	for _, c := range mcpClients {
+		ids = append(ids, c.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func homePath(elem ...string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+func workspacePath(elem ...string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(append([]string{cwd}, elem...)...)
+}
+
+func parentDir(path string, levels int) string {
+	if path == "" {
+		return ""
+	}
+	for i := 0; i < levels; i++ {
+		path = filepath.Dir(path)
+	}
+	return path
+}
+
+func getClaudeConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json")
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "Claude", "claude_desktop_config.json")
+	default:
+		return filepath.Join(home, ".config", "Claude", "claude_desktop_config.json")
+	}
+}
+
+func getGlobalClineConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")
+	default:
+		return filepath.Join(home, ".config", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")
+	}
+}
+
+// getZedSettingsPath follows Zed: XDG_CONFIG_HOME on Linux, ~/.config on
+// macOS and %APPDATA% on Windows.
+func getZedSettingsPath() string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "Zed", "settings.json")
+	case "darwin":
+		return homePath(".config", "zed", "settings.json")
+	default:
+		if xdg := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); xdg != "" {
+			return filepath.Join(xdg, "zed", "settings.json")
+		}
+		return homePath(".config", "zed", "settings.json")
+	}
+}
+
+// readMCPConfig reads a client config. A missing or empty file is an empty
+// config; a file that is not plain JSON (Zed allows comments, for example)
+// is an error so that it is never overwritten.
+func readMCPConfig(path string) (map[string]interface{}, bool, error) {
+	config := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return config, true, nil
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, true, fmt.Errorf("not plain JSON: %v", err)
+	}
+	return config, true, nil
+}
+
+func writeMCPConfig(path string, config map[string]interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// installMCPClient writes Envault's entry into one client config, leaving
+// every other key as it was.
+func installMCPClient(c mcpClient) {
+	path := c.Path()
+	if path == "" {
+		return
+	}
+	config, _, err := readMCPConfig(path)
+	if err != nil {
+		fmt.Printf("[WARN] Skipped %s: could not read %s (%v).\n", c.Name, path, err)
+		snippet, _ := json.MarshalIndent(map[string]interface{}{c.ServersKey: map[string]interface{}{mcpServerName: c.entry()}}, "", "  ")
+		fmt.Printf("[WARN] Add this entry by hand:\n%s\n", snippet)
+		return
+	}
+
+	servers, ok := config[c.ServersKey].(map[string]interface{})
+	if !ok || servers == nil {
+		servers = make(map[string]interface{})
+		config[c.ServersKey] = servers
+	}
+	revokeStoredMCPToken(servers[mcpServerName])
+	servers[mcpServerName] = c.entry()
+	if c.Prepare != nil {
+		c.Prepare(config)
+	}
+
+	if err := writeMCPConfig(path, config); err != nil {
+		fmt.Printf("[WARN] Could not write %s config (%s): %v\n", c.Name, path, err)
+		return
+	}
+	fmt.Printf("[OK] Added Envault MCP to %s (%s)\n", c.Name, path)
+}
+
+// uninstallMCPClient removes Envault's entry from one client config and
+// drops the server map if nothing else is left in it. It reports whether an
+// entry was removed.
+func uninstallMCPClient(c mcpClient) bool {
+	path := c.Path()
+	if path == "" {
+		return false
+	}
+	config, exists, err := readMCPConfig(path)
+	if !exists {
+		return false
+	}
+	if err != nil {
+		fmt.Printf("[WARN] Skipped %s: could not read %s (%v). Remove %q from %q by hand.\n", c.Name, path, err, mcpServerName, c.ServersKey)
+		return false
+	}
+	servers, ok := config[c.ServersKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	entry, ok := servers[mcpServerName]
+	if !ok {
+		return false
+	}
+	revokeStoredMCPToken(entry)
+	delete(servers, mcpServerName)
+	if len(servers) == 0 {
+		delete(config, c.ServersKey)
+	}
+
+	if err := writeMCPConfig(path, config); err != nil {
+		fmt.Printf("[WARN] Could not write %s config (%s): %v\n", c.Name, path, err)
+		return false
+	}
+	fmt.Printf("[OK] Removed Envault MCP from %s (%s)\n", c.Name, path)
+	return true
+}
+
+var mcpLogin struct {
+	once   sync.Once
+	client *api.Client
+	userID string
+}
+
+// mcpLoginUser returns a client authenticated with the `envault login`
+// session and the ID of its user, or nil when there is no usable session.
+func mcpLoginUser() (*api.Client, string) {
+	mcpLogin.once.Do(func() {
+		client, err := api.LoadClient()
+		if err != nil || !strings.HasPrefix(client.Token, "envault_at_") {
+			return
+		}
+		body, err := client.Get("/me")
+		if err != nil {
+			return
+		}
+		var me struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(body, &me) != nil || me.ID == "" {
+			return
+		}
+		mcpLogin.client, mcpLogin.userID = client, me.ID
+	})
+	return mcpLogin.client, mcpLogin.userID
+}
+
+// revokeStoredMCPToken revokes the agent token an older version of
+// `mcp install` wrote into an entry that is being replaced or removed. Only
+// live tokens issued by the current user are revoked; tokens of other
+// members, and those held by running servers, are left alone.
+func revokeStoredMCPToken(raw interface{}) {
+	claims, ok := parseAgentTokenClaims(strings.TrimSpace(readConfiguredMCPEntry(raw).Env["ENVAULT_TOKEN"]))
+	if !ok || claims.Jti == "" || (claims.Exp > 0 && time.Now().Unix() >= claims.Exp) {
+		return
+	}
+	client, userID := mcpLoginUser()
+	if client == nil || claims.Sub != userID {
+		fmt.Printf("[WARN] The old entry held agent token %s; revoke it with 'envault agent-token revoke %s' if it is no longer needed.\n", claims.Jti, claims.Jti)
+		return
+	}
+	for _, projectID := range claims.Projects {
+		_, err := client.Delete(agentTokensPath(projectID) + "/" + url.PathEscape(claims.Jti))
+		var apiErr *api.APIError
+		if err != nil && !(errors.As(err, &apiErr) && (apiErr.StatusCode == 404 || apiErr.StatusCode == 409)) {
+			fmt.Printf("[WARN] Could not revoke agent token %s stored in the old entry: %s\n", claims.Jti, classifyAPIError(err))
+			return
+		}
+	}
+	fmt.Printf("[OK] Revoked agent token %s stored in the old entry\n", claims.Jti)
+}
+
+// configuredMCPEntry is Envault's entry as found in a client config,
+// normalised across the client shapes.
+type configuredMCPEntry struct {
+	Command string
+	Args    []string
+	Env     map[string]string
+}
+
+func readConfiguredMCPEntry(raw interface{}) configuredMCPEntry {
+	var entry configuredMCPEntry
+	fields, _ := raw.(map[string]interface{})
+	switch command := fields["command"].(type) {
+	case string:
+		entry.Command = command
+		entry.Args = stringList(fields["args"])
+	case []interface{}:
+		parts := stringList(command)
+		if len(parts) > 0 {
+			entry.Command = parts[0]
+			entry.Args = parts[1:]
+		}
+	}
+	env, ok := fields["env"].(map[string]interface{})
+	if !ok {
+		env, _ = fields["environment"].(map[string]interface{})
+	}
+	entry.Env = map[string]string{}
+	for k, v := range env {
+		if s, ok := v.(string); ok {
+			entry.Env[k] = s
+		}
+	}
+	return entry
+}
+
+func stringList(raw interface{}) []string {
+	items, _ := raw.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// kind reports whether the entry runs the native server, the npm package or
+// something Envault did not write.
+func (e configuredMCPEntry) kind() string {
+	if len(e.Args) >= 2 && e.Args[0] == "mcp" && e.Args[1] == "serve" {
+		return "native"
+	}
+	if e.packageSpec() != "" {
+		return "npm"
+	}
+	return "custom"
+}
+
+// packageSpec returns the npm package argument, e.g.
+// "@dinanathdash/envault-mcp-server@latest".
+func (e configuredMCPEntry) packageSpec() string {
+	for _, arg := range e.Args {
+		if strings.HasPrefix(arg, mcpPackageName) {
+			return arg
+		}
+	}
+	return ""
+}
+
+// packageVersion returns the version the npm entry pins: "latest", a
+// version number, or "" when unpinned.
+func (e configuredMCPEntry) packageVersion() string {
+	return strings.TrimPrefix(strings.TrimPrefix(e.packageSpec(), mcpPackageName), "@")
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMCPClients_InstallStatusUninstall(t *testing.T) {
+	const projectID = "11111111-1111-4111-8111-111111111111"
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/mcp-version" {
+			_, _ = w.Write([]byte(`{"latest_version":"1.12.0"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(tmp, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(rel string) map[string]interface{} {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(tmp, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var config map[string]interface{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			t.Fatalf("%s is not JSON: %v\n%s", rel, err, data)
+		}
+		return config
+	}
+
+	write("envault.json", `{"projectId":"`+projectID+`"}`)
+	write(".cursor/mcp.json", `{"mcpServers":{"other":{"command":"other-server"}}}`)
+	zedSettings := "// Zed settings\n{\n  \"theme\": \"One Dark\"\n}\n"
+	write(".config/zed/settings.json", zedSettings)
+	if err := os.MkdirAll(filepath.Join(tmp, ".config", "opencode"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	bin := buildBinary(t)
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = tmp
+		cmd.Env = append(os.Environ(),
+			"HOME="+tmp,
+			"XDG_CONFIG_HOME="+filepath.Join(tmp, ".config"),
+			"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+			"ENVAULT_ALLOW_INSECURE_HTTP=1",
+			"ENVAULT_TOKEN=",
+			"ENVAULT_CREDENTIAL_STORE=env",
+			"ENVAULT_ACCESS_TOKEN=envault_at_me",
+			"NO_COLOR=1",
+		)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := run("mcp", "install", "--native", "--global")
+	if err != nil {
+		t.Fatalf("mcp install failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Skipped Zed") || strings.Contains(out, "Claude Desktop") {
+		t.Fatalf("expected Zed to be skipped and undetected clients left alone:\n%s", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmp, ".config", "zed", "settings.json")); string(data) != zedSettings {
+		t.Fatalf("settings with comments must never be rewritten, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".config", "Claude")); !os.IsNotExist(err) {
+		t.Fatalf("expected no Claude Desktop config to be created")
+	}
+
+	cursor := read(".cursor/mcp.json")["mcpServers"].(map[string]interface{})
+	if cursor["other"] == nil || cursor["envault"] == nil {
+		t.Fatalf("expected Envault added next to the existing server, got %v", cursor)
+	}
+	opencode := read(".config/opencode/opencode.json")["mcp"].(map[string]interface{})["envault"].(map[string]interface{})
+	if command, _ := opencode["command"].([]interface{}); opencode["type"] != "local" || len(command) < 3 || command[1] != "mcp" {
+		t.Fatalf("unexpected opencode entry: %v", opencode)
+	}
+
+	claims, _ := json.Marshal(map[string]interface{}{"act": "mcp-cli", "iat": time.Now().Add(-3 * time.Hour).Unix(), "exp": time.Now().Add(-2 * time.Hour).Unix()})
+	token := "envault_agt_e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+	write(".codeium/windsurf/mcp_config.json", fmt.Sprintf(`{"mcpServers":{"envault":{"command":"npx","args":["-y","@dinanathdash/envault-mcp-server@1.2.0"],"env":{"ENVAULT_TOKEN":%q}}}}`, token))
+
+	out, err = run("mcp", "status", "--json")
+	var statuses []mcpClientStatus
+	if err != nil || json.Unmarshal([]byte(out), &statuses) != nil {
+		t.Fatalf("mcp status --json failed: %v\n%s", err, out)
+	}
+	byClient := map[string]mcpClientStatus{}
+	for _, st := range statuses {
+		byClient[st.Client] = st
+	}
+	if st := byClient["cursor"]; !st.Configured || st.Server != "native" || st.Drift != "" || st.Token != "issued at start" {
+		t.Fatalf("unexpected cursor status: %+v", st)
+	}
+	if st := byClient["windsurf"]; st.Server != "npm" || st.Version != "1.2.0" || st.Drift != "latest 1.12.0" || !st.TokenInConfig || !st.TokenExpired || st.TokenIssuedAt == nil {
+		t.Fatalf("expected version drift and an expired token for windsurf: %+v", st)
+	}
+	if st := byClient["zed"]; st.Status != "unreadable" {
+		t.Fatalf("expected zed to be reported unreadable: %+v", st)
+	}
+	if st := byClient["claude-desktop"]; st.Status != "not installed" {
+		t.Fatalf("expected claude desktop to be reported not installed: %+v", st)
+	}
+
+	if out, err = run("mcp", "uninstall", "--client", "cursor"); err != nil {
+		t.Fatalf("mcp uninstall --client failed: %v\n%s", err, out)
+	}
+	cursor = read(".cursor/mcp.json")["mcpServers"].(map[string]interface{})
+	if cursor["envault"] != nil || cursor["other"] == nil {
+		t.Fatalf("expected only Envault's entry removed, got %v", cursor)
+	}
+	if read(".codeium/windsurf/mcp_config.json")["mcpServers"] == nil {
+		t.Fatalf("expected other clients untouched by --client cursor")
+	}
+
+	if out, err = run("mcp", "uninstall"); err != nil || !strings.Contains(out, "Removed Envault MCP from 2 client config(s)") {
+		t.Fatalf("mcp uninstall failed: %v\n%s", err, out)
+	}
+	if config := read(".codeium/windsurf/mcp_config.json"); len(config) != 0 {
+		t.Fatalf("expected the emptied server map to be dropped, got %v", config)
+	}
+
+	if out, err = run("mcp", "install", "--client", "nope"); err == nil || !strings.Contains(out, "unknown MCP client") {
+		t.Fatalf("expected an unknown client to be refused, got err=%v\n%s", err, out)
+	}
+}
+
+func TestMCPInstall_EntryRunsTokenCommandInsteadOfStoringToken(t *testing.T) {
+	const projectID = "11111111-1111-4111-8111-111111111111"
+	base := "/api/cli/projects/" + projectID + "/agent-tokens"
+	api := newMockAPI(t, "envault_at_me", func(w http.ResponseWriter, req recordedRequest) {
+		switch {
+		case req.Path == "/api/cli/me":
+			_, _ = w.Write([]byte(`{"id":"u1","email":"me@example.com"}`))
+		case req.Method == http.MethodGet && req.Path == base:
+			_, _ = w.Write([]byte(`{"tokens":[]}`))
+		case req.Method == http.MethodPost && req.Path == base:
+			_, _ = w.Write([]byte(`{"token":"envault_agt_fresh","tokenRecord":{"id":"t1","agent_id":"mcp-cli","expires_at":"` + time.Now().Add(15*time.Minute).UTC().Format(time.RFC3339) + `","status":"active"},"rotated":[]}`))
+		case req.Method == http.MethodDelete && strings.HasPrefix(req.Path, base+"/"):
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "envault.json"), []byte(`{"projectId":"`+projectID+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// An entry written by an older version holds a live token of this user.
+	claims, _ := json.Marshal(map[string]interface{}{"sub": "u1", "act": "mcp-cli", "projects": []string{projectID}, "jti": "old1", "exp": time.Now().Add(time.Hour).Unix()})
+	stored := "envault_agt_e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+	if err := os.MkdirAll(filepath.Join(tmp, ".vscode"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ".vscode", "mcp.json"), []byte(fmt.Sprintf(`{"servers":{"envault":{"command":"npx","env":{"ENVAULT_TOKEN":%q}}}}`, stored)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := newSessionCLIIn(t, tmp, api, "envault_at_me")
+	runCI := newSessionCLIIn(t, tmp, api, "envault_at_me", "ENVAULT_TOKEN=envault_svc_ci")
+
+	stdout, stderr, err := run("mcp", "install", "--local")
+	if err != nil {
+		t.Fatalf("mcp install failed: %v\n%s%s", err, stdout, stderr)
+	}
+	if issued := api.Requests(http.MethodPost); len(issued) != 0 {
+		t.Fatalf("install must not issue tokens, got %v", issued)
+	}
+	if deleted := api.Requests(http.MethodDelete); len(deleted) != 1 || deleted[0].Path != base+"/old1" {
+		t.Fatalf("expected only the token stored in the replaced entry to be revoked, got %v", deleted)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmp, ".vscode", "mcp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "envault_agt_") {
+		t.Fatalf("the config file must not hold a token:\n%s", data)
+	}
+	var config struct {
+		Servers map[string]struct {
+			Env map[string]string `json:"env"`
+		} `json:"servers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	var tokenCommand []string
+	if err := json.Unmarshal([]byte(config.Servers["envault"].Env["ENVAULT_TOKEN_COMMAND"]), &tokenCommand); err != nil {
+		t.Fatalf("expected a JSON argv in ENVAULT_TOKEN_COMMAND, got %v", config.Servers["envault"].Env)
+	}
+	if len(tokenCommand) != 5 || strings.Join(tokenCommand[1:], " ") != "mcp token --project "+projectID {
+		t.Fatalf("unexpected token command: %v", tokenCommand)
+	}
+
+	stdout, stderr, err = run(tokenCommand[1:]...)
+	if err != nil || stdout != "envault_agt_fresh\n" {
+		t.Fatalf("mcp token must print only the token (%v):\n%q\n%s", err, stdout, stderr)
+	}
+	issued := api.Requests(http.MethodPost)
+	if len(issued) != 1 || issued[0].Body["rotate"] != false || issued[0].Body["ttl_seconds"] != float64(900) || issued[0].Body["agent_id"] != "mcp-cli" {
+		t.Fatalf("expected one non-rotating 15m token, got %v", issued)
+	}
+
+	if _, stderr, err = runCI(tokenCommand[1:]...); err == nil || !strings.Contains(stderr, "envault login") {
+		t.Fatalf("expected a service token to be refused, got err=%v stderr=%s", err, stderr)
+	}
+}
@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/mcp"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// mcpRequestTimeout bounds each API call a tool makes, so a hung server
+// never stalls the assistant.
+const mcpRequestTimeout = 20 * time.Second
+
+// mcpAgentTokenTTL is how long the agent tokens the server issues for itself
+// last; they are renewed once less than mcpAgentTokenRenewal is left.
+const (
+	mcpAgentTokenTTL     = time.Hour
+	mcpAgentTokenRenewal = 5 * time.Minute
+)
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the Envault MCP server over stdio",
+	Long: `Run a Model Context Protocol server on stdin and stdout, built into the
+envault binary, so AI assistants can use Envault without Node.js.
+
+The server offers tools to list projects and environments, read secret
+metadata (key names and lengths, never values), propose secret changes for
+human approval, check on an approval and audit the local env files.
+
+Reads use your CLI login, falling back to the offline cache when the API is
+unreachable. Proposed changes are sent with an agent token the server issues
+for itself under --agent, so approvals and the audit log name the agent.
+
+Point an MCP client at it with 'envault mcp install --native'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := approvalContext()
+		defer cancel()
+
+		session := &mcpSession{
+			client:      api.NewClient(),
+			agentID:     strings.TrimSpace(mcpAgentID),
+			agentTokens: map[string]agentTokenIssueResponse{},
+		}
+		if err := newEnvaultMCPServer(session).Serve(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("MCP server stopped: %v", err)))
+			os.Exit(1)
+		}
+	},
+}
+
+// mcpSession is the state the MCP tools share: the CLI's API client and the
+// agent tokens issued so far, one per project.
+type mcpSession struct {
+	client  *api.Client
+	agentID string
+
+	mu          sync.Mutex
+	agentTokens map[string]agentTokenIssueResponse
+}
+
+// agentClient returns a client that acts as the agent in projectID. An agent
+// token passed in ENVAULT_TOKEN is used as is; otherwise one is issued with
+// the CLI login and reused until it nears expiry.
+func (s *mcpSession) agentClient(ctx context.Context, projectID string) (*api.Client, error) {
+	token := s.client.Token
+	switch {
+	case strings.HasPrefix(token, "envault_agt_"):
+	case strings.HasPrefix(token, "envault_at_"):
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		issued, ok := s.agentTokens[projectID]
+		if !ok || time.Until(issued.Record.ExpiresAt) < mcpAgentTokenRenewal {
+			var err error
+			issued, err = issueAgentToken(ctx, s.client, projectID, s.agentID, "", nil, mcpAgentTokenTTL, false)
+			if err != nil {
+				return nil, fmt.Errorf("could not issue an agent token: %s", approvalErrorMessage(err))
+			}
+			s.agentTokens[projectID] = issued
+		}
+		token = issued.Token
+	default:
+		return nil, errors.New("proposing changes needs `envault login` or an agent token in ENVAULT_TOKEN")
+	}
+	return &api.Client{BaseURL: s.client.BaseURL, Token: token, HTTP: s.client.HTTP}, nil
+}
+
+func (s *mcpSession) projectID(requested string) (string, error) {
+	projectID := strings.TrimSpace(requested)
+	if projectID == "" {
+		projectID = strings.TrimSpace(ensureProjectID())
+	}
+	if !isValidProjectID(projectID) {
+		return "", errors.New("no project: pass projectId (see envault_list_projects) or start the server with --project")
+	}
+	return projectID, nil
+}
+
+func (s *mcpSession) environment(requested string) string {
+	if env := strings.TrimSpace(requested); env != "" {
+		return env
+	}
+	return resolveTargetEnvironment()
+}
+
+func newEnvaultMCPServer(s *mcpSession) *mcp.Server {
+	server := mcp.NewServer("envault", version)
+	server.Instructions = "Envault manages a team's environment secrets. Secret values are never returned. " +
+		"Changes proposed with envault_request_change wait for a person to approve them: show the approval_url to the user and check envault_approval_status before assuming a change is applied."
+
+	projectIDProp := map[string]interface{}{"type": "string", "description": "Project ID. Defaults to the project linked in envault.json or passed with --project."}
+	environmentProp := map[string]interface{}{"type": "string", "description": "Environment slug. Defaults to the project's default environment."}
+	object := func(properties map[string]interface{}, required ...string) map[string]interface{} {
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	server.AddTool(mcp.Tool{
+		Name:        "envault_list_projects",
+		Description: "List the Envault projects the user can access, and the project linked to the current directory.",
+		InputSchema: object(map[string]interface{}{}),
+		Handler:     s.listProjects,
+	})
+	server.AddTool(mcp.Tool{
+		Name:        "envault_list_environments",
+		Description: "List the environments of a project that the user may read.",
+		InputSchema: object(map[string]interface{}{"projectId": projectIDProp}),
+		Handler:     s.listEnvironments,
+	})
+	server.AddTool(mcp.Tool{
+		Name:        "envault_secret_metadata",
+		Description: "Describe the secrets of an environment: key names, value lengths and whether each value is empty. Values are never returned. Falls back to the offline cache when the API is unreachable.",
+		InputSchema: object(map[string]interface{}{"projectId": projectIDProp, "environment": environmentProp}),
+		Handler:     s.secretMetadata,
+	})
+	server.AddTool(mcp.Tool{
+		Name:        "envault_request_change",
+		Description: "Propose secret changes for human approval. Nothing changes until a person approves: show the returned approval_url to the user. A request covered by an earlier time-boxed grant is applied at once and returns status \"approved\".",
+		InputSchema: object(map[string]interface{}{
+			"projectId":   projectIDProp,
+			"environment": environmentProp,
+			"changes": map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items": object(map[string]interface{}{
+					"key":    map[string]interface{}{"type": "string"},
+					"value":  map[string]interface{}{"type": "string", "description": "New value; required for upsert."},
+					"action": map[string]interface{}{"type": "string", "enum": []string{"upsert", "delete"}, "default": "upsert"},
+				}, "key"),
+			},
+		}, "changes"),
+		Handler: s.requestChange,
+	})
+	server.AddTool(mcp.Tool{
+		Name:        "envault_approval_status",
+		Description: "Check whether a proposed change was approved, rejected or has expired.",
+		InputSchema: object(map[string]interface{}{
+			"approvalId": map[string]interface{}{"type": "string"},
+			"projectId":  projectIDProp,
+		}, "approvalId"),
+		Handler: s.approvalStatus,
+	})
+	server.AddTool(mcp.Tool{
+		Name:        "envault_audit",
+		Description: "Audit the local env files: .gitignore coverage, env files tracked by git, key parity with the template and placeholder values. Values are never returned.",
+		InputSchema: object(map[string]interface{}{
+			"file":     map[string]interface{}{"type": "string", "description": "Env file to audit (default .env)."},
+			"template": map[string]interface{}{"type": "string", "description": "Template to compare against (default .env.example)."},
+			"strict":   map[string]interface{}{"type": "boolean", "description": "Treat warnings as errors."},
+		}),
+		Handler: s.audit,
+	})
+	return server
+}
+
+func decodeToolArgs(raw json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid arguments: %v", err)
+	}
+	return nil
+}
+
+func (s *mcpSession) listProjects(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	respBytes, err := s.client.GetWithContextAndTimeout(ctx, "/projects", mcpRequestTimeout)
+	if err != nil {
+		return nil, errors.New(approvalErrorMessage(err))
+	}
+	var resp ProjectResponse
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("invalid projects response: %w", err)
+	}
+	linked := ""
+	if id := strings.TrimSpace(ensureProjectID()); isValidProjectID(id) {
+		linked = id
+	}
+	return map[string]interface{}{"projects": resp.Projects, "linkedProjectId": linked}, nil
+}
+
+func (s *mcpSession) listEnvironments(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		ProjectID string `json:"projectId"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	projectID, err := s.projectID(args.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, mcpRequestTimeout)
+	defer cancel()
+	environments, err := requestAuthorizedEnvironments(ctx, s.client, projectID)
+	if err != nil {
+		return nil, errors.New(approvalErrorMessage(err))
+	}
+	return map[string]interface{}{"projectId": projectID, "environments": environments}, nil
+}
+
+type mcpSecretMetadata struct {
+	Key    string `json:"key"`
+	Length int    `json:"length"`
+	Status string `json:"status"`
+}
+
+func (s *mcpSession) secretMetadata(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		ProjectID   string `json:"projectId"`
+		Environment string `json:"environment"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	projectID, err := s.projectID(args.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	environment := s.environment(args.Environment)
+
+	out := map[string]interface{}{"projectId": projectID, "environment": environment, "source": "api"}
+	secrets, err := fetchEnvironmentSecrets(ctx, s.client, projectID, environment, mcpRequestTimeout, nil)
+	if err != nil {
+		if !api.IsFallbackEligible(err) {
+			return nil, errors.New(approvalErrorMessage(err))
+		}
+		cached, cachedAt, cacheErr := offlinecache.Load(projectID, environment)
+		if cacheErr != nil {
+			return nil, fmt.Errorf("the Envault API is unreachable (%v) and the offline cache has no usable entry: %v", err, cacheErr)
+		}
+		secrets = cached
+		out["source"] = "offline-cache"
+		out["cachedAt"] = cachedAt.UTC().Format(time.RFC3339)
+	}
+
+	metadata := make([]mcpSecretMetadata, 0, len(secrets))
+	for _, secret := range secrets {
+		status := "set"
+		switch {
+		case secret.Value == decryptionFailedMarker:
+			status = "undecryptable"
+		case secret.Value == "":
+			status = "empty"
+		}
+		length := utf8.RuneCountInString(secret.Value)
+		if status == "undecryptable" {
+			length = 0
+		}
+		metadata = append(metadata, mcpSecretMetadata{Key: secret.Key, Length: length, Status: status})
+	}
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].Key < metadata[j].Key })
+	out["secrets"] = metadata
+	return out, nil
+}
+
+func (s *mcpSession) requestChange(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		ProjectID   string `json:"projectId"`
+		Environment string `json:"environment"`
+		Changes     []struct {
+			Key    string  `json:"key"`
+			Value  *string `json:"value"`
+			Action string  `json:"action"`
+		} `json:"changes"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Changes) == 0 {
+		return nil, errors.New("changes must list at least one key")
+	}
+	mutations := make([]map[string]string, 0, len(args.Changes))
+	for _, change := range args.Changes {
+		key := strings.TrimSpace(change.Key)
+		action := strings.TrimSpace(change.Action)
+		if action == "" {
+			action = "upsert"
+		}
+		switch {
+		case key == "":
+			return nil, errors.New("every change needs a key")
+		case action != "upsert" && action != "delete":
+			return nil, fmt.Errorf("invalid action %q for %s: expected upsert or delete", change.Action, key)
+		case action == "upsert" && change.Value == nil:
+			return nil, fmt.Errorf("upsert of %s needs a value", key)
+		}
+		mutation := map[string]string{"key": key, "action": action}
+		if change.Value != nil && action == "upsert" {
+			mutation["value"] = *change.Value
+		}
+		mutations = append(mutations, mutation)
+	}
+	projectID, err := s.projectID(args.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.agentClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	client.Header = http.Header{"Idempotency-Key": {hex.EncodeToString(key)}}
+
+	ctx, cancel := context.WithTimeout(ctx, mcpRequestTimeout)
+	defer cancel()
+	respBytes, err := client.PostWithContext(ctx, "/api/sdk/secrets", map[string]interface{}{
+		"projectId": projectID,
+		"payload": map[string]interface{}{
+			"environment": s.environment(args.Environment),
+			"mutations":   mutations,
+		},
+	})
+	if err != nil {
+		return nil, errors.New(approvalErrorMessage(err))
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(respBytes, &out); err != nil {
+		return nil, fmt.Errorf("unexpected response: %s", strings.TrimSpace(string(respBytes)))
+	}
+	return out, nil
+}
+
+// approvalStatus reads the status route with the CLI login when there is
+// one, which reports the decision without consuming it, and otherwise as
+// the agent.
+func (s *mcpSession) approvalStatus(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		ApprovalID string `json:"approvalId"`
+		ProjectID  string `json:"projectId"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	approvalID := strings.TrimSpace(args.ApprovalID)
+	if approvalID == "" {
+		return nil, errors.New("approvalId is required")
+	}
+
+	client := s.client
+	if !strings.HasPrefix(client.Token, "envault_at_") {
+		projectID, err := s.projectID(args.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if client, err = s.agentClient(ctx, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mcpRequestTimeout)
+	defer cancel()
+	status, request, err := waitForApprovalDecision(ctx, client, approvalID, time.Now())
+	if err != nil {
+		return nil, errors.New(approvalErrorMessage(err))
+	}
+	out := map[string]interface{}{"approvalId": approvalID, "status": status}
+	if request.ID != "" {
+		out["approval"] = request.toJSON()
+	}
+	return out, nil
+}
+
+func (s *mcpSession) audit(_ context.Context, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		File     string `json:"file"`
+		Template string `json:"template"`
+		Strict   bool   `json:"strict"`
+	}
+	if err := decodeToolArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	previousFile, previousTemplate := auditEnvFile, auditTemplate
+	defer func() { auditEnvFile, auditTemplate = previousFile, previousTemplate }()
+	if strings.TrimSpace(args.File) != "" {
+		auditEnvFile = strings.TrimSpace(args.File)
+	}
+	if strings.TrimSpace(args.Template) != "" {
+		auditTemplate = strings.TrimSpace(args.Template)
+	}
+	return collectAuditResult(args.Strict, nil), nil
+}
+
+func init() {
+	mcpCmd.AddCommand(mcpServeCmd)
+	mcpServeCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Default project ID for tools called without one")
+	mcpServeCmd.Flags().StringVar(&mcpAgentID, "agent", "mcp-cli", "Agent name the server acts under in approvals and the audit log")
+}
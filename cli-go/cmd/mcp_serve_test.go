@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMCPServe_ToolsOverStdio(t *testing.T) {
+	const projectID = "11111111-1111-4111-8111-111111111111"
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	var issued, proposed map[string]interface{}
+	var idempotencyKey string
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		auth := r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/api/sdk/secrets":
+			if auth != "Bearer envault_agt_issued" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			idempotencyKey = r.Header.Get("Idempotency-Key")
+			_ = json.NewDecoder(r.Body).Decode(&proposed)
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"status":"pending","approval_id":"a1","approval_url":"https://envault.tech/approve/a1"}`))
+		case auth != "Bearer envault_at_me":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/api/cli/projects":
+			_, _ = w.Write([]byte(`{"projects":[{"id":"` + projectID + `","name":"Shop"}]}`))
+		case r.URL.Path == "/api/cli/projects/"+projectID+"/environments":
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"development","isDefault":true}]}`))
+		case r.URL.Path == "/api/cli/projects/"+projectID+"/secrets":
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"STRIPE_KEY","value":"sk_live_abcdef"},{"key":"EMPTY","value":""}]}`))
+		case r.URL.Path == "/api/cli/projects/"+projectID+"/agent-tokens":
+			_ = json.NewDecoder(r.Body).Decode(&issued)
+			_, _ = w.Write([]byte(`{"token":"envault_agt_issued","tokenRecord":{"id":"t1","agent_id":"pairing-bot","expires_at":"` + expires + `","status":"active"},"rotated":[]}`))
+		case r.URL.Path == "/api/sdk/approvals/a1/status":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"status":"pending","approval":{"id":"a1","status":"pending","agent_id":"pairing-bot"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	if err := os.WriteFile(tmp+"/.gitignore", []byte(".env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := buildBinary(t)
+
+	calls := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"envault_list_projects","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"envault_list_environments","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"envault_secret_metadata","arguments":{"environment":"development"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"envault_request_change","arguments":{"changes":[{"key":"OPENAI_API_KEY","value":"sk-new"},{"key":"OLD","action":"delete"}]}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"envault_approval_status","arguments":{"approvalId":"a1"}}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"envault_audit","arguments":{}}}`,
+	}
+	cmd := exec.Command(bin, "mcp", "serve", "--project", projectID, "--agent", "pairing-bot")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"ENVAULT_TOKEN=",
+		"ENVAULT_CREDENTIAL_STORE=env",
+		"ENVAULT_ACCESS_TOKEN=envault_at_me",
+		"NO_COLOR=1",
+	)
+	cmd.Stdin = strings.NewReader(strings.Join(calls, "\n") + "\n")
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("mcp serve failed: %v\n%s", err, errBuf.String())
+	}
+
+	results := map[int]string{}
+	var tools []string
+	for _, line := range strings.Split(strings.TrimSpace(outBuf.String()), "\n") {
+		var reply struct {
+			ID     int `json:"id"`
+			Result struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+				IsError bool `json:"isError"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &reply); err != nil {
+			t.Fatalf("stdout must only carry protocol messages, got %q", line)
+		}
+		for _, tool := range reply.Result.Tools {
+			tools = append(tools, tool.Name)
+		}
+		if len(reply.Result.Content) > 0 {
+			if reply.Result.IsError {
+				t.Fatalf("tool call %d failed: %s", reply.ID, reply.Result.Content[0].Text)
+			}
+			results[reply.ID] = reply.Result.Content[0].Text
+		}
+	}
+
+	if len(tools) != 6 {
+		t.Fatalf("expected six tools, got %v", tools)
+	}
+	if !strings.Contains(results[3], `"name": "Shop"`) || !strings.Contains(results[3], `"linkedProjectId": "`+projectID+`"`) {
+		t.Fatalf("unexpected projects result:\n%s", results[3])
+	}
+	if !strings.Contains(results[4], `"slug": "development"`) {
+		t.Fatalf("unexpected environments result:\n%s", results[4])
+	}
+	if strings.Contains(results[5], "sk_live_abcdef") || !strings.Contains(results[5], `"length": 14`) || !strings.Contains(results[5], `"status": "empty"`) {
+		t.Fatalf("expected masked metadata without values:\n%s", results[5])
+	}
+	if !strings.Contains(results[6], "https://envault.tech/approve/a1") || idempotencyKey == "" {
+		t.Fatalf("unexpected change request result (key %q):\n%s", idempotencyKey, results[6])
+	}
+	if issued["agent_id"] != "pairing-bot" {
+		t.Fatalf("expected an agent token issued for the --agent name, got %v", issued)
+	}
+	payload, _ := proposed["payload"].(map[string]interface{})
+	if proposed["projectId"] != projectID || payload["environment"] != "development" || len(payload["mutations"].([]interface{})) != 2 {
+		t.Fatalf("unexpected proposal: %v", proposed)
+	}
+	if !strings.Contains(results[7], `"status": "pending"`) {
+		t.Fatalf("unexpected approval status:\n%s", results[7])
+	}
+	if !strings.Contains(results[8], `"passed"`) {
+		t.Fatalf("unexpected audit result:\n%s", results[8])
+	}
+}
+
+func TestMCPInstall_NativeWritesNoToken(t *testing.T) {
+	const projectID = "11111111-1111-4111-8111-111111111111"
+	tmp := t.TempDir()
+	if err := os.WriteFile(tmp+"/envault.json", []byte(`{"projectId":"`+projectID+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := buildBinary(t)
+
+	cmd := exec.Command(bin, "mcp", "install", "--native", "--local")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL=https://envault.example/api/cli",
+		"ENVAULT_TOKEN=",
+		"ENVAULT_CREDENTIAL_STORE=env",
+		"ENVAULT_ACCESS_TOKEN=envault_at_me",
+		"NO_COLOR=1",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("mcp install --native failed: %v\n%s", err, out)
+	}
+
+	data, err := os.ReadFile(tmp + "/.vscode/mcp.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Servers map[string]struct {
+			Command string            `json:"command"`
+			Args    []string          `json:"args"`
+			Env     map[string]string `json:"env"`
+			Type    string            `json:"type"`
+		} `json:"servers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	entry := config.Servers["envault"]
+	if entry.Command == "" || strings.Join(entry.Args, " ") != "mcp serve --project "+projectID || entry.Type != "stdio" {
+		t.Fatalf("expected the entry to run this binary's mcp serve, got %+v", entry)
+	}
+	if _, ok := entry.Env["ENVAULT_TOKEN"]; ok || entry.Env["ENVAULT_BASE_URL"] != "https://envault.example" {
+		t.Fatalf("expected no token and the app URL in the entry env, got %v", entry.Env)
+	}
+}
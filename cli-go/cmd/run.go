@@ -1,37 +1,164 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/DinanathDash/Envault/cli-go/internal/api"
-	"github.com/DinanathDash/Envault/cli-go/internal/crypto"
+	"github.com/DinanathDash/Envault/cli-go/internal/bundle"
 	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	runWatch         bool
+	runWatchInterval time.Duration
+	runWatchSignal   string
+	runWatchGrace    time.Duration
+
+	runNewProcessGroup bool
+	runExec            bool
+	runMask            bool
+
+	runOverride   bool
+	runNoOverride bool
+	runCleanEnv   bool
+	runAllowEnv   []string
+
+	runFileSecrets []string
+	runSecretsDir  bool
+
+	runSourceFlags []string
+	runExplain     bool
+
+	runOffline bool
+	runNoCache bool
+
+	runBundle string
+)
+
 var runCmd = &cobra.Command{
 	Use:   "run -- <command>",
 	Short: "Run a command with secrets injected from Envault",
+	Long: `Run a command with the target environment's secrets injected as environment
+variables. Nothing is written to disk.
+
+With --watch, envault polls the environment every --interval. When a secret
+changes it logs the changed key names (never values), sends --restart-signal to
+the child process group, waits up to --grace-period and restarts the command
+with the new environment.
+
+envault stays in front of the child and forwards stop and user signals (HUP,
+INT, QUIT, TERM, USR1, USR2, ALRM) to it, so container stop requests reach the
+real process. With --new-process-group the child gets its own process group
+and signals go to the whole group. When envault runs as PID 1 it also reaps
+orphaned processes. On Unix, --exec replaces envault with the command instead
+of supervising it.
+
+With --mask, the child's stdout and stderr pass through a redactor that
+replaces every injected secret value, and its base64 and URL-encoded forms,
+with ***KEY***. The child then writes to pipes rather than the terminal.
+
+Secrets override OS variables of the same name unless --no-override is given;
+either way envault warns about the shadowed names. --clean-env passes only a
+small allowlist of OS variables (PATH, HOME, TERM, ...), extended with
+--allow-env. Secrets can be renamed or re-prefixed per environment with
+"environmentMappings" in envault.json.
+
+--file-secret KEY=PATH writes a secret to a new 0600 file instead of the
+environment; an existing PATH is refused. --secrets-dir writes every secret
+to a private 0700 directory, on tmpfs where available, and exports only
+ENVAULT_SECRETS_DIR. The files are overwritten and removed once the command
+exits.
+
+--source project[:environment] (repeatable) layers other projects' secrets
+under the linked project's; it replaces "sources" in envault.json. Later
+sources override earlier ones, and the linked project wins unless it is
+listed itself. Each source falls back to its own offline cache entry.
+--explain prints which source each key comes from, with value lengths only,
+without running a command.
+
+--offline uses only the offline cache and never contacts the API; --no-cache
+neither reads nor writes it. A cache max age from config.toml or envault.json
+(see "envault cache --help") refuses or warns about old entries.
+
+--bundle FILE takes the secrets from a bundle (see "envault bundle --help")
+instead of the API, for machines with no network. The bundle's expiry is
+enforced.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
+		if len(args) == 0 && !runExplain {
 			return fmt.Errorf("missing command to run")
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		runTarget := args[0]
-		runArgs := args[1:]
+		var runTarget string
+		var runArgs []string
+		if len(args) > 0 {
+			runTarget = args[0]
+			runArgs = args[1:]
+		}
+
+		if runExec && runWatch {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--exec cannot be combined with --watch."))
+			os.Exit(1)
+		}
+		if runExec && runMask {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--exec cannot be combined with --mask."))
+			os.Exit(1)
+		}
+		if cmd.Flags().Changed("override") && cmd.Flags().Changed("no-override") {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--override cannot be combined with --no-override."))
+			os.Exit(1)
+		}
+		if runOffline && runNoCache {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--offline cannot be combined with --no-cache."))
+			os.Exit(1)
+		}
+		if runOffline && runWatch {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--offline cannot be combined with --watch."))
+			os.Exit(1)
+		}
+		if runBundle != "" && (runWatch || runOffline || len(runSourceFlags) > 0) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--bundle cannot be combined with --watch, --offline or --source."))
+			os.Exit(1)
+		}
+		if runExec && (len(runFileSecrets) > 0 || runSecretsDir) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--exec cannot be combined with --file-secret or --secrets-dir; nothing would remain to remove the files."))
+			os.Exit(1)
+		}
+		fileSet, err := newSecretFileSet(runFileSecrets, runSecretsDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(1)
+		}
+		runSecretFiles = fileSet
+
+		var bundled *bundle.Payload
+		if runBundle != "" {
+			payload := openBundleOrExit(runBundle)
+			bundled = &payload
+		}
 
 		projectID := ensureProjectID()
+		if bundled != nil {
+			if projectID != "" && projectID != bundled.ProjectID {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("The bundle is for project %s, not %s.", bundled.ProjectID, projectID)))
+				os.Exit(1)
+			}
+			projectID = bundled.ProjectID
+		}
 		if projectID == "" {
 			fmt.Fprintln(os.Stderr, ui.ColorYellow("No project linked."))
 			projectID = selectProjectAndPersistOrExit()
@@ -42,117 +169,265 @@ var runCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		targetEnv, err := resolveTargetEnvironmentForProject(projectID)
+		// Offline runs cannot ask the API which environments are accessible.
+		targetEnv := resolveTargetEnvironment()
+		if bundled != nil {
+			if strings.TrimSpace(envFlag) != "" && !strings.EqualFold(envFlag, bundled.Environment) {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("The bundle is for the %s environment, not %s.", bundled.Environment, envFlag)))
+				os.Exit(1)
+			}
+			targetEnv = bundled.Environment
+		} else if !runOffline {
+			targetEnv, err = resolveTargetEnvironmentForProject(projectID)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Run failed."))
+				fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+				os.Exit(1)
+			}
+		}
+		config, err := project.ReadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to read envault.json: %v", err)))
+			os.Exit(1)
+		}
+		configuredSources := config.Sources
+		if bundled != nil {
+			// A bundle carries a single environment.
+			configuredSources = nil
+		}
+		sources, err := resolveRunSources(runSource{ProjectID: projectID, Environment: targetEnv}, runSourceFlags, configuredSources)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, ui.ColorRed("Run failed."))
 			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
 			os.Exit(1)
 		}
-		client := api.NewClient()
-		path := fmt.Sprintf("/projects/%s/secrets?environment=%s", projectID, url.QueryEscape(targetEnv))
 
-		var secretsResp SecretsResponse
-		var envSecrets []offlinecache.Secret
+		cacheOpts := runCacheOptions{offline: runOffline, disabled: runNoCache, bundle: bundled}
+		if !runNoCache {
+			cacheOpts.policies = loadCachePoliciesOrExit()
+		}
+
+		// Offline and bundle runs never contact the API, so a bad token or
+		// API URL must not stop them before the cache is read.
+		var client *api.Client
+		var timeout time.Duration
+		if !runOffline && bundled == nil {
+			client = api.NewClient()
+			timeout = resolveRunTimeout(client.BaseURL)
+		}
+
 		loader := ui.NewLoader(ui.LoaderThemeFetch, fmt.Sprintf("VaultPulse preparing runtime secrets (%s)...", targetEnv))
 		loader.Start()
-		respBytes, err := client.GetWithTimeout(path, resolveRunTimeout(client.BaseURL))
+		results := make([]runSourceResult, len(sources))
+		for i, src := range sources {
+			results[i] = loadRunSource(context.Background(), client, src, timeout, cacheOpts)
+		}
 		loader.Stop()
 
-		usedOfflineCache := false
-		cachedAt := time.Time{}
-		if err != nil {
-			if handleEnvironmentAccessDenied(err, targetEnv) {
-				os.Exit(1)
+		for _, result := range results {
+			for _, warning := range result.warnings {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow(warning))
 			}
-			if api.IsFallbackEligible(err) {
-				cachedSecrets, loadedAt, cacheErr := offlinecache.Load(projectID, targetEnv)
-				if cacheErr != nil {
-					fmt.Fprintln(os.Stderr, ui.ColorRed("Run failed."))
-					fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Network error: %v", err)))
-					fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Offline cache unavailable: %v", cacheErr)))
-					if isLocalBaseURL(client.BaseURL) {
-						fmt.Fprintln(os.Stderr, ui.ColorYellow("Hint: ENVAULT_BASE_URL/ENVAULT_CLI_URL points to a local server."))
-						if isLikelyDevCommand(runTarget, runArgs) {
-							fmt.Fprintln(os.Stderr, ui.ColorYellow("      This command starts a dev server, so secrets cannot be injected into an already-running process."))
-							fmt.Fprintln(os.Stderr, ui.ColorYellow("      Use hosted API URL for true one-command dev, or start local server in another terminal first."))
-						}
-					}
+			if result.err != nil && !result.offline {
+				if handleEnvironmentAccessDenied(result.err, result.source.Environment) {
 					os.Exit(1)
 				}
-
-				envSecrets = cachedSecrets
-				usedOfflineCache = true
-				cachedAt = loadedAt
-			} else {
 				fmt.Fprintln(os.Stderr, ui.ColorRed("Run failed."))
-				fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+				if len(sources) > 1 {
+					fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Source %s could not be loaded.", result.source)))
+				}
+				if result.cacheErr == nil {
+					fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(result.err)))
+					os.Exit(1)
+				}
+				if !runOffline {
+					fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Network error: %v", result.err)))
+				}
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Offline cache unavailable: %v", result.cacheErr)))
+				if !runOffline && isLocalBaseURL(client.BaseURL) {
+					fmt.Fprintln(os.Stderr, ui.ColorYellow("Hint: ENVAULT_BASE_URL/ENVAULT_CLI_URL points to a local server."))
+					if isLikelyDevCommand(runTarget, runArgs) {
+						fmt.Fprintln(os.Stderr, ui.ColorYellow("      This command starts a dev server, so secrets cannot be injected into an already-running process."))
+						fmt.Fprintln(os.Stderr, ui.ColorYellow("      Use hosted API URL for true one-command dev, or start local server in another terminal first."))
+					}
+				}
 				os.Exit(1)
 			}
-		} else {
-			if err := json.Unmarshal(respBytes, &secretsResp); err != nil {
-				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Error parsing response: %v", err)))
-				os.Exit(1)
+			if result.offline {
+				cacheTime := "unknown"
+				cacheAge := "unknown"
+				if !result.cachedAt.IsZero() {
+					cacheTime = result.cachedAt.Format(time.RFC3339)
+					cacheAge = humanizeDuration(time.Since(result.cachedAt))
+				}
+				fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Using offline cache for %s (%s). Cached at %s (%s ago).", result.source.ProjectID, result.source.Environment, cacheTime, cacheAge)))
 			}
+		}
 
-			envSecrets = make([]offlinecache.Secret, len(secretsResp.Secrets))
-			for i, s := range secretsResp.Secrets {
-				plaintext := "<<DECRYPTION_FAILED>>"
-				if s.Ciphertext != "" && s.Ciphertext != "<<DECRYPTION_FAILED>>" && s.Dek != "" {
-					decrypted, err := crypto.DecryptAESGCM(s.Ciphertext, s.Dek)
-					if err == nil {
-						plaintext = decrypted
-					} else {
-						fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to decrypt secret '%s': %v", s.Key, err)))
+		envSecrets, origins := mergeRunSources(results)
+		mapping := config.EnvironmentMappings[targetEnv]
+		if runExplain {
+			printRunExplain(os.Stdout, sources, origins, mapping)
+			os.Exit(0)
+		}
+
+		envSecrets, err = applyKeyMapping(envSecrets, mapping)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid environmentMappings for %s: %v", targetEnv, err)))
+			os.Exit(1)
+		}
+		warnShadowedVariables(envSecrets)
+
+		if runWatch {
+			refresh := func(ctx context.Context) ([]offlinecache.Secret, error) {
+				latest := make([]runSourceResult, len(sources))
+				for i, src := range sources {
+					latest[i] = loadRunSource(ctx, client, src, timeout, cacheOpts)
+					for _, warning := range latest[i].warnings {
+						fmt.Fprintln(os.Stderr, ui.ColorYellow(warning))
+					}
+					// Restarting onto a stale cache entry would be worse than
+					// keeping the current child.
+					if latest[i].err != nil {
+						return nil, fmt.Errorf("%s: %w", src, latest[i].err)
 					}
-				} else if s.Value != "" || (s.Ciphertext == "" && s.Dek == "") {
-					plaintext = s.Value
 				}
-				envSecrets[i] = offlinecache.Secret{Key: s.Key, Value: plaintext}
-			}
-			if cacheErr := offlinecache.Save(projectID, targetEnv, envSecrets); cacheErr != nil {
-				fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to update offline cache: %v", cacheErr)))
+				secrets, _ := mergeRunSources(latest)
+				return applyKeyMapping(secrets, mapping)
 			}
+			code := superviseWatchedChild(runTarget, runArgs, envSecrets, refresh)
+			runSecretFiles.shred()
+			os.Exit(code)
+		}
+
+		command, err := buildRunCommand(runTarget, runArgs, envSecrets)
+		if err != nil {
+			runSecretFiles.shred()
+			fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+			os.Exit(1)
 		}
 
-		if usedOfflineCache {
-			cacheTime := "unknown"
-			cacheAge := "unknown"
-			if !cachedAt.IsZero() {
-				cacheTime = cachedAt.Format(time.RFC3339)
-				cacheAge = humanizeDuration(time.Since(cachedAt))
+		if runExec {
+			if err := execReplace(command); err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to execute command: %v", err)))
+				os.Exit(1)
 			}
-			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Using offline cache for %s (%s). Cached at %s (%s ago).", projectID, targetEnv, cacheTime, cacheAge)))
 		}
 
-		var command *exec.Cmd
-		if runtime.GOOS == "windows" {
-			allArgs := append([]string{"/c", runTarget}, runArgs...)
-			command = exec.Command("cmd", allArgs...)
-		} else {
-			binPath, err := exec.LookPath(runTarget)
+		var output *maskedOutput
+		if runMask {
+			output, err = attachMaskedOutput(command, envSecrets)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Error locating executable '%s': %v", runTarget, err)))
+				runSecretFiles.shred()
+				fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
 				os.Exit(1)
 			}
-			command = exec.Command(binPath, runArgs...)
 		}
 
-		command.Env = os.Environ()
-		for _, s := range envSecrets {
-			command.Env = append(command.Env, fmt.Sprintf("%s=%s", s.Key, s.Value))
-		}
-		command.Stdout = os.Stdout
-		command.Stderr = os.Stderr
-		command.Stdin = os.Stdin
+		code := runChild(command, runNewProcessGroup, output)
+		runSecretFiles.shred()
+		os.Exit(code)
+	},
+}
 
-		if err := command.Run(); err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				os.Exit(exitErr.ExitCode())
+// runChild starts the command, relays envault's signals to it until it exits
+// and returns its exit code. A non-nil output is drained before returning.
+func runChild(command *exec.Cmd, ownGroup bool, output *maskedOutput) int {
+	if ownGroup {
+		configureChildProcessGroup(command)
+	}
+
+	// Subscribe before starting the child so that a stop request arriving
+	// during startup is forwarded instead of killing envault alone.
+	sigCh := make(chan os.Signal, 8)
+	signal.Notify(sigCh, forwardedSignals()...)
+	defer signal.Stop(sigCh)
+
+	err := command.Start()
+	output.started()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to execute command: %v", err)))
+		return 1
+	}
+
+	done := waitChild(command)
+	for {
+		select {
+		case code := <-done:
+			output.finish()
+			if ownGroup {
+				reclaimTerminal()
 			}
-			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to execute command: %v", err)))
-			os.Exit(1)
+			return code
+		case sig := <-sigCh:
+			_ = forwardSignal(command, sig, ownGroup)
 		}
-	},
+	}
+}
+
+// childExitCode maps the result of cmd.Wait to envault's exit code. A child
+// killed by a signal yields 128+signal, as a shell would report it.
+func childExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to execute command: %v", err)))
+	return 1
+}
+
+// buildRunCommand prepares the child process with the secrets merged into the
+// current environment and the standard streams attached. Secrets delivered as
+// files are written here and left out of the environment.
+func buildRunCommand(runTarget string, runArgs []string, secrets []offlinecache.Secret) (*exec.Cmd, error) {
+	var command *exec.Cmd
+	if runtime.GOOS == "windows" {
+		allArgs := append([]string{"/c", runTarget}, runArgs...)
+		command = exec.Command("cmd", allArgs...)
+	} else {
+		binPath, err := exec.LookPath(runTarget)
+		if err != nil {
+			return nil, fmt.Errorf("Error locating executable '%s': %v", runTarget, err)
+		}
+		command = exec.Command(binPath, runArgs...)
+	}
+
+	envSecrets, err := runSecretFiles.deliver(secrets)
+	if err != nil {
+		return nil, err
+	}
+	command.Env, _ = buildChildEnv(os.Environ(), envSecrets, runEnvironmentOptions())
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Stdin = os.Stdin
+	return command, nil
+}
+
+func runEnvironmentOptions() runEnvOptions {
+	return runEnvOptions{
+		override: runOverride && !runNoOverride,
+		clean:    runCleanEnv,
+		allow:    runAllowEnv,
+	}
+}
+
+// warnShadowedVariables lists the OS variables that share a name with a
+// secret, and which side wins.
+func warnShadowedVariables(secrets []offlinecache.Secret) {
+	opts := runEnvironmentOptions()
+	_, shadowed := buildChildEnv(os.Environ(), runSecretFiles.environment(secrets), opts)
+	if len(shadowed) == 0 {
+		return
+	}
+	if opts.override {
+		fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: secrets override existing environment variables: %s", strings.Join(shadowed, ", "))))
+		return
+	}
+	fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: existing environment variables take precedence over secrets: %s", strings.Join(shadowed, ", "))))
 }
 
 func humanizeDuration(d time.Duration) string {
@@ -221,4 +496,22 @@ func isLikelyDevCommand(runTarget string, runArgs []string) bool {
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+	runCmd.Flags().BoolVar(&runWatch, "watch", false, "Restart the command when the environment's secrets change")
+	runCmd.Flags().DurationVar(&runWatchInterval, "interval", 30*time.Second, "How often --watch polls for secret changes")
+	runCmd.Flags().StringVar(&runWatchSignal, "restart-signal", "SIGTERM", "Signal sent to the child process group before a --watch restart")
+	runCmd.Flags().DurationVar(&runWatchGrace, "grace-period", 10*time.Second, "How long --watch waits for the child to exit before killing it")
+	runCmd.Flags().BoolVar(&runNewProcessGroup, "new-process-group", false, "Run the command in its own process group and signal the whole group")
+	runCmd.Flags().BoolVar(&runExec, "exec", false, "Replace envault with the command after building its environment (Unix only)")
+	runCmd.Flags().BoolVar(&runMask, "mask", false, "Redact secret values from the command's stdout and stderr")
+	runCmd.Flags().BoolVar(&runOverride, "override", true, "Let secrets override OS environment variables of the same name")
+	runCmd.Flags().BoolVar(&runNoOverride, "no-override", false, "Keep OS environment variables when a secret has the same name")
+	runCmd.Flags().BoolVar(&runCleanEnv, "clean-env", false, "Pass only an allowlist of OS environment variables to the command")
+	runCmd.Flags().StringSliceVar(&runAllowEnv, "allow-env", nil, "Additional OS variables to pass with --clean-env (repeatable, NAME or PREFIX_*)")
+	runCmd.Flags().StringArrayVar(&runFileSecrets, "file-secret", nil, "Write a secret to a file instead of the environment (repeatable, KEY=PATH)")
+	runCmd.Flags().BoolVar(&runSecretsDir, "secrets-dir", false, "Write every secret to a private tmpfs directory and export only ENVAULT_SECRETS_DIR")
+	runCmd.Flags().StringArrayVar(&runSourceFlags, "source", nil, "Additional project[:environment] to merge, lowest precedence first (repeatable)")
+	runCmd.Flags().BoolVar(&runExplain, "explain", false, "Show which source each key comes from, with value lengths only, and exit")
+	runCmd.Flags().BoolVar(&runOffline, "offline", false, "Use only the offline cache; never contact the API")
+	runCmd.Flags().BoolVar(&runNoCache, "no-cache", false, "Neither read nor write the offline cache")
+	runCmd.Flags().StringVar(&runBundle, "bundle", "", "Take secrets from a bundle file instead of the API")
 }
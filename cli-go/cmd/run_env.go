@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
+)
+
+// defaultCleanEnvAllowlist is what --clean-env passes through from the OS
+// environment: enough for most programs to locate binaries, their home
+// directory, a temp directory and the terminal.
+var defaultCleanEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM",
+	"LANG", "LANGUAGE", "LC_*", "TZ", "TMPDIR",
+	// Windows
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT",
+	"TEMP", "TMP", "USERPROFILE", "APPDATA", "LOCALAPPDATA", "PROGRAMDATA",
+}
+
+// runEnvOptions controls how secrets are merged into the OS environment.
+type runEnvOptions struct {
+	// override makes secrets win over OS variables of the same name.
+	override bool
+	// clean passes only allowlisted OS variables to the child.
+	clean bool
+	// allow extends the default allowlist. Entries ending in * match by prefix.
+	allow []string
+}
+
+// applyKeyMapping renames secrets according to the environment's mapping in
+// envault.json. Two secrets that end up with the same name are an error, since
+// one of them would silently disappear.
+func applyKeyMapping(secrets []offlinecache.Secret, mapping project.KeyMapping) ([]offlinecache.Secret, error) {
+	if mapping.IsZero() {
+		return secrets, nil
+	}
+
+	mapped := make([]offlinecache.Secret, 0, len(secrets))
+	sources := make(map[string]string, len(secrets))
+	for _, s := range secrets {
+		name := mapping.Apply(s.Key)
+		if name == "" {
+			return nil, fmt.Errorf("key mapping turns %s into an empty name", s.Key)
+		}
+		if other, ok := sources[envKeyFold(name)]; ok {
+			return nil, fmt.Errorf("key mapping exposes both %s and %s as %s", other, s.Key, name)
+		}
+		sources[envKeyFold(name)] = s.Key
+		mapped = append(mapped, offlinecache.Secret{Key: name, Value: s.Value})
+	}
+	return mapped, nil
+}
+
+// buildChildEnv merges secrets into base, an os.Environ()-style list, so that
+// every name appears exactly once. It also returns the sorted names of the OS
+// variables that share a name with a secret.
+func buildChildEnv(base []string, secrets []offlinecache.Secret, opts runEnvOptions) ([]string, []string) {
+	allow := append(append([]string{}, defaultCleanEnvAllowlist...), opts.allow...)
+
+	secretNames := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		secretNames[envKeyFold(s.Key)] = true
+	}
+
+	env := make([]string, 0, len(base)+len(secrets))
+	osNames := make(map[string]bool, len(base))
+	shadowed := []string{}
+	for _, kv := range base {
+		name, _, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			continue
+		}
+		if opts.clean && !envNameAllowed(name, allow) {
+			continue
+		}
+		if secretNames[envKeyFold(name)] {
+			shadowed = append(shadowed, name)
+			if opts.override {
+				continue
+			}
+		}
+		osNames[envKeyFold(name)] = true
+		env = append(env, kv)
+	}
+
+	for _, s := range secrets {
+		if osNames[envKeyFold(s.Key)] {
+			continue
+		}
+		env = append(env, s.Key+"="+s.Value)
+	}
+
+	sort.Strings(shadowed)
+	return env, shadowed
+}
+
+func envNameAllowed(name string, allow []string) bool {
+	for _, pattern := range allow {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := path.Match(envKeyFold(pattern), envKeyFold(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// envKeyFold normalizes a variable name for comparison. Windows treats
+// environment variable names case-insensitively.
+func envKeyFold(name string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(name)
+	}
+	return name
+}
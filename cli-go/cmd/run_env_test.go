@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
+)
+
+func envValue(env []string, name string) (string, int) {
+	value, count := "", 0
+	for _, kv := range env {
+		if k, v, _ := strings.Cut(kv, "="); k == name {
+			value = v
+			count++
+		}
+	}
+	return value, count
+}
+
+func TestBuildChildEnv_Precedence(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "API_URL=http://os", "EDITOR=vim"}
+	secrets := []offlinecache.Secret{{Key: "API_URL", Value: "https://secret"}, {Key: "TOKEN", Value: "t0ken"}}
+
+	env, shadowed := buildChildEnv(base, secrets, runEnvOptions{override: true})
+	if v, n := envValue(env, "API_URL"); v != "https://secret" || n != 1 {
+		t.Fatalf("override: expected one API_URL from secrets, got %q x%d", v, n)
+	}
+	if !reflect.DeepEqual(shadowed, []string{"API_URL"}) {
+		t.Fatalf("expected API_URL to be reported as shadowed, got %v", shadowed)
+	}
+
+	env, shadowed = buildChildEnv(base, secrets, runEnvOptions{override: false})
+	if v, n := envValue(env, "API_URL"); v != "http://os" || n != 1 {
+		t.Fatalf("no-override: expected one API_URL from the OS, got %q x%d", v, n)
+	}
+	if v, _ := envValue(env, "TOKEN"); v != "t0ken" {
+		t.Fatalf("no-override: non-colliding secrets must still be injected, got %q", v)
+	}
+	if !reflect.DeepEqual(shadowed, []string{"API_URL"}) {
+		t.Fatalf("expected API_URL to be reported as shadowed, got %v", shadowed)
+	}
+}
+
+func TestBuildChildEnv_CleanEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C", "AWS_SECRET_ACCESS_KEY=leak", "CI=true"}
+	secrets := []offlinecache.Secret{{Key: "TOKEN", Value: "t0ken"}}
+
+	env, _ := buildChildEnv(base, secrets, runEnvOptions{override: true, clean: true, allow: []string{"CI"}})
+	for _, name := range []string{"PATH", "HOME", "LC_ALL", "CI", "TOKEN"} {
+		if _, n := envValue(env, name); n != 1 {
+			t.Errorf("expected %s to be passed, env=%v", name, env)
+		}
+	}
+	if _, n := envValue(env, "AWS_SECRET_ACCESS_KEY"); n != 0 {
+		t.Errorf("expected AWS_SECRET_ACCESS_KEY to be dropped by --clean-env, env=%v", env)
+	}
+}
+
+func TestApplyKeyMapping(t *testing.T) {
+	secrets := []offlinecache.Secret{{Key: "DATABASE_URL", Value: "postgres://"}, {Key: "APP_PORT", Value: "3000"}}
+	mapping := project.KeyMapping{
+		Rename:      map[string]string{"DATABASE_URL": "PRISMA_DATABASE_URL"},
+		StripPrefix: "APP_",
+	}
+
+	got, err := applyKeyMapping(secrets, mapping)
+	if err != nil {
+		t.Fatalf("applyKeyMapping: %v", err)
+	}
+	want := []offlinecache.Secret{{Key: "PRISMA_DATABASE_URL", Value: "postgres://"}, {Key: "PORT", Value: "3000"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	_, err = applyKeyMapping([]offlinecache.Secret{{Key: "APP_PORT"}, {Key: "PORT"}}, mapping)
+	if err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Fatalf("expected a collision error, got %v", err)
+	}
+}
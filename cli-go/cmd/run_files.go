@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+)
+
+// secretsDirEnv is the only variable exported in --secrets-dir mode. It points
+// at the directory holding one file per secret, named after its key.
+const secretsDirEnv = "ENVAULT_SECRETS_DIR"
+
+// runSecretFiles is the set of secret files for the current run, or nil when
+// secrets are delivered through the environment only.
+var runSecretFiles *secretFileSet
+
+// secretFileSet writes secrets to files instead of the child's environment
+// and shreds them again once the child is done.
+type secretFileSet struct {
+	paths  map[string]string
+	useDir bool
+	dir    string
+
+	written []string
+	// createdDirs are the parent directories of --file-secret paths that
+	// this run created, deepest first, so shred can remove them again.
+	createdDirs []string
+}
+
+// newSecretFileSet parses --file-secret KEY=PATH specs. It returns nil when
+// neither files nor a secrets directory were requested.
+func newSecretFileSet(specs []string, useDir bool) (*secretFileSet, error) {
+	if len(specs) == 0 && !useDir {
+		return nil, nil
+	}
+
+	paths := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, path, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		path = strings.TrimSpace(path)
+		if !ok || key == "" || path == "" {
+			return nil, fmt.Errorf("invalid --file-secret %q (expected KEY=PATH)", spec)
+		}
+		if _, dup := paths[key]; dup {
+			return nil, fmt.Errorf("--file-secret given twice for %s", key)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --file-secret path for %s: %v", key, err)
+		}
+		paths[key] = abs
+	}
+	return &secretFileSet{paths: paths, useDir: useDir}, nil
+}
+
+// deliver writes the file-delivered secrets and returns the secrets that
+// still belong in the environment. Files from a previous delivery are
+// shredded first, so a --watch restart never leaves stale values behind.
+func (s *secretFileSet) deliver(secrets []offlinecache.Secret) ([]offlinecache.Secret, error) {
+	if s == nil {
+		return secrets, nil
+	}
+	s.shredFiles()
+
+	byKey := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		byKey[secret.Key] = secret.Value
+	}
+	for key, path := range s.paths {
+		value, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("--file-secret %s: no such secret in this environment", key)
+		}
+		if err := s.mkdirAll(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("--file-secret %s: %v", key, err)
+		}
+		if err := s.writeFile(path, value); err != nil {
+			return nil, fmt.Errorf("--file-secret %s: %v", key, err)
+		}
+	}
+
+	if !s.useDir {
+		return s.environment(secrets), nil
+	}
+
+	if s.dir == "" {
+		dir, err := createPrivateSecretsDir()
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+	}
+	for _, secret := range secrets {
+		if _, ok := s.paths[secret.Key]; ok {
+			continue
+		}
+		if strings.ContainsAny(secret.Key, `/\`) || secret.Key == "." || secret.Key == ".." {
+			return nil, fmt.Errorf("secret %s cannot be written as a file name", secret.Key)
+		}
+		if err := s.writeFile(filepath.Join(s.dir, secret.Key), secret.Value); err != nil {
+			return nil, err
+		}
+	}
+	return s.environment(secrets), nil
+}
+
+// environment returns the secrets that deliver leaves in the environment.
+func (s *secretFileSet) environment(secrets []offlinecache.Secret) []offlinecache.Secret {
+	if s == nil {
+		return secrets
+	}
+	if s.useDir {
+		return []offlinecache.Secret{{Key: secretsDirEnv, Value: s.dir}}
+	}
+	env := make([]offlinecache.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		if _, ok := s.paths[secret.Key]; !ok {
+			env = append(env, secret)
+		}
+	}
+	return env
+}
+
+// mkdirAll creates dir with owner-only permissions and remembers every
+// directory it had to create.
+func (s *secretFileSet) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(d); err == nil || d == filepath.Dir(d) {
+			break
+		}
+		missing = append(missing, d)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	s.createdDirs = append(s.createdDirs, missing...)
+	return nil
+}
+
+// writeFile creates path with owner-only permissions and writes value to it.
+// The file must not exist yet: O_EXCL makes the open fail on an existing file
+// or symlink, even one swapped in concurrently, so nothing of the user's is
+// ever overwritten and later shredded.
+func (s *secretFileSet) writeFile(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return fmt.Errorf("refusing to overwrite existing %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	s.written = append(s.written, path)
+	if err := f.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+		_ = f.Close()
+		return err
+	}
+	if _, err := io.WriteString(f, value); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// shred overwrites and removes every file written for this run, then the
+// directories created for it and the secrets directory when one was created.
+func (s *secretFileSet) shred() {
+	if s == nil {
+		return
+	}
+	s.shredFiles()
+	for _, dir := range s.createdDirs {
+		// Only empty directories are removed; anything else put there since
+		// belongs to someone else.
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to remove %s: %v", dir, err)))
+		}
+	}
+	s.createdDirs = nil
+	if s.dir != "" {
+		if err := os.RemoveAll(s.dir); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to remove %s: %v", s.dir, err)))
+		}
+		s.dir = ""
+	}
+}
+
+func (s *secretFileSet) shredFiles() {
+	for _, path := range s.written {
+		if err := shredFile(path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: failed to shred %s: %v", path, err)))
+		}
+	}
+	s.written = nil
+}
+
+// shredFile overwrites the file's contents with zeros before removing it.
+func shredFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		zeros := make([]byte, 4096)
+		for remaining := info.Size(); remaining > 0; {
+			n := int64(len(zeros))
+			if remaining < n {
+				n = remaining
+			}
+			if _, err = f.Write(zeros[:n]); err != nil {
+				break
+			}
+			remaining -= n
+		}
+		_ = f.Sync()
+	}
+	_ = f.Close()
+	if removeErr := os.Remove(path); removeErr != nil {
+		return removeErr
+	}
+	return err
+}
+
+// createPrivateSecretsDir creates a 0700 directory for --secrets-dir, on a
+// memory-backed file system when one is available.
+func createPrivateSecretsDir() (string, error) {
+	for _, base := range memoryBackedDirCandidates() {
+		if base == "" || !isMemoryBackedDir(base) {
+			continue
+		}
+		if dir, err := os.MkdirTemp(base, "envault-secrets-"); err == nil {
+			return dir, nil
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, ui.ColorYellow("Warning: no memory-backed directory available; secrets files are written to the temp directory on disk."))
+	dir, err := os.MkdirTemp("", "envault-secrets-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create secrets directory: %v", err)
+	}
+	return dir, nil
+}
+
+func memoryBackedDirCandidates() []string {
+	return []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm", "/run/user/" + fmt.Sprint(os.Getuid())}
+}
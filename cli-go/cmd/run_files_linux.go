@@ -0,0 +1,15 @@
+//go:build linux
+
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// isMemoryBackedDir reports whether dir lives on tmpfs or ramfs, so that the
+// secrets written there never reach a disk.
+func isMemoryBackedDir(dir string) bool {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(dir, &fs); err != nil {
+		return false
+	}
+	return fs.Type == unix.TMPFS_MAGIC || fs.Type == unix.RAMFS_MAGIC
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package cmd
+
+// isMemoryBackedDir is only implemented on Linux; elsewhere --secrets-dir
+// falls back to the temp directory.
+func isMemoryBackedDir(dir string) bool {
+	return false
+}
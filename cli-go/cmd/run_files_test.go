@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+func TestSecretFileSet_FileSecret(t *testing.T) {
+	tmp := t.TempDir()
+	target := filepath.Join(tmp, "tls", "tls.key")
+
+	set, err := newSecretFileSet([]string{"TLS_KEY=" + target}, false)
+	if err != nil {
+		t.Fatalf("newSecretFileSet: %v", err)
+	}
+	env, err := set.deliver([]offlinecache.Secret{{Key: "TLS_KEY", Value: "-----BEGIN KEY-----"}, {Key: "PORT", Value: "3000"}})
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	if len(env) != 1 || env[0].Key != "PORT" {
+		t.Fatalf("expected only PORT to stay in the environment, got %v", env)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "-----BEGIN KEY-----" {
+		t.Fatalf("expected secret file contents, got %q (%v)", data, err)
+	}
+	if info, _ := os.Stat(target); runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	set.shred()
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, stat err=%v", target, err)
+	}
+	if _, err := os.Stat(filepath.Dir(target)); !os.IsNotExist(err) {
+		t.Fatalf("expected the created directory to be removed, stat err=%v", err)
+	}
+}
+
+func TestSecretFileSet_RefusesExistingPaths(t *testing.T) {
+	tmp := t.TempDir()
+	existing := filepath.Join(tmp, "config.json")
+	if err := os.WriteFile(existing, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{existing}
+	if runtime.GOOS != "windows" {
+		link := filepath.Join(tmp, "link")
+		if err := os.Symlink(filepath.Join(tmp, "elsewhere"), link); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, link)
+	}
+
+	for _, path := range paths {
+		set, err := newSecretFileSet([]string{"TLS_KEY=" + path}, false)
+		if err != nil {
+			t.Fatalf("newSecretFileSet: %v", err)
+		}
+		if _, err := set.deliver([]offlinecache.Secret{{Key: "TLS_KEY", Value: "secret"}}); err == nil {
+			t.Fatalf("expected %s to be refused", path)
+		}
+		set.shred()
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep me" {
+		t.Fatalf("existing file was touched: %q", data)
+	}
+	if _, err := os.Lstat(filepath.Join(tmp, "elsewhere")); !os.IsNotExist(err) {
+		t.Fatalf("expected the symlink not to be followed, stat err=%v", err)
+	}
+}
+
+func TestSecretFileSet_SecretsDir(t *testing.T) {
+	set, err := newSecretFileSet(nil, true)
+	if err != nil {
+		t.Fatalf("newSecretFileSet: %v", err)
+	}
+	env, err := set.deliver([]offlinecache.Secret{{Key: "API_TOKEN", Value: "t0ken"}, {Key: "PORT", Value: "3000"}})
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	t.Cleanup(set.shred)
+
+	if len(env) != 1 || env[0].Key != secretsDirEnv {
+		t.Fatalf("expected only %s in the environment, got %v", secretsDirEnv, env)
+	}
+	dir := env[0].Value
+	if info, err := os.Stat(dir); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0700) {
+		t.Fatalf("expected a 0700 directory, got %v (%v)", info, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "API_TOKEN")); string(data) != "t0ken" {
+		t.Fatalf("expected API_TOKEN file, got %q", data)
+	}
+
+	// A --watch restart rewrites the directory without stale keys.
+	if _, err := set.deliver([]offlinecache.Secret{{Key: "API_TOKEN", Value: "rotated"}}); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "PORT")); !os.IsNotExist(err) {
+		t.Fatalf("expected removed key's file to be gone, stat err=%v", err)
+	}
+
+	set.shred()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, stat err=%v", dir, err)
+	}
+}
+
+func TestNewSecretFileSet_Errors(t *testing.T) {
+	for _, specs := range [][]string{{"NOPATH"}, {"=/tmp/x"}, {"A=/tmp/a", "A=/tmp/b"}} {
+		if _, err := newSecretFileSet(specs, false); err == nil {
+			t.Errorf("expected an error for %v", specs)
+		}
+	}
+
+	set, _ := newSecretFileSet([]string{"MISSING=" + filepath.Join(t.TempDir(), "x")}, false)
+	if _, err := set.deliver(nil); err == nil {
+		t.Errorf("expected an error for a secret that does not exist")
+	}
+}
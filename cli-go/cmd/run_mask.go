@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/redact"
+)
+
+// maskDrainTimeout bounds how long envault waits for output still buffered in
+// the pipes after the child exits. Background processes the child left
+// behind may keep the pipes open indefinitely.
+const maskDrainTimeout = 500 * time.Millisecond
+
+// maskedOutput routes a child's stdout and stderr through redactors. envault
+// owns the pipes and the copy loops, so output is drained the same way
+// whether the child is collected by cmd.Wait or by the PID 1 reaper.
+type maskedOutput struct {
+	writers []*redact.Writer
+	pipes   []*os.File
+	copies  sync.WaitGroup
+}
+
+func attachMaskedOutput(command *exec.Cmd, secrets []offlinecache.Secret) (*maskedOutput, error) {
+	values := make(map[string]string, len(secrets))
+	for _, s := range secrets {
+		values[s.Key] = s.Value
+	}
+
+	m := &maskedOutput{}
+	stdout, err := m.pipe(os.Stdout, values)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := m.pipe(os.Stderr, values)
+	if err != nil {
+		m.started()
+		return nil, err
+	}
+	command.Stdout = stdout
+	command.Stderr = stderr
+	return m, nil
+}
+
+func (m *maskedOutput) pipe(dst io.Writer, values map[string]string) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+	redactor := redact.NewWriter(dst, values)
+	m.writers = append(m.writers, redactor)
+	m.pipes = append(m.pipes, w)
+
+	m.copies.Add(1)
+	go func() {
+		defer m.copies.Done()
+		defer r.Close()
+		_, _ = io.Copy(redactor, r)
+		_ = redactor.Flush()
+	}()
+	return w, nil
+}
+
+// started closes envault's copies of the pipe write ends, so that the copy
+// loops end once the child closes its output. It must be called after
+// cmd.Start, whether or not the start succeeded.
+func (m *maskedOutput) started() {
+	if m == nil {
+		return
+	}
+	for _, p := range m.pipes {
+		_ = p.Close()
+	}
+	m.pipes = nil
+}
+
+// finish waits briefly for the remaining output and flushes it.
+func (m *maskedOutput) finish() {
+	if m == nil {
+		return
+	}
+	drained := make(chan struct{})
+	go func() {
+		m.copies.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(maskDrainTimeout):
+	}
+	for _, w := range m.writers {
+		_ = w.Flush()
+	}
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/bundle"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runSource is one project environment that run reads secrets from.
+type runSource struct {
+	ProjectID   string
+	Environment string
+}
+
+func (s runSource) String() string {
+	return s.ProjectID + ":" + s.Environment
+}
+
+// parseRunSource parses a --source value of the form project[:environment].
+// A missing environment means defaultEnv.
+func parseRunSource(spec, defaultEnv string) (runSource, error) {
+	projectID, env, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	projectID = strings.TrimSpace(projectID)
+	env = strings.TrimSpace(env)
+	if env == "" {
+		env = defaultEnv
+	}
+	if !isValidProjectID(projectID) {
+		return runSource{}, fmt.Errorf("invalid source %q: expected <project-id>[:<environment>]", spec)
+	}
+	return runSource{ProjectID: projectID, Environment: env}, nil
+}
+
+// resolveRunSources returns the sources to merge, lowest precedence first.
+// The --source flags replace the "sources" list from envault.json. The linked
+// project's environment comes last and therefore wins, unless it is listed
+// explicitly, in which case its position in the list decides.
+func resolveRunSources(primary runSource, specs []string, configured []project.Source) ([]runSource, error) {
+	if len(specs) == 0 {
+		for _, s := range configured {
+			specs = append(specs, s.ProjectId+":"+s.Environment)
+		}
+	}
+
+	sources := make([]runSource, 0, len(specs)+1)
+	seen := map[runSource]bool{}
+	for _, spec := range specs {
+		src, err := parseRunSource(spec, primary.Environment)
+		if err != nil {
+			return nil, err
+		}
+		if seen[src] {
+			return nil, fmt.Errorf("source %s is listed twice", src)
+		}
+		seen[src] = true
+		sources = append(sources, src)
+	}
+	if !seen[primary] {
+		sources = append(sources, primary)
+	}
+	return sources, nil
+}
+
+// errOfflineRequested stands in for the API error when --offline skipped the
+// API altogether.
+var errOfflineRequested = errors.New("--offline: the API was not contacted")
+
+// runCacheOptions controls how run uses the offline cache.
+type runCacheOptions struct {
+	// offline reads the cache without contacting the API.
+	offline bool
+	// disabled neither reads nor writes the cache.
+	disabled bool
+	policies []offlinecache.Policy
+	// bundle, when set, supplies the secrets instead of the API and cache.
+	bundle *bundle.Payload
+}
+
+// runSourceResult is the outcome of loading one source. When the API could
+// not be reached, secrets come from the offline cache and offline is set;
+// err is only left for the caller when no secrets are available at all.
+type runSourceResult struct {
+	source   runSource
+	secrets  []offlinecache.Secret
+	offline  bool
+	cachedAt time.Time
+	err      error
+	cacheErr error
+	warnings []string
+}
+
+// loadRunSource fetches one source and falls back to its offline cache entry
+// when the API is unreachable, subject to the cache policies. A successful
+// fetch refreshes that entry.
+func loadRunSource(ctx context.Context, client *api.Client, src runSource, timeout time.Duration, opts runCacheOptions) runSourceResult {
+	result := runSourceResult{source: src}
+	if opts.bundle != nil {
+		result.secrets = opts.bundle.Secrets
+		return result
+	}
+	var secrets []offlinecache.Secret
+	var err error
+	if opts.offline {
+		err = errOfflineRequested
+	} else {
+		secrets, err = fetchEnvironmentSecrets(ctx, client, src.ProjectID, src.Environment, timeout, func(key string, err error) {
+			result.warnings = append(result.warnings, fmt.Sprintf("Warning: failed to decrypt secret '%s': %v", key, err))
+		})
+	}
+	if err != nil {
+		result.err = err
+		if !opts.offline && !api.IsFallbackEligible(err) {
+			return result
+		}
+		if opts.disabled {
+			result.cacheErr = errors.New("disabled by --no-cache")
+			return result
+		}
+		cached, cachedAt, cacheErr := offlinecache.Load(src.ProjectID, src.Environment)
+		if cacheErr != nil {
+			result.cacheErr = cacheErr
+			return result
+		}
+		warnings, policyErr := checkCachePolicies(cachedAt, time.Now(), opts.policies)
+		if policyErr != nil {
+			result.cacheErr = policyErr
+			return result
+		}
+		result.warnings = append(result.warnings, warnings...)
+		result.secrets = cached
+		result.offline = true
+		result.cachedAt = cachedAt
+		return result
+	}
+
+	result.secrets = secrets
+	if opts.disabled {
+		return result
+	}
+	if cacheErr := offlinecache.Save(src.ProjectID, src.Environment, secrets); cacheErr != nil {
+		result.warnings = append(result.warnings, fmt.Sprintf("Warning: failed to update offline cache: %v", cacheErr))
+	}
+	return result
+}
+
+// secretOrigin records where a merged key came from.
+type secretOrigin struct {
+	Key      string
+	Value    string
+	Source   runSource
+	Offline  bool
+	Shadowed []runSource
+}
+
+// mergeRunSources layers the results in order; a later source overrides an
+// earlier one. The merged secrets keep the order in which keys first appear.
+func mergeRunSources(results []runSourceResult) ([]offlinecache.Secret, []secretOrigin) {
+	index := map[string]int{}
+	origins := []secretOrigin{}
+	for _, result := range results {
+		for _, s := range result.secrets {
+			i, ok := index[s.Key]
+			if !ok {
+				index[s.Key] = len(origins)
+				origins = append(origins, secretOrigin{Key: s.Key, Value: s.Value, Source: result.source, Offline: result.offline})
+				continue
+			}
+			prev := origins[i]
+			origins[i] = secretOrigin{
+				Key:      s.Key,
+				Value:    s.Value,
+				Source:   result.source,
+				Offline:  result.offline,
+				Shadowed: append(prev.Shadowed, prev.Source),
+			}
+		}
+	}
+
+	merged := make([]offlinecache.Secret, len(origins))
+	for i, o := range origins {
+		merged[i] = offlinecache.Secret{Key: o.Key, Value: o.Value}
+	}
+	return merged, origins
+}
+
+// printRunExplain writes which source each key comes from, with the length of
+// each value only, as the command would receive it.
+func printRunExplain(w io.Writer, sources []runSource, origins []secretOrigin, mapping project.KeyMapping) {
+	fmt.Fprintln(w, ui.ColorBold("Sources (lowest to highest precedence):"))
+	for i, src := range sources {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, src)
+	}
+	fmt.Fprintln(w)
+
+	rows := make([][4]string, 0, len(origins))
+	for _, o := range origins {
+		name := mapping.Apply(o.Key)
+		if name != o.Key {
+			name += " (from " + o.Key + ")"
+		}
+		source := o.Source.String()
+		if o.Offline {
+			source += " (offline cache)"
+		}
+		shadowed := make([]string, len(o.Shadowed))
+		for i, s := range o.Shadowed {
+			shadowed[i] = s.String()
+		}
+		rows = append(rows, [4]string{name, source, describeValueLength(o.Value), strings.Join(shadowed, ", ")})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	header := [4]string{"KEY", "SOURCE", "LENGTH", "OVERRIDES"}
+	widths := [4]int{}
+	for _, row := range append([][4]string{header}, rows...) {
+		for i, cell := range row {
+			if n := lipgloss.Width(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	format := func(row [4]string) string {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+		}
+		return strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+
+	fmt.Fprintln(w, ui.ColorBold(format(header)))
+	for _, row := range rows {
+		fmt.Fprintln(w, format(row))
+	}
+}
+
+// describeValueLength reports a value's length without revealing any of it.
+func describeValueLength(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	return fmt.Sprintf("%d chars", utf8.RuneCountInString(value))
+}
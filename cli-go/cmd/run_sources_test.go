@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/project"
+)
+
+const (
+	testAppProject      = "aaaaaaaa-bbbb-4ccc-8ddd-eeeeeeeeeeee"
+	testPlatformProject = "11111111-2222-4333-8444-555555555555"
+)
+
+func TestResolveRunSources(t *testing.T) {
+	primary := runSource{ProjectID: testAppProject, Environment: "production"}
+	platform := runSource{ProjectID: testPlatformProject, Environment: "production"}
+
+	got, err := resolveRunSources(primary, nil, []project.Source{{ProjectId: testPlatformProject}})
+	if err != nil {
+		t.Fatalf("resolveRunSources: %v", err)
+	}
+	if want := []runSource{platform, primary}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("config sources: expected %v, got %v", want, got)
+	}
+
+	// Flags replace config sources, and listing the primary fixes its position.
+	got, err = resolveRunSources(primary, []string{testAppProject + ":production", testPlatformProject + ":staging"}, []project.Source{{ProjectId: testPlatformProject}})
+	if err != nil {
+		t.Fatalf("resolveRunSources: %v", err)
+	}
+	if want := []runSource{primary, {ProjectID: testPlatformProject, Environment: "staging"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("flag sources: expected %v, got %v", want, got)
+	}
+
+	for _, specs := range [][]string{{"not-a-uuid:production"}, {testPlatformProject, testPlatformProject + ":production"}} {
+		if _, err := resolveRunSources(primary, specs, nil); err == nil {
+			t.Errorf("expected an error for %v", specs)
+		}
+	}
+}
+
+func TestMergeRunSources_LaterSourceWins(t *testing.T) {
+	platform := runSource{ProjectID: testPlatformProject, Environment: "production"}
+	app := runSource{ProjectID: testAppProject, Environment: "production"}
+
+	merged, origins := mergeRunSources([]runSourceResult{
+		{source: platform, secrets: []offlinecache.Secret{{Key: "OTEL_KEY", Value: "otel"}, {Key: "LOG_LEVEL", Value: "info"}}, offline: true},
+		{source: app, secrets: []offlinecache.Secret{{Key: "LOG_LEVEL", Value: "debug"}, {Key: "DATABASE_URL", Value: "postgres://"}}},
+	})
+
+	want := []offlinecache.Secret{{Key: "OTEL_KEY", Value: "otel"}, {Key: "LOG_LEVEL", Value: "debug"}, {Key: "DATABASE_URL", Value: "postgres://"}}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("expected %v, got %v", want, merged)
+	}
+	if origins[0].Source != platform || !origins[0].Offline {
+		t.Fatalf("expected OTEL_KEY from the offline platform source, got %+v", origins[0])
+	}
+	if origins[1].Source != app || !reflect.DeepEqual(origins[1].Shadowed, []runSource{platform}) {
+		t.Fatalf("expected LOG_LEVEL from app shadowing platform, got %+v", origins[1])
+	}
+}
+
+func TestPrintRunExplain_MasksValues(t *testing.T) {
+	app := runSource{ProjectID: testAppProject, Environment: "production"}
+	var buf bytes.Buffer
+	printRunExplain(&buf, []runSource{app}, []secretOrigin{{Key: "DATABASE_URL", Value: "postgres://user:pass@db/app", Source: app}},
+		project.KeyMapping{Rename: map[string]string{"DATABASE_URL": "PRISMA_DATABASE_URL"}})
+
+	out := buf.String()
+	if strings.Contains(out, "user:pass") || strings.Contains(out, "po*") || !strings.Contains(out, "27 chars") {
+		t.Fatalf("explain output leaked a value:\n%s", out)
+	}
+	if !strings.Contains(out, "PRISMA_DATABASE_URL (from DATABASE_URL)") || !strings.Contains(out, app.String()) {
+		t.Fatalf("unexpected explain output:\n%s", out)
+	}
+}
+
+func TestRunCmd_ExplainShowsProvenance(t *testing.T) {
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/environments"):
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"development","isDefault":true}]}`))
+		case strings.Contains(r.URL.Path, testPlatformProject+"/secrets"):
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"OTEL_KEY","value":"otel-platform-key"},{"key":"LOG_LEVEL","value":"info"}]}`))
+		case strings.Contains(r.URL.Path, "/secrets"):
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"LOG_LEVEL","value":"debug"}]}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	_ = os.WriteFile(tmp+"/envault.json", []byte(`{"projectId":"`+testAppProject+`","defaultEnvironment":"development","sources":[{"projectId":"`+testPlatformProject+`"}]}`), 0644)
+
+	cmd := exec.Command(buildBinary(t), "run", "--explain")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+		"NO_COLOR=1",
+	)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("run --explain failed: %v\nstderr:\n%s", err, errBuf.String())
+	}
+
+	out := outBuf.String()
+	if strings.Contains(out, "otel-platform-key") {
+		t.Fatalf("explain output leaked a value:\n%s", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "OTEL_KEY":
+			if fields[1] != testPlatformProject+":development" {
+				t.Errorf("expected OTEL_KEY from the platform source, got line %q", line)
+			}
+		case "LOG_LEVEL":
+			if fields[1] != testAppProject+":development" || !strings.HasSuffix(line, testPlatformProject+":development") {
+				t.Errorf("expected LOG_LEVEL from the app source overriding platform, got line %q", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// handedOffTerminal records that a child's process group was made the
+// terminal's foreground group, so envault must take the terminal back once
+// that child exits.
+var handedOffTerminal bool
+
+// configureChildProcessGroup starts the child in its own process group so
+// that signals reach it and everything it spawns. When envault owns the
+// terminal, the child's group becomes the foreground group so interactive
+// input and Ctrl+C keep working.
+func configureChildProcessGroup(cmd *exec.Cmd) {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	if fd, ok := foregroundTerminal(); ok {
+		attr.Foreground = true
+		attr.Ctty = fd
+		handedOffTerminal = true
+	}
+	cmd.SysProcAttr = attr
+}
+
+// foregroundTerminal reports whether stdin is a terminal whose foreground
+// process group is envault's own.
+func foregroundTerminal() (int, bool) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, false
+	}
+	pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil || pgrp != unix.Getpgrp() {
+		return 0, false
+	}
+	return fd, true
+}
+
+// reclaimTerminal makes envault's process group the foreground group again
+// after a child that had been handed the terminal has exited.
+func reclaimTerminal() {
+	if !handedOffTerminal {
+		return
+	}
+	handedOffTerminal = false
+
+	// A background process that changes the foreground group receives
+	// SIGTTOU, which would stop envault.
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(int(os.Stdin.Fd()), unix.TIOCSPGRP, unix.Getpgrp())
+}
+
+func signalChildGroup(cmd *exec.Cmd, sig os.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
+
+func killChildGroup(cmd *exec.Cmd) error {
+	return signalChildGroup(cmd, syscall.SIGKILL)
+}
+
+// forwardedSignals are the catchable signals relayed to the child. Job
+// control signals (TSTP, CONT, TTIN, TTOU) and WINCH keep their default
+// behaviour; the terminal already delivers them to the foreground group.
+func forwardedSignals() []os.Signal {
+	return []os.Signal{
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGQUIT,
+		syscall.SIGTERM,
+		syscall.SIGUSR1,
+		syscall.SIGUSR2,
+		syscall.SIGALRM,
+	}
+}
+
+// forwardSignal relays sig to the child, or to its whole process group when
+// it runs in its own group. When the child shares envault's process group on
+// a terminal, Ctrl+C and Ctrl+\ have already reached it from the terminal and
+// are not sent a second time.
+func forwardSignal(cmd *exec.Cmd, sig os.Signal, ownGroup bool) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	if ownGroup {
+		return signalChildGroup(cmd, sig)
+	}
+	if (sig == syscall.SIGINT || sig == syscall.SIGQUIT) && term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return cmd.Process.Signal(sig)
+}
+
+// waitChild reports the child's exit code on the returned channel. As PID 1
+// (for example as a container entrypoint) envault also inherits orphaned
+// processes and reaps them so that they do not linger as zombies.
+func waitChild(cmd *exec.Cmd) <-chan int {
+	done := make(chan int, 1)
+	if os.Getpid() != 1 {
+		go func() {
+			done <- childExitCode(cmd.Wait())
+		}()
+		return done
+	}
+
+	go reapChildren(cmd.Process.Pid, done)
+	return done
+}
+
+func reapChildren(childPid int, done chan<- int) {
+	sigchld := make(chan os.Signal, 16)
+	signal.Notify(sigchld, syscall.SIGCHLD)
+	defer signal.Stop(sigchld)
+
+	for {
+		for {
+			var status unix.WaitStatus
+			pid, err := unix.Wait4(-1, &status, unix.WNOHANG, nil)
+			if err == unix.EINTR {
+				continue
+			}
+			if err != nil || pid <= 0 {
+				break
+			}
+			if pid == childPid {
+				done <- waitStatusExitCode(status)
+				return
+			}
+		}
+		<-sigchld
+	}
+}
+
+func waitStatusExitCode(status unix.WaitStatus) int {
+	if status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return status.ExitStatus()
+}
+
+// execReplace replaces the envault process with the command. It only returns
+// on failure.
+func execReplace(cmd *exec.Cmd) error {
+	return syscall.Exec(cmd.Path, cmd.Args, cmd.Env)
+}
+
+// parseSignalName accepts names such as "SIGTERM", "term" or "HUP".
+func parseSignalName(name string) (os.Signal, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(normalized, "SIG") {
+		normalized = "SIG" + normalized
+	}
+	if sig := unix.SignalNum(normalized); sig != 0 {
+		return sig, nil
+	}
+	return nil, fmt.Errorf("unknown signal %q", name)
+}
@@ -0,0 +1,176 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that can be read while exec copies the
+// child's output into it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// startRunWithMockSecrets starts `envault <args...>` against a mock API that
+// serves a single development environment with two plain secrets.
+func startRunWithMockSecrets(t *testing.T, args ...string) (*exec.Cmd, *lockedBuffer, *lockedBuffer) {
+	t.Helper()
+
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Path, "/environments") {
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"development","isDefault":true}]}`))
+			return
+		}
+		if strings.Contains(r.URL.Path, "/secrets") {
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"INJECTED","value":"yes"},{"key":"API_TOKEN","value":"sk_live_masked_value"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(mockSrv.Close)
+
+	tmp := t.TempDir()
+	_ = os.WriteFile(tmp+"/envault.json", []byte(`{"projectId":"aaaaaaaa-bbbb-4ccc-8ddd-eeeeeeeeeeee","defaultEnvironment":"development"}`), 0644)
+
+	bin := buildBinary(t)
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+	)
+
+	var outBuf, errBuf lockedBuffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("cmd.Start: %v", err)
+	}
+	return cmd, &outBuf, &errBuf
+}
+
+func waitExitCode(t *testing.T, cmd *exec.Cmd, timeout time.Duration) int {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		return 0
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		t.Fatalf("process did not exit within %s", timeout)
+		return -1
+	}
+}
+
+func TestRunCmd_ForwardsSIGTERMToChild(t *testing.T) {
+	for _, extra := range [][]string{nil, {"--new-process-group"}} {
+		t.Run(fmt.Sprintf("args=%v", extra), func(t *testing.T) {
+			args := append([]string{"run"}, extra...)
+			args = append(args, "--", "sh", "-c", `trap 'echo got-term; exit 3' TERM; echo ready; while :; do sleep 0.1; done`)
+			cmd, outBuf, errBuf := startRunWithMockSecrets(t, args...)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for !strings.Contains(outBuf.String(), "ready") {
+				if time.Now().After(deadline) {
+					_ = cmd.Process.Kill()
+					t.Fatalf("child never started\nstderr:\n%s", errBuf.String())
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			_ = cmd.Process.Signal(syscall.SIGTERM)
+			if code := waitExitCode(t, cmd, 5*time.Second); code != 3 {
+				t.Fatalf("expected child's exit code 3, got %d\nstdout:\n%s\nstderr:\n%s", code, outBuf.String(), errBuf.String())
+			}
+			if !strings.Contains(outBuf.String(), "got-term") {
+				t.Fatalf("child did not receive SIGTERM, stdout:\n%s", outBuf.String())
+			}
+		})
+	}
+}
+
+func TestRunCmd_SignaledChildExitsWith128PlusSignal(t *testing.T) {
+	cmd, _, errBuf := startRunWithMockSecrets(t, "run", "--", "sh", "-c", "kill -KILL $$")
+	if code := waitExitCode(t, cmd, 5*time.Second); code != 128+int(syscall.SIGKILL) {
+		t.Fatalf("expected exit code %d, got %d\nstderr:\n%s", 128+int(syscall.SIGKILL), code, errBuf.String())
+	}
+}
+
+func TestRunCmd_ExecReplacesProcess(t *testing.T) {
+	cmd, outBuf, errBuf := startRunWithMockSecrets(t, "run", "--exec", "--", "sh", "-c", `echo "pid=$$ injected=$INJECTED"`)
+	if code := waitExitCode(t, cmd, 5*time.Second); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr:\n%s", code, errBuf.String())
+	}
+
+	want := fmt.Sprintf("pid=%d injected=yes", cmd.Process.Pid)
+	if got := strings.TrimSpace(outBuf.String()); got != want {
+		t.Fatalf("expected %q from the exec'd command, got %q", want, got)
+	}
+}
+
+func TestRunCmd_MaskRedactsChildOutput(t *testing.T) {
+	script := `echo "token=$API_TOKEN"; printf '%s' "$API_TOKEN" | base64 >&2; printf 'sk_live_'; printf 'masked_value\n'`
+	cmd, outBuf, errBuf := startRunWithMockSecrets(t, "run", "--mask", "--", "sh", "-c", script)
+	if code := waitExitCode(t, cmd, 5*time.Second); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr:\n%s", code, errBuf.String())
+	}
+
+	for _, out := range []string{outBuf.String(), errBuf.String()} {
+		if strings.Contains(out, "sk_live_masked_value") || strings.Contains(out, "c2tfbGl2ZV9tYXNrZWRfdmFsdWU") {
+			t.Fatalf("secret leaked into output:\nstdout:\n%s\nstderr:\n%s", outBuf.String(), errBuf.String())
+		}
+	}
+	if got, want := outBuf.String(), "token=***API_TOKEN***\n***API_TOKEN***\n"; got != want {
+		t.Fatalf("expected stdout %q, got %q", want, got)
+	}
+	if !strings.Contains(errBuf.String(), "***API_TOKEN***") {
+		t.Fatalf("expected base64 form to be masked on stderr, got:\n%s", errBuf.String())
+	}
+}
+
+func TestRunCmd_SecretsDirIsRemovedAfterExit(t *testing.T) {
+	script := `echo "$ENVAULT_SECRETS_DIR"; cat "$ENVAULT_SECRETS_DIR/API_TOKEN"; echo; echo "env=${API_TOKEN:-unset}"`
+	cmd, outBuf, errBuf := startRunWithMockSecrets(t, "run", "--secrets-dir", "--", "sh", "-c", script)
+	if code := waitExitCode(t, cmd, 5*time.Second); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr:\n%s", code, errBuf.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(outBuf.String()), "\n")
+	if len(lines) != 3 || lines[1] != "sk_live_masked_value" || lines[2] != "env=unset" {
+		t.Fatalf("unexpected child output:\n%s\nstderr:\n%s", outBuf.String(), errBuf.String())
+	}
+	if _, err := os.Stat(lines[0]); !os.IsNotExist(err) {
+		t.Fatalf("expected secrets directory %s to be removed, stat err=%v", lines[0], err)
+	}
+}
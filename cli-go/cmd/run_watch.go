@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+)
+
+// watchedChild is a running child process together with its exit code.
+type watchedChild struct {
+	cmd  *exec.Cmd
+	done <-chan int
+}
+
+func startWatchedChild(runTarget string, runArgs []string, secrets []offlinecache.Secret) (*watchedChild, error) {
+	command, err := buildRunCommand(runTarget, runArgs, secrets)
+	if err != nil {
+		return nil, err
+	}
+	var output *maskedOutput
+	if runMask {
+		output, err = attachMaskedOutput(command, secrets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	configureChildProcessGroup(command)
+	err = command.Start()
+	output.started()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute command: %v", err)
+	}
+
+	exited := waitChild(command)
+	done := make(chan int, 1)
+	go func() {
+		code := <-exited
+		output.finish()
+		done <- code
+	}()
+	return &watchedChild{cmd: command, done: done}, nil
+}
+
+// stop sends sig to the child's process group and waits up to grace for it
+// to exit before killing the whole group.
+func (c *watchedChild) stop(sig os.Signal, grace time.Duration) {
+	_ = signalChildGroup(c.cmd, sig)
+	select {
+	case <-c.done:
+		return
+	case <-time.After(grace):
+	}
+
+	fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Child did not exit within %s; killing it.", grace)))
+	_ = killChildGroup(c.cmd)
+	<-c.done
+}
+
+// superviseWatchedChild runs the command and restarts it whenever refresh
+// reports a different set of secrets. It returns the exit code of the last
+// child once that child exits on its own.
+func superviseWatchedChild(runTarget string, runArgs []string, secrets []offlinecache.Secret, refresh func(context.Context) ([]offlinecache.Secret, error)) int {
+	restartSignal, err := parseSignalName(runWatchSignal)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+		return 1
+	}
+	interval := runWatchInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	sigCh := make(chan os.Signal, 8)
+	signal.Notify(sigCh, forwardedSignals()...)
+	defer signal.Stop(sigCh)
+
+	child, err := startWatchedChild(runTarget, runArgs, secrets)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+		return 1
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	fmt.Fprintln(os.Stderr, ui.ColorDim(fmt.Sprintf("Watching for secret changes every %s.", interval)))
+
+	for {
+		select {
+		case code := <-child.done:
+			reclaimTerminal()
+			return code
+		case sig := <-sigCh:
+			_ = forwardSignal(child.cmd, sig, true)
+		case <-ticker.C:
+			next, err := refresh(context.Background())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow(fmt.Sprintf("Warning: could not check for secret changes: %v", err)))
+				continue
+			}
+			changed := changedSecretKeys(secrets, next)
+			if len(changed) == 0 {
+				continue
+			}
+
+			fmt.Fprintln(os.Stderr, ui.ColorCyan(fmt.Sprintf("Secrets changed (%s). Restarting command...", strings.Join(changed, ", "))))
+			child.stop(restartSignal, runWatchGrace)
+			reclaimTerminal()
+			secrets = next
+
+			child, err = startWatchedChild(runTarget, runArgs, secrets)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(err.Error()))
+				return 1
+			}
+		}
+	}
+}
+
+// changedSecretKeys returns the sorted names of keys that were added, removed
+// or changed between two secret sets.
+func changedSecretKeys(previous, next []offlinecache.Secret) []string {
+	before := make(map[string]string, len(previous))
+	for _, s := range previous {
+		before[s.Key] = s.Value
+	}
+	after := make(map[string]string, len(next))
+	for _, s := range next {
+		after[s.Key] = s.Value
+	}
+
+	changed := []string{}
+	for k, v := range after {
+		if old, ok := before[k]; !ok || old != v {
+			changed = append(changed, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+func TestChangedSecretKeys(t *testing.T) {
+	previous := []offlinecache.Secret{
+		{Key: "KEEP", Value: "same"},
+		{Key: "ROTATED", Value: "old"},
+		{Key: "REMOVED", Value: "x"},
+	}
+	next := []offlinecache.Secret{
+		{Key: "KEEP", Value: "same"},
+		{Key: "ROTATED", Value: "new"},
+		{Key: "ADDED", Value: "y"},
+	}
+
+	got := changedSecretKeys(previous, next)
+	want := []string{"ADDED", "REMOVED", "ROTATED"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("changedSecretKeys = %v, want %v", got, want)
+	}
+
+	if got := changedSecretKeys(previous, previous); len(got) != 0 {
+		t.Fatalf("expected no changes for identical sets, got %v", got)
+	}
+}
+
+func TestRunCmd_WatchRestartsOnSecretChange(t *testing.T) {
+	var secretRequests int32
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Path, "/environments") {
+			_, _ = w.Write([]byte(`{"environments":[{"slug":"development","isDefault":true}]}`))
+			return
+		}
+		if strings.Contains(r.URL.Path, "/secrets") {
+			if atomic.AddInt32(&secretRequests, 1) == 1 {
+				_, _ = w.Write([]byte(`{"secrets":[{"key":"ROTATING","value":"first"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"secrets":[{"key":"ROTATING","value":"second"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	_ = os.WriteFile(tmp+"/envault.json", []byte(`{"projectId":"aaaaaaaa-bbbb-4ccc-8ddd-eeeeeeeeeeee","defaultEnvironment":"development"}`), 0644)
+
+	bin := buildBinary(t)
+	cmd := exec.Command(bin, "run", "--watch", "--interval", "200ms", "--grace-period", "1s", "--",
+		"sh", "-c", `echo "value=$ROTATING"; if [ "$ROTATING" = "second" ]; then exit 7; fi; sleep 10`)
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(),
+		"HOME="+tmp,
+		"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+		"ENVAULT_TOKEN=envault_svc_test-token",
+		"ENVAULT_ALLOW_INSECURE_HTTP=1",
+	)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("cmd.Start: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		exitCode := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+		if exitCode != 7 {
+			t.Fatalf("expected exit code 7 from restarted child, got %d\nstderr:\n%s", exitCode, errBuf.String())
+		}
+	case <-time.After(8 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatalf("watch did not restart the child\nstdout:\n%s\nstderr:\n%s", outBuf.String(), errBuf.String())
+	}
+
+	if out := outBuf.String(); !strings.Contains(out, "value=first") || !strings.Contains(out, "value=second") {
+		t.Fatalf("expected output from both children, got:\n%s", out)
+	}
+	stderr := errBuf.String()
+	if !strings.Contains(stderr, "ROTATING") {
+		t.Fatalf("expected changed key name in stderr, got:\n%s", stderr)
+	}
+	if strings.Contains(stderr, "second") {
+		t.Fatalf("changed secret value leaked to stderr:\n%s", stderr)
+	}
+}
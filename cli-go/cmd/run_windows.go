@@ -0,0 +1,71 @@
+//go:build windows
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Windows has no process groups in the Unix sense and cannot deliver signals
+// other than a kill, so the child runs as a plain process and every stop
+// request terminates it.
+func configureChildProcessGroup(cmd *exec.Cmd) {}
+
+func reclaimTerminal() {}
+
+func signalChildGroup(cmd *exec.Cmd, sig os.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+func killChildGroup(cmd *exec.Cmd) error {
+	return signalChildGroup(cmd, os.Kill)
+}
+
+// forwardedSignals are caught so that envault outlives a console Ctrl+C long
+// enough to report the child's exit code; the console already delivers the
+// event to the child.
+func forwardedSignals() []os.Signal {
+	return []os.Signal{os.Interrupt, syscall.SIGTERM}
+}
+
+func forwardSignal(cmd *exec.Cmd, sig os.Signal, ownGroup bool) error {
+	if cmd.Process == nil || sig == os.Interrupt {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+func waitChild(cmd *exec.Cmd) <-chan int {
+	done := make(chan int, 1)
+	go func() {
+		done <- childExitCode(cmd.Wait())
+	}()
+	return done
+}
+
+func execReplace(cmd *exec.Cmd) error {
+	return errors.New("--exec is not supported on Windows")
+}
+
+func parseSignalName(name string) (os.Signal, error) {
+	normalized := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+	switch normalized {
+	case "INT":
+		return os.Interrupt, nil
+	case "TERM":
+		return syscall.SIGTERM, nil
+	case "KILL":
+		return os.Kill, nil
+	case "HUP":
+		return syscall.SIGHUP, nil
+	}
+	return nil, fmt.Errorf("unknown signal %q", name)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/crypto"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+const decryptionFailedMarker = "<<DECRYPTION_FAILED>>"
+
+// decryptSecrets converts an API secrets response into plaintext key/value
+// pairs. Secrets that cannot be decrypted keep the decryption-failed marker
+// and are reported through warn when it is non-nil.
+func decryptSecrets(resp SecretsResponse, warn func(key string, err error)) []offlinecache.Secret {
+	out := make([]offlinecache.Secret, len(resp.Secrets))
+	for i, s := range resp.Secrets {
+		plaintext := decryptionFailedMarker
+		if s.Ciphertext != "" && s.Ciphertext != decryptionFailedMarker && s.Dek != "" {
+			decrypted, err := crypto.DecryptAESGCM(s.Ciphertext, s.Dek)
+			if err == nil {
+				plaintext = decrypted
+			} else if warn != nil {
+				warn(s.Key, err)
+			}
+		} else if s.Value != "" || (s.Ciphertext == "" && s.Dek == "") {
+			plaintext = s.Value
+		}
+		out[i] = offlinecache.Secret{Key: s.Key, Value: plaintext}
+	}
+	return out
+}
+
+// fetchEnvironmentSecrets fetches and decrypts the secrets of one environment.
+// A non-positive timeout uses the client's default. Decryption failures are
+// reported through warn when it is non-nil.
+func fetchEnvironmentSecrets(ctx context.Context, client *api.Client, projectID, environment string, timeout time.Duration, warn func(key string, err error)) ([]offlinecache.Secret, error) {
+	path := fmt.Sprintf("/projects/%s/secrets?environment=%s", projectID, url.QueryEscape(environment))
+	respBytes, err := client.GetWithContextAndTimeout(ctx, path, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp SecretsResponse
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets response: %w", err)
+	}
+	return decryptSecrets(resp, warn), nil
+}
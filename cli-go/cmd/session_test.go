@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+)
+
+// --- Helper -------------------------------------------------------------------
+
+// recordedRequest is one request the mock API received. Body holds the
+// decoded JSON body, or nil when there was none.
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   map[string]interface{}
+}
+
+// mockAPI is an httptest server that records every authenticated request.
+// The handler runs on the server's goroutines, so recorded requests are only
+// read through Requests, under the lock.
+type mockAPI struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+// newMockAPI starts a server that rejects requests not bearing accessToken,
+// records the rest and answers them with respond.
+func newMockAPI(t *testing.T, accessToken string, respond func(w http.ResponseWriter, req recordedRequest)) *mockAPI {
+	t.Helper()
+	m := &mockAPI{}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		req := recordedRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()}
+		_ = json.NewDecoder(r.Body).Decode(&req.Body)
+
+		m.mu.Lock()
+		m.requests = append(m.requests, req)
+		m.mu.Unlock()
+		respond(w, req)
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// Requests returns a copy of the recorded requests with the given method.
+func (m *mockAPI) Requests(method string) []recordedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []recordedRequest{}
+	for _, req := range m.requests {
+		if req.Method == method {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+// newSessionCLI returns a function that runs the CLI against api in a fresh
+// home directory, logged in with accessToken and with no ENVAULT_TOKEN.
+func newSessionCLI(t *testing.T, api *mockAPI, accessToken string, extraEnv ...string) func(args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	return newSessionCLIIn(t, t.TempDir(), api, accessToken, extraEnv...)
+}
+
+// newSessionCLIIn is newSessionCLI with tmp as both home and working
+// directory, for tests that prepare or inspect files there.
+func newSessionCLIIn(t *testing.T, tmp string, api *mockAPI, accessToken string, extraEnv ...string) func(args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	bin := buildBinary(t)
+	return func(args ...string) (string, string, error) {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = tmp
+		cmd.Env = append(os.Environ(),
+			"HOME="+tmp,
+			"ENVAULT_CLI_URL="+api.URL+"/api/cli",
+			"ENVAULT_ALLOW_INSECURE_HTTP=1",
+			"ENVAULT_TOKEN=",
+			"ENVAULT_CREDENTIAL_STORE=env",
+			"ENVAULT_ACCESS_TOKEN="+accessToken,
+			"NO_COLOR=1",
+		)
+		cmd.Env = append(cmd.Env, extraEnv...)
+		var outBuf, errBuf bytes.Buffer
+		cmd.Stdout = &outBuf
+		cmd.Stderr = &errBuf
+		err := cmd.Run()
+		return outBuf.String(), errBuf.String(), err
+	}
+}
@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenName   string
+	tokenTTL    string
+	tokensJSON  bool
+	forceRevoke bool
+)
+
+// serviceToken is a service token record as the API returns it; the secret
+// itself is only ever returned once, by create.
+type serviceToken struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Environment string     `json:"environment"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+type serviceTokenListResponse struct {
+	Tokens []serviceToken `json:"tokens"`
+}
+
+type serviceTokenCreateResponse struct {
+	Token  string       `json:"token"`
+	Record serviceToken `json:"tokenRecord"`
+}
+
+// serviceTokenJSON is the --json shape of a token, with the project it
+// belongs to and, after create, the secret.
+type serviceTokenJSON struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"projectId"`
+	Name        string     `json:"name"`
+	Environment string     `json:"environment"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	Token       string     `json:"token,omitempty"`
+}
+
+func (t serviceToken) toJSON(projectID string) serviceTokenJSON {
+	return serviceTokenJSON{
+		ID:          t.ID,
+		ProjectID:   projectID,
+		Name:        t.Name,
+		Environment: t.Environment,
+		CreatedAt:   t.CreatedAt,
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+	}
+}
+
+var tokensCmd = &cobra.Command{
+	Use:     "tokens",
+	Aliases: []string{"token"},
+	Short:   "Manage service tokens for CI and servers",
+	Long: `Create, list and revoke a project's service tokens (envault_svc_...).
+A service token reads one environment's secrets and is passed to the CLI in
+ENVAULT_TOKEN. Managing tokens needs a personal login as the project owner.
+
+--json prints machine-readable output for provisioning scripts.`,
+}
+
+var tokensCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a service token and print it once",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.TrimSpace(tokenName)
+		if name == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Pass a token name with --name."))
+			os.Exit(1)
+		}
+		environment := strings.TrimSpace(envFlag)
+		if environment == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Pass the environment the token may read with --env."))
+			os.Exit(1)
+		}
+		payload := map[string]interface{}{
+			"name":        name,
+			"environment": environment,
+		}
+		if strings.TrimSpace(tokenTTL) != "" {
+			ttl, err := offlinecache.ParseMaxAge(tokenTTL)
+			if err != nil || ttl < time.Second {
+				fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Invalid --ttl %q: expected a positive duration such as 72h or 30d.", tokenTTL)))
+				os.Exit(1)
+			}
+			payload["ttl_seconds"] = int(ttl / time.Second)
+		}
+		projectID := tokensProjectIDOrExit()
+
+		client := api.NewClient()
+		respBytes, err := client.Post(serviceTokensPath(projectID), payload)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to create service token."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+		var resp serviceTokenCreateResponse
+		if err := json.Unmarshal(respBytes, &resp); err != nil || resp.Token == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to parse the created token."))
+			os.Exit(1)
+		}
+
+		if tokensJSON {
+			out := resp.Record.toJSON(projectID)
+			out.Token = resp.Token
+			printTokensJSON(out)
+			return
+		}
+		fmt.Fprintln(os.Stderr, ui.ColorGreen(fmt.Sprintf("[OK] Created service token %q for %s (%s), %s.", resp.Record.Name, projectID, resp.Record.Environment, describeTokenExpiry(resp.Record.ExpiresAt))))
+		fmt.Fprintln(os.Stderr, ui.ColorYellow("Copy it now; it cannot be shown again."))
+		fmt.Println(resp.Token)
+	},
+}
+
+var tokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a project's service tokens",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectID := tokensProjectIDOrExit()
+		tokens := listServiceTokensOrExit(projectID)
+
+		if tokensJSON {
+			out := make([]serviceTokenJSON, 0, len(tokens))
+			for _, t := range tokens {
+				out = append(out, t.toJSON(projectID))
+			}
+			printTokensJSON(out)
+			return
+		}
+		if len(tokens) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("No service tokens."))
+			return
+		}
+
+		now := time.Now()
+		rows := [][]string{{"ID", "NAME", "SCOPE", "CREATED", "LAST USED", "EXPIRES"}}
+		for _, t := range tokens {
+			lastUsed := "never"
+			if t.LastUsedAt != nil {
+				lastUsed = humanizeDuration(now.Sub(*t.LastUsedAt)) + " ago"
+			}
+			expires := "never"
+			if t.ExpiresAt != nil {
+				if t.ExpiresAt.Before(now) {
+					expires = "expired"
+				} else {
+					expires = "in " + humanizeDuration(t.ExpiresAt.Sub(now))
+				}
+			}
+			rows = append(rows, []string{
+				t.ID,
+				t.Name,
+				t.Environment,
+				t.CreatedAt.Local().Format("2006-01-02"),
+				lastUsed,
+				expires,
+			})
+		}
+		printCacheTable(rows)
+	},
+}
+
+var tokensRevokeCmd = &cobra.Command{
+	Use:   "revoke <name-or-id>",
+	Short: "Revoke a service token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectID := tokensProjectIDOrExit()
+		target := findServiceTokenOrExit(listServiceTokensOrExit(projectID), args[0])
+
+		if !forceRevoke {
+			if Headless {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Error: revoking a token needs --force in headless mode."))
+				os.Exit(1)
+			}
+			confirm := false
+			prompt := &survey.Confirm{Message: fmt.Sprintf("Revoke service token %q (%s)? Anything using it stops working.", target.Name, target.Environment)}
+			if err := survey.AskOne(prompt, &confirm); err != nil || !confirm {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+				return
+			}
+		}
+
+		client := api.NewClient()
+		if _, err := client.Delete(serviceTokensPath(projectID) + "/" + url.PathEscape(target.ID)); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to revoke service token."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+
+		if tokensJSON {
+			printTokensJSON(target.toJSON(projectID))
+			return
+		}
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Revoked service token %q.", target.Name)))
+	},
+}
+
+func tokensProjectIDOrExit() string {
+	projectID := strings.TrimSpace(ensureProjectID())
+	if !isValidProjectID(projectID) {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("No valid project. Pass --project or run inside a linked project."))
+		os.Exit(1)
+	}
+	return projectID
+}
+
+func serviceTokensPath(projectID string) string {
+	return "/projects/" + url.PathEscape(projectID) + "/service-tokens"
+}
+
+func listServiceTokensOrExit(projectID string) []serviceToken {
+	client := api.NewClient()
+	respBytes, err := client.Get(serviceTokensPath(projectID))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to list service tokens."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+		os.Exit(1)
+	}
+	var resp serviceTokenListResponse
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to parse service tokens: %v", err)))
+		os.Exit(1)
+	}
+	return resp.Tokens
+}
+
+// findServiceTokenOrExit matches an ID exactly, then a name; a name shared by
+// several tokens must be given as an ID.
+func findServiceTokenOrExit(tokens []serviceToken, ref string) serviceToken {
+	ref = strings.TrimSpace(ref)
+	var byName []serviceToken
+	for _, t := range tokens {
+		if t.ID == ref {
+			return t
+		}
+		if t.Name == ref {
+			byName = append(byName, t)
+		}
+	}
+	switch len(byName) {
+	case 1:
+		return byName[0]
+	case 0:
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("No service token named or with ID %q.", ref)))
+	default:
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Several service tokens are named %q; pass the ID from `envault tokens list`.", ref)))
+	}
+	os.Exit(1)
+	return serviceToken{}
+}
+
+func describeTokenExpiry(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return "never expires"
+	}
+	return "expires " + expiresAt.Local().Format(time.RFC3339)
+}
+
+func printTokensJSON(v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+	tokensCmd.AddCommand(tokensCreateCmd, tokensListCmd, tokensRevokeCmd)
+	for _, c := range []*cobra.Command{tokensCreateCmd, tokensListCmd, tokensRevokeCmd} {
+		c.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+		c.Flags().BoolVar(&tokensJSON, "json", false, "Print JSON")
+	}
+	tokensCreateCmd.Flags().StringVar(&tokenName, "name", "", "Token name, unique within the project")
+	tokensCreateCmd.Flags().StringVar(&tokenTTL, "ttl", "", "Lifetime such as 720h or 30d (default: no expiry)")
+	tokensRevokeCmd.Flags().BoolVarP(&forceRevoke, "force", "f", false, "Revoke without confirmation")
+}
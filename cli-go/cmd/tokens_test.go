@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTokensCmd_CreateListRevoke(t *testing.T) {
+	base := "/api/cli/projects/" + testAppProject + "/service-tokens"
+	api := newMockAPI(t, "envault_at_owner", func(w http.ResponseWriter, req recordedRequest) {
+		switch {
+		case req.Method == http.MethodPost && req.Path == base:
+			_, _ = w.Write([]byte(`{"token":"envault_svc_secret","tokenRecord":{"id":"t1","name":"ci-deploy","environment":"production","created_at":"2026-10-18T10:00:00.123456+00:00","expires_at":"2026-11-17T10:00:00+00:00","last_used_at":null}}`))
+		case req.Method == http.MethodGet && req.Path == base:
+			_, _ = w.Write([]byte(`{"tokens":[{"id":"t1","name":"ci-deploy","environment":"production","created_at":"2026-10-18T10:00:00+00:00","expires_at":null,"last_used_at":"2026-10-18T11:00:00+00:00"}]}`))
+		case req.Method == http.MethodDelete && strings.HasPrefix(req.Path, base+"/"):
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	cli := newSessionCLI(t, api, "envault_at_owner")
+	run := func(args ...string) (string, string) {
+		t.Helper()
+		stdout, stderr, err := cli(args...)
+		if err != nil {
+			t.Fatalf("envault %v failed: %v\nstderr:\n%s", args, err, stderr)
+		}
+		return stdout, stderr
+	}
+
+	stdout, stderr := run("tokens", "create", "-p", testAppProject, "--env", "production", "--name", "ci-deploy", "--ttl", "30d")
+	if stdout != "envault_svc_secret\n" || !strings.Contains(stderr, "cannot be shown again") {
+		t.Fatalf("expected only the token on stdout, got %q / %q", stdout, stderr)
+	}
+	created := api.Requests(http.MethodPost)[0].Body
+	if created["name"] != "ci-deploy" || created["environment"] != "production" || created["ttl_seconds"] != float64(30*24*60*60) {
+		t.Fatalf("unexpected create request: %v", created)
+	}
+
+	stdout, _ = run("tokens", "create", "-p", testAppProject, "--env", "production", "--name", "ci-deploy", "--json")
+	var createdJSON serviceTokenJSON
+	if err := json.Unmarshal([]byte(stdout), &createdJSON); err != nil || createdJSON.Token != "envault_svc_secret" || createdJSON.ProjectID != testAppProject || createdJSON.ExpiresAt == nil {
+		t.Fatalf("unexpected create --json output: %s (%v)", stdout, err)
+	}
+
+	stdout, _ = run("tokens", "list", "-p", testAppProject, "--json")
+	var listed []serviceTokenJSON
+	if err := json.Unmarshal([]byte(stdout), &listed); err != nil || len(listed) != 1 || listed[0].Name != "ci-deploy" || listed[0].LastUsedAt == nil || listed[0].Token != "" {
+		t.Fatalf("unexpected list --json output: %s (%v)", stdout, err)
+	}
+
+	stdout, _ = run("tokens", "list", "-p", testAppProject)
+	if !strings.Contains(stdout, "LAST USED") || !strings.Contains(stdout, "ci-deploy") || !strings.Contains(stdout, "never") {
+		t.Fatalf("unexpected list output:\n%s", stdout)
+	}
+
+	run("tokens", "revoke", "ci-deploy", "-p", testAppProject, "--force")
+	if deleted := api.Requests(http.MethodDelete); len(deleted) != 1 || deleted[0].Path != base+"/t1" {
+		t.Fatalf("expected token t1 to be revoked, got %v", deleted)
+	}
+}
+
+func TestTokensTrustCmd_AddListRemove(t *testing.T) {
+	base := "/api/cli/projects/" + testAppProject + "/oidc-trust"
+	policy := `{"id":"p1","project_id":"` + testAppProject + `","issuer":"https://token.actions.githubusercontent.com","audience":"envault","subject_pattern":"repo:acme/shop:*","environment":"production","created_at":"2026-10-18T10:00:00+00:00"}`
+	api := newMockAPI(t, "envault_at_owner", func(w http.ResponseWriter, req recordedRequest) {
+		switch {
+		case req.Method == http.MethodPost && req.Path == base:
+			_, _ = w.Write([]byte(`{"policy":` + policy + `}`))
+		case req.Method == http.MethodGet && req.Path == base:
+			_, _ = w.Write([]byte(`{"policies":[` + policy + `]}`))
+		case req.Method == http.MethodDelete && req.Path == base+"/p1":
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	cli := newSessionCLI(t, api, "envault_at_owner")
+
+	if _, stderr, err := cli("tokens", "trust", "add", "-p", testAppProject, "--issuer", "https://token.actions.githubusercontent.com", "--subject", "*", "--env", "production"); err == nil || !strings.Contains(stderr, "more than a wildcard") {
+		t.Fatalf("expected a bare wildcard subject to be refused, got err=%v stderr=%s", err, stderr)
+	}
+
+	stdout, stderr, err := cli("tokens", "trust", "add", "-p", testAppProject, "--issuer", "https://token.actions.githubusercontent.com", "--subject", "repo:acme/shop:*", "--env", "production")
+	if err != nil || !strings.Contains(stdout, "can now read production") {
+		t.Fatalf("trust add failed: %v\n%s%s", err, stdout, stderr)
+	}
+	added := api.Requests(http.MethodPost)
+	if len(added) != 1 || added[0].Body["audience"] != "envault" || added[0].Body["subject_pattern"] != "repo:acme/shop:*" || added[0].Body["environment"] != "production" {
+		t.Fatalf("unexpected add request: %v", added)
+	}
+
+	stdout, _, err = cli("tokens", "trust", "list", "-p", testAppProject)
+	if err != nil || !strings.Contains(stdout, "repo:acme/shop:*") || !strings.Contains(stdout, "SUBJECT") {
+		t.Fatalf("unexpected trust list output (%v):\n%s", err, stdout)
+	}
+
+	if _, stderr, err = cli("tokens", "trust", "remove", "p1", "-p", testAppProject, "--force"); err != nil {
+		t.Fatalf("trust remove failed: %v\n%s", err, stderr)
+	}
+	if deleted := api.Requests(http.MethodDelete); len(deleted) != 1 {
+		t.Fatalf("expected the policy to be removed, got %v", deleted)
+	}
+}
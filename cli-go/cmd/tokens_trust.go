@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/auth"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trustIssuer   string
+	trustAudience string
+	trustSubject  string
+)
+
+// trustPolicy is an OIDC trust policy as the API returns it.
+type trustPolicy struct {
+	ID             string    `json:"id"`
+	ProjectID      string    `json:"project_id"`
+	Issuer         string    `json:"issuer"`
+	Audience       string    `json:"audience"`
+	SubjectPattern string    `json:"subject_pattern"`
+	Environment    string    `json:"environment"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+var tokensTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage which CI identities `login --oidc` accepts",
+	Long: `Add, list and remove a project's OIDC trust policies. A policy accepts
+identity tokens from one issuer, for one audience, whose subject matches a
+pattern ("*" matches anything), and lets 'envault login --oidc' exchange
+them for short-lived tokens that read one environment. Managing policies
+needs a personal login as the project owner.
+
+  envault tokens trust add --issuer https://token.actions.githubusercontent.com \
+    --subject 'repo:acme/shop:ref:refs/heads/main' --env production`,
+}
+
+var tokensTrustAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Trust CI identity tokens from an issuer",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		issuer := strings.TrimSpace(trustIssuer)
+		subject := strings.TrimSpace(trustSubject)
+		environment := strings.TrimSpace(envFlag)
+		if issuer == "" || subject == "" || environment == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Pass --issuer, --subject and --env."))
+			os.Exit(1)
+		}
+		if strings.Trim(subject, "*") == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("--subject must name more than a wildcard; it would trust every job the issuer signs for."))
+			os.Exit(1)
+		}
+		projectID := tokensProjectIDOrExit()
+
+		respBytes, err := api.NewClient().Post(trustPoliciesPath(projectID), map[string]string{
+			"issuer":          issuer,
+			"audience":        strings.TrimSpace(trustAudience),
+			"subject_pattern": subject,
+			"environment":     environment,
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to add trust policy."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+		var resp struct {
+			Policy trustPolicy `json:"policy"`
+		}
+		if err := json.Unmarshal(respBytes, &resp); err != nil || resp.Policy.ID == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to parse the created trust policy."))
+			os.Exit(1)
+		}
+
+		if tokensJSON {
+			printTokensJSON(resp.Policy)
+			return
+		}
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Jobs from %s with subject %q can now read %s (policy %s).", resp.Policy.Issuer, resp.Policy.SubjectPattern, resp.Policy.Environment, resp.Policy.ID)))
+	},
+}
+
+var tokensTrustListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List a project's OIDC trust policies",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		policies := listTrustPoliciesOrExit(tokensProjectIDOrExit())
+
+		if tokensJSON {
+			printTokensJSON(policies)
+			return
+		}
+		if len(policies) == 0 {
+			fmt.Fprintln(os.Stderr, ui.ColorDim("No trust policies."))
+			return
+		}
+		rows := [][]string{{"ID", "ISSUER", "AUDIENCE", "SUBJECT", "SCOPE", "CREATED"}}
+		for _, p := range policies {
+			rows = append(rows, []string{
+				p.ID,
+				p.Issuer,
+				p.Audience,
+				p.SubjectPattern,
+				p.Environment,
+				p.CreatedAt.Local().Format("2006-01-02"),
+			})
+		}
+		printCacheTable(rows)
+	},
+}
+
+var tokensTrustRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a trust policy and revoke the tokens issued under it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectID := tokensProjectIDOrExit()
+		id := strings.TrimSpace(args[0])
+		var target *trustPolicy
+		policies := listTrustPoliciesOrExit(projectID)
+		for i := range policies {
+			if policies[i].ID == id {
+				target = &policies[i]
+			}
+		}
+		if target == nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("No trust policy with ID %q.", id)))
+			os.Exit(1)
+		}
+
+		if !forceRevoke {
+			if Headless {
+				fmt.Fprintln(os.Stderr, ui.ColorRed("Error: removing a trust policy needs --force in headless mode."))
+				os.Exit(1)
+			}
+			confirm := false
+			prompt := &survey.Confirm{Message: fmt.Sprintf("Remove the policy for %s (%s)? Jobs using it stop working.", target.Issuer, target.SubjectPattern)}
+			if err := survey.AskOne(prompt, &confirm); err != nil || !confirm {
+				fmt.Fprintln(os.Stderr, ui.ColorYellow("Operation cancelled."))
+				return
+			}
+		}
+
+		if _, err := api.NewClient().Delete(trustPoliciesPath(projectID) + "/" + url.PathEscape(target.ID)); err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to remove trust policy."))
+			fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+			os.Exit(1)
+		}
+
+		if tokensJSON {
+			printTokensJSON(target)
+			return
+		}
+		fmt.Println(ui.ColorGreen(fmt.Sprintf("[OK] Removed the trust policy for %s (%s).", target.Issuer, target.SubjectPattern)))
+	},
+}
+
+func trustPoliciesPath(projectID string) string {
+	return "/projects/" + url.PathEscape(projectID) + "/oidc-trust"
+}
+
+func listTrustPoliciesOrExit(projectID string) []trustPolicy {
+	respBytes, err := api.NewClient().Get(trustPoliciesPath(projectID))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed("Failed to list trust policies."))
+		fmt.Fprintln(os.Stderr, ui.ColorRed(classifyAPIError(err)))
+		os.Exit(1)
+	}
+	var resp struct {
+		Policies []trustPolicy `json:"policies"`
+	}
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Failed to parse trust policies: %v", err)))
+		os.Exit(1)
+	}
+	return resp.Policies
+}
+
+func init() {
+	tokensCmd.AddCommand(tokensTrustCmd)
+	tokensTrustCmd.AddCommand(tokensTrustAddCmd, tokensTrustListCmd, tokensTrustRemoveCmd)
+	for _, c := range []*cobra.Command{tokensTrustAddCmd, tokensTrustListCmd, tokensTrustRemoveCmd} {
+		c.Flags().StringVarP(&projectFlag, "project", "p", "", "Project ID")
+		c.Flags().BoolVar(&tokensJSON, "json", false, "Print JSON")
+	}
+	tokensTrustAddCmd.Flags().StringVar(&trustIssuer, "issuer", "", "Issuer URL of the CI provider's identity tokens")
+	tokensTrustAddCmd.Flags().StringVar(&trustAudience, "audience", auth.DefaultOIDCAudience, "Audience the identity tokens must carry")
+	tokensTrustAddCmd.Flags().StringVar(&trustSubject, "subject", "", "Subject pattern to accept; * matches anything")
+	tokensTrustRemoveCmd.Flags().BoolVarP(&forceRevoke, "force", "f", false, "Remove without confirmation")
+}
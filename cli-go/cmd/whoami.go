@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// expiryWarning is how close to expiry a personal access token has to be
+// before whoami warns about it.
+const expiryWarning = 10 * time.Minute
+
+var whoamiJSON bool
+
+// tokenInfo describes a token as /auth/introspect reports it, plus what the
+// CLI knows locally: where the token came from and whether it could be
+// verified.
+type tokenInfo struct {
+	Active       bool       `json:"active"`
+	Verified     bool       `json:"verified"`
+	Type         string     `json:"type"`
+	Prefix       string     `json:"prefix"`
+	Source       string     `json:"source"`
+	Name         string     `json:"name,omitempty"`
+	UserID       string     `json:"userId,omitempty"`
+	Email        string     `json:"email,omitempty"`
+	AgentID      string     `json:"agentId,omitempty"`
+	ProjectID    string     `json:"projectId,omitempty"`
+	ProjectName  string     `json:"projectName,omitempty"`
+	Projects     []string   `json:"projects,omitempty"`
+	Environments []string   `json:"environments"`
+	Keys         []string   `json:"keys,omitempty"`
+	Scopes       []string   `json:"scopes"`
+	CreatedAt    *time.Time `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+	RefreshToken string     `json:"refreshToken,omitempty"`
+	Warnings     []string   `json:"warnings"`
+	// Problem explains why commands cannot use the token even though it may
+	// be valid, such as a personal token set in ENVAULT_TOKEN.
+	Problem string `json:"problem,omitempty"`
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the identity and token the CLI is using",
+	Long: `Show who the CLI acts as: the identity, the kind of token in effect
+(personal envault_at_, service envault_svc_ or agent envault_agt_), its
+scopes, the environments it may read, when it expires and where it came
+from (ENVAULT_TOKEN, the credential store or config.toml).
+
+Use 'envault token inspect' to describe a token read from stdin instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		token, source := activeToken()
+		if token == "" {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Not logged in. Run `envault login`, or set ENVAULT_TOKEN to a service or agent token."))
+			os.Exit(1)
+		}
+
+		info := describeToken(token, source)
+		fromEnv := strings.HasPrefix(source, "ENVAULT_")
+		if fromEnv && (info.Type == "personal" || info.Type == "refresh") {
+			info.Problem = "Personal tokens are not accepted from " + strings.TrimSuffix(source, " environment variable") + "; every other command refuses to run with it. Set a service, agent or federated CI token there instead, or unset it and run `envault login`."
+		}
+		if info.Type == "personal" && !fromEnv {
+			info.RefreshToken = "missing"
+			if refresh, err := credstore.Get(credstore.AccountRefreshToken); err == nil && refresh != "" {
+				info.RefreshToken = "present"
+				// An expired access token is not the end of the session:
+				// commands refresh it on their first request, so do the same.
+				if info.Verified && !info.Active {
+					if refreshed := refreshSessionToken(token); refreshed != "" {
+						warnings := append(info.Warnings, "The access token had expired and was refreshed.")
+						info = describeToken(refreshed, source)
+						info.RefreshToken = "present"
+						info.Warnings = append(warnings, info.Warnings...)
+					}
+				}
+			} else {
+				info.Warnings = append(info.Warnings, "No refresh token in the credential store; the session ends when the access token expires. Run `envault login` to start a new one.")
+			}
+		}
+		reportToken(info)
+	},
+}
+
+var tokensInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Describe a token read from stdin",
+	Long: `Read a token from stdin and describe it the way whoami describes the
+token in effect: identity, type, scopes, allowed environments and expiry.
+The token itself is never printed.
+
+  envault token inspect < token.txt
+  echo "$ENVAULT_TOKEN" | envault token inspect --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprintln(os.Stderr, ui.ColorRed("Pipe the token on stdin, e.g. `envault token inspect < token.txt`."))
+			os.Exit(1)
+		}
+		token, err := readTokenFromStdin(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		reportToken(describeToken(token, "stdin"))
+	},
+}
+
+// activeToken returns the token commands authenticate with and where it
+// came from, in the order api.NewClient picks it.
+func activeToken() (string, string) {
+	if token := strings.TrimSpace(os.Getenv("ENVAULT_TOKEN")); token != "" {
+		return token, "ENVAULT_TOKEN environment variable"
+	}
+	if token := strings.TrimSpace(os.Getenv("ENVAULT_SERVICE_TOKEN")); token != "" {
+		return token, "ENVAULT_SERVICE_TOKEN environment variable"
+	}
+	if token, err := credstore.Get(credstore.AccountAccessToken); err == nil && token != "" {
+		source := "credential store"
+		if store, err := credstore.Default(); err == nil {
+			source = store.Describe()
+		}
+		return token, source
+	}
+	if credstore.HasPlaintextToken() {
+		return strings.TrimSpace(viper.GetString("auth.token")), "config.toml (plaintext)"
+	}
+	return "", ""
+}
+
+func readTokenFromStdin(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read the token from stdin: %w", err)
+	}
+	return "", errors.New("no token on stdin")
+}
+
+// tokenKind classifies a token by its prefix.
+func tokenKind(token string) (string, string) {
+	for _, k := range []struct{ prefix, kind string }{
+		{"envault_at_", "personal"},
+		{"envault_rt_", "refresh"},
+		{"envault_svc_", "service"},
+		{"envault_agt_", "agent"},
+		{api.FederatedTokenPrefix, "federated"},
+	} {
+		if strings.HasPrefix(token, k.prefix) {
+			return k.kind, k.prefix
+		}
+	}
+	return "unknown", ""
+}
+
+// refreshSessionToken makes one authenticated request with the stored
+// session so the client refreshes an expired access token, and returns the
+// new token, or "" when it was not refreshed.
+func refreshSessionToken(token string) string {
+	baseURL, err := api.ResolveBaseURL()
+	if err != nil {
+		return ""
+	}
+	client := &api.Client{BaseURL: baseURL, Token: token, HTTP: &http.Client{}}
+	if _, err := client.Get("/me"); err != nil || client.Token == token {
+		return ""
+	}
+	return client.Token
+}
+
+// describeToken asks the server about the token and falls back to what can
+// be read from the token itself when the server cannot be reached.
+func describeToken(token, source string) tokenInfo {
+	kind, prefix := tokenKind(token)
+	info := tokenInfo{Type: kind, Prefix: prefix, Source: source, Warnings: []string{}}
+
+	if kind == "unknown" {
+		info.Warnings = append(info.Warnings, "This is not an Envault token.")
+		return info
+	}
+	// Introspection sends the token in the body and nothing else, so the
+	// client is built without ENVAULT_TOKEN: a token there that commands
+	// refuse is exactly what whoami has to describe.
+	baseURL, err := api.ResolveBaseURL()
+	var resp []byte
+	if err == nil {
+		client := &api.Client{BaseURL: baseURL, HTTP: &http.Client{}}
+		resp, err = client.Post("/auth/introspect", map[string]string{"token": token})
+	}
+	if err == nil {
+		var remote tokenInfo
+		if err = json.Unmarshal(resp, &remote); err == nil {
+			info.Active = remote.Active
+			info.Verified = true
+			info.Name = remote.Name
+			info.UserID = remote.UserID
+			info.Email = remote.Email
+			info.AgentID = remote.AgentID
+			info.ProjectID = remote.ProjectID
+			info.ProjectName = remote.ProjectName
+			info.Projects = remote.Projects
+			info.Environments = remote.Environments
+			info.Keys = remote.Keys
+			info.Scopes = remote.Scopes
+			info.CreatedAt = remote.CreatedAt
+			info.ExpiresAt = remote.ExpiresAt
+			info.LastUsedAt = remote.LastUsedAt
+		}
+	}
+	if err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("Could not verify the token with the server (%v).", err))
+		decodeTokenLocally(token, &info)
+	}
+
+	if info.Active && kind == "personal" && info.ExpiresAt != nil {
+		if left := time.Until(*info.ExpiresAt); left < expiryWarning {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("The access token expires in %s; it is refreshed automatically while a refresh token is available.", humanizeDuration(left)))
+		}
+	}
+	return info
+}
+
+// decodeTokenLocally fills in what an unverified token reveals on its own.
+// Only agent tokens carry claims; the rest are opaque, so nothing is assumed
+// about them. The token is left inactive: without the server there is no
+// telling whether it was revoked.
+func decodeTokenLocally(token string, info *tokenInfo) {
+	if info.Type != "agent" {
+		return
+	}
+
+	claims, ok := parseAgentTokenClaims(token)
+	if !ok {
+		return
+	}
+	info.UserID = claims.Sub
+	info.AgentID = claims.Act
+	info.Projects = claims.Projects
+	if claims.Env != "" {
+		info.Environments = []string{claims.Env}
+	}
+	info.Keys = claims.Keys
+	if claims.Iat > 0 {
+		created := time.Unix(claims.Iat, 0)
+		info.CreatedAt = &created
+	}
+	if claims.Exp > 0 {
+		expires := time.Unix(claims.Exp, 0)
+		info.ExpiresAt = &expires
+		if !time.Now().Before(expires) {
+			info.Warnings = append(info.Warnings, "The token's own expiry time has passed.")
+		}
+	}
+}
+
+func reportToken(info tokenInfo) {
+	if whoamiJSON {
+		printTokensJSON(info)
+		if !info.Active || info.Problem != "" {
+			os.Exit(1)
+		}
+		return
+	}
+
+	for _, w := range info.Warnings {
+		fmt.Fprintln(os.Stderr, ui.ColorYellow("Warning: "+w))
+	}
+	if info.Verified && !info.Active {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("The %s token from %s is not active: it is revoked, expired or unknown to this server.", info.Type, info.Source)))
+		os.Exit(1)
+	}
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Printf("%s %s\n", ui.ColorBold(label+":"), value)
+		}
+	}
+	field("Identity", describeIdentity(info))
+	kind := info.Type
+	if info.Prefix != "" {
+		kind += fmt.Sprintf(" (%s...)", info.Prefix)
+	}
+	field("Token Type", kind)
+	field("Token Name", info.Name)
+	field("Source", info.Source)
+	if info.ProjectID != "" {
+		field("Project", strings.TrimSpace(fmt.Sprintf("%s (%s)", info.ProjectName, info.ProjectID)))
+	}
+	if len(info.Projects) > 0 {
+		field("Projects", strings.Join(info.Projects, ", "))
+	}
+	field("Scopes", strings.Join(info.Scopes, ", "))
+	environments := "all permitted by your project roles"
+	switch {
+	case info.Environments != nil:
+		environments = strings.Join(info.Environments, ", ")
+	case !info.Verified:
+		environments = "unknown"
+	}
+	field("Environments", environments)
+	field("Keys", strings.Join(info.Keys, ", "))
+	switch {
+	case info.ExpiresAt != nil:
+		field("Expires", fmt.Sprintf("%s (in %s)", info.ExpiresAt.Local().Format(time.RFC3339), humanizeDuration(time.Until(*info.ExpiresAt))))
+	case !info.Verified:
+		field("Expires", "unknown")
+	default:
+		field("Expires", "never")
+	}
+	if info.LastUsedAt != nil {
+		field("Last Used", info.LastUsedAt.Local().Format(time.RFC3339))
+	}
+	field("Refresh Token", info.RefreshToken)
+	if info.Problem != "" {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(info.Problem))
+		os.Exit(1)
+	}
+	if !info.Verified {
+		fmt.Fprintln(os.Stderr, ui.ColorRed(fmt.Sprintf("The %s token from %s could not be verified with the server; it may be revoked or expired.", info.Type, info.Source)))
+		os.Exit(1)
+	}
+}
+
+func describeIdentity(info tokenInfo) string {
+	switch {
+	case info.AgentID != "":
+		return fmt.Sprintf("agent %s acting for user %s", info.AgentID, info.UserID)
+	case info.Email != "":
+		return fmt.Sprintf("%s (user %s)", info.Email, info.UserID)
+	case info.UserID != "":
+		return "user " + info.UserID
+	case info.Type == "service":
+		return "service token"
+	case info.Type == "federated":
+		return "CI job " + info.Name
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+	tokensCmd.AddCommand(tokensInspectCmd)
+	whoamiCmd.Flags().BoolVar(&whoamiJSON, "json", false, "Print JSON")
+	tokensInspectCmd.Flags().BoolVar(&whoamiJSON, "json", false, "Print JSON")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWhoamiAndTokenInspect(t *testing.T) {
+	expiring := time.Now().Add(5 * time.Minute).UTC().Format(time.RFC3339)
+	mockSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/cli/auth/refresh":
+			_, _ = w.Write([]byte(`{"access_token":"envault_at_me"}`))
+			return
+		case r.URL.Path == "/api/cli/me" && r.Header.Get("Authorization") == "Bearer envault_at_me":
+			_, _ = w.Write([]byte(`{"id":"u1"}`))
+			return
+		case r.URL.Path == "/api/cli/me":
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/cli/auth/introspect" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch body["token"] {
+		case "envault_at_me":
+			_, _ = w.Write([]byte(`{"active":true,"type":"personal","name":"CLI Access Token on laptop","userId":"u1","email":"dev@example.com","environments":null,"scopes":["user"],"expiresAt":"` + expiring + `"}`))
+		case "envault_at_old":
+			_, _ = w.Write([]byte(`{"active":false,"type":"personal"}`))
+		case "envault_svc_ci":
+			_, _ = w.Write([]byte(`{"active":true,"type":"service","name":"ci-deploy","projectId":"p1","projectName":"Shop","environments":["production"],"scopes":["secrets:read"],"expiresAt":null}`))
+		default:
+			_, _ = w.Write([]byte(`{"active":false,"type":"service"}`))
+		}
+	}))
+	defer mockSrv.Close()
+
+	tmp := t.TempDir()
+	bin := buildBinary(t)
+	var extraEnv []string
+	run := func(stdin string, args ...string) (string, string, error) {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = tmp
+		cmd.Env = append(os.Environ(),
+			"HOME="+tmp,
+			"ENVAULT_CLI_URL="+mockSrv.URL+"/api/cli",
+			"ENVAULT_ALLOW_INSECURE_HTTP=1",
+			"ENVAULT_TOKEN=",
+			"ENVAULT_CREDENTIAL_STORE=env",
+			"ENVAULT_ACCESS_TOKEN=envault_at_me",
+			"NO_COLOR=1",
+		)
+		cmd.Env = append(cmd.Env, extraEnv...)
+		cmd.Stdin = strings.NewReader(stdin)
+		var outBuf, errBuf bytes.Buffer
+		cmd.Stdout = &outBuf
+		cmd.Stderr = &errBuf
+		err := cmd.Run()
+		return outBuf.String(), errBuf.String(), err
+	}
+
+	stdout, stderr, err := run("", "whoami")
+	if err != nil {
+		t.Fatalf("whoami failed: %v\n%s", err, stderr)
+	}
+	for _, want := range []string{"dev@example.com", "personal (envault_at_...)", "Refresh Token: missing", "all permitted by your project roles"} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q in whoami output:\n%s", want, stdout)
+		}
+	}
+	if !strings.Contains(stderr, "expires in") || !strings.Contains(stderr, "No refresh token") {
+		t.Fatalf("expected expiry and refresh token warnings, got:\n%s", stderr)
+	}
+
+	stdout, _, err = run("envault_svc_ci\n", "token", "inspect", "--json")
+	if err != nil {
+		t.Fatalf("token inspect failed: %v", err)
+	}
+	var info tokenInfo
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil || !info.Active || !info.Verified || info.Source != "stdin" || info.ProjectName != "Shop" || len(info.Environments) != 1 || info.Environments[0] != "production" {
+		t.Fatalf("unexpected inspect --json output: %s (%v)", stdout, err)
+	}
+	if strings.Contains(stdout, "envault_svc_ci") {
+		t.Fatalf("inspect must not echo the token:\n%s", stdout)
+	}
+
+	_, stderr, err = run("envault_svc_revoked\n", "token", "inspect")
+	if err == nil || !strings.Contains(stderr, "not active") {
+		t.Fatalf("expected a revoked token to fail, got err=%v stderr=%s", err, stderr)
+	}
+
+	extraEnv = []string{"ENVAULT_ACCESS_TOKEN=envault_at_old", "ENVAULT_REFRESH_TOKEN=envault_rt_x"}
+	stdout, stderr, err = run("", "whoami")
+	if err != nil || !strings.Contains(stdout, "dev@example.com") || !strings.Contains(stderr, "was refreshed") {
+		t.Fatalf("expected an expired access token to be refreshed, got err=%v\n%s%s", err, stdout, stderr)
+	}
+
+	extraEnv = []string{"ENVAULT_TOKEN=envault_at_me"}
+	stdout, stderr, err = run("", "whoami")
+	if err == nil || !strings.Contains(stdout, "dev@example.com") || !strings.Contains(stderr, "not accepted from ENVAULT_TOKEN") {
+		t.Fatalf("expected a personal token in ENVAULT_TOKEN to be described and refused, got err=%v\n%s%s", err, stdout, stderr)
+	}
+	extraEnv = nil
+
+	mockSrv.Close()
+	stdout, _, err = run("envault_svc_ci\n", "token", "inspect", "--json")
+	if err == nil {
+		t.Fatalf("expected an unverifiable token to fail:\n%s", stdout)
+	}
+	info = tokenInfo{}
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil || info.Active || info.Verified || len(info.Scopes) != 0 {
+		t.Fatalf("an unverifiable token must not be reported active or given scopes: %s (%v)", stdout, err)
+	}
+}
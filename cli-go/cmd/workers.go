@@ -0,0 +1,32 @@
+package cmd
+
+import "sync"
+
+// runBounded calls fn for 0..n-1 on at most workers goroutines and returns
+// once every call has finished. Commands that fan out API requests (matrix,
+// cache sync) share one *api.Client across the calls; the client serializes
+// token refreshes itself.
+func runBounded(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if n < workers {
+		workers = n
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBounded_CallsEveryIndexWithinTheLimit(t *testing.T) {
+	var running, peak int32
+	seen := make([]int32, 10)
+	runBounded(len(seen), 3, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&seen[i], 1)
+		atomic.AddInt32(&running, -1)
+	})
+
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("index %d was called %d times", i, n)
+		}
+	}
+	if peak > 3 {
+		t.Fatalf("expected at most 3 concurrent calls, got %d", peak)
+	}
+}
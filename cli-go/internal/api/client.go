@@ -13,10 +13,10 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/spf13/viper"
-	"github.com/zalando/go-keyring"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
 )
 
 type APIError struct {
@@ -28,13 +28,61 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Body)
 }
 
+// FederatedTokenPrefix marks the short-lived tokens `envault login --oidc`
+// gets in exchange for a CI provider's OIDC token. Like service tokens, they
+// are passed in ENVAULT_TOKEN and are never refreshed.
+const FederatedTokenPrefix = "envault_oidc_"
+
 type Client struct {
 	BaseURL string
 	Token   string
 	HTTP    *http.Client
+	// Header holds extra headers sent with every request, such as the
+	// Idempotency-Key the agent SDK routes require.
+	Header http.Header
+
+	// tokenMu guards Token while requests run concurrently, so that a 401
+	// seen by several goroutines triggers a single refresh.
+	tokenMu sync.Mutex
 }
 
+// NewClient builds a client from the environment and the stored session. It
+// exits when the API URL or ENVAULT_TOKEN is unusable; commands that need
+// their own exit codes use LoadClient instead.
 func NewClient() *Client {
+	baseURL, err := ResolveBaseURL()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	token, err := ResolveToken()
+	if err != nil {
+		log.Fatalf("Security Error: %v", err)
+	}
+	return &Client{
+		BaseURL: baseURL,
+		Token:   token,
+		HTTP:    &http.Client{},
+	}
+}
+
+// LoadClient is NewClient returning configuration errors instead of exiting.
+func LoadClient() (*Client, error) {
+	baseURL, err := ResolveBaseURL()
+	if err != nil {
+		return nil, err
+	}
+	token, err := ResolveToken()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{BaseURL: baseURL, Token: token, HTTP: &http.Client{}}, nil
+}
+
+// ResolveBaseURL returns the CLI API URL from ENVAULT_CLI_URL or
+// ENVAULT_BASE_URL. Plain HTTP is refused unless
+// ENVAULT_ALLOW_INSECURE_HTTP=1.
+func ResolveBaseURL() (string, error) {
 	baseURL := os.Getenv("ENVAULT_CLI_URL")
 	if baseURL == "" {
 		if rootBase := strings.TrimSpace(os.Getenv("ENVAULT_BASE_URL")); rootBase != "" {
@@ -46,39 +94,48 @@ func NewClient() *Client {
 
 	u, err := url.Parse(baseURL)
 	if err != nil {
-		fmt.Printf("Error: Invalid API URL: %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("Invalid API URL: %v", err)
 	}
 
 	if u.Scheme != "https" && os.Getenv("ENVAULT_ALLOW_INSECURE_HTTP") != "1" {
-		fmt.Println("Error: Insecure connection (HTTP) is not allowed.")
-		fmt.Println("       Please use an HTTPS URL for ENVAULT_CLI_URL.")
-		os.Exit(1)
+		return "", errors.New("Insecure connection (HTTP) is not allowed.\n       Please use an HTTPS URL for ENVAULT_CLI_URL.")
 	}
+	return baseURL, nil
+}
 
-	// 1. Check for Service Tokens via Envar
+// ResolveToken returns the machine token from ENVAULT_TOKEN or
+// ENVAULT_SERVICE_TOKEN, or else the stored session token. Personal tokens
+// are never accepted from the environment.
+func ResolveToken() (string, error) {
 	token := os.Getenv("ENVAULT_TOKEN")
 	if token == "" {
 		token = os.Getenv("ENVAULT_SERVICE_TOKEN")
 	}
-
-	if token != "" {
-		if !strings.HasPrefix(token, "envault_svc_") && !strings.HasPrefix(token, "envault_agt_") {
-			log.Fatalf("Security Error: ENVAULT_TOKEN detected, but it is not a valid Service Token or Agent Token. Personal OAuth tokens cannot be used via environment variables.")
-		}
-	} else {
-		// Fallback to local session token
-		token = viper.GetString("auth.token")
+	if token == "" {
+		return credstore.AccessToken(), nil
 	}
-
-	return &Client{
-		BaseURL: baseURL,
-		Token:   token,
-		HTTP:    &http.Client{},
+	if !strings.HasPrefix(token, "envault_svc_") && !strings.HasPrefix(token, "envault_agt_") && !strings.HasPrefix(token, FederatedTokenPrefix) {
+		return "", errors.New("ENVAULT_TOKEN detected, but it is not a valid Service Token, Agent Token or federated CI token. Personal OAuth tokens cannot be used via environment variables.")
 	}
+	return token, nil
+}
+
+func (c *Client) currentToken() string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.Token
 }
 
-func (c *Client) refreshToken(httpClient *http.Client) error {
+// refreshToken exchanges the stored refresh token for a new access token.
+// stale is the token the failed request used; when another request already
+// replaced it, the refresh is skipped and the caller simply retries.
+func (c *Client) refreshToken(httpClient *http.Client, stale string) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.Token != stale {
+		return nil
+	}
+
 	if httpClient == nil {
 		httpClient = c.HTTP
 	}
@@ -86,7 +143,7 @@ func (c *Client) refreshToken(httpClient *http.Client) error {
 		httpClient = &http.Client{}
 	}
 
-	rt, err := keyring.Get("envault", "cli")
+	rt, err := credstore.Get(credstore.AccountRefreshToken)
 	if err != nil || rt == "" {
 		return fmt.Errorf("no refresh token found")
 	}
@@ -128,8 +185,7 @@ func (c *Client) refreshToken(httpClient *http.Client) error {
 
 	// Save new access token
 	c.Token = newToken
-	viper.Set("auth.token", newToken)
-	_ = viper.WriteConfig()
+	_ = credstore.SaveAccessToken(newToken)
 	return nil
 }
 
@@ -141,6 +197,10 @@ func (c *Client) Get(path string) ([]byte, error) {
 	return c.doReqWithHTTP("GET", path, nil, true, c.HTTP)
 }
 
+func (c *Client) Delete(path string) ([]byte, error) {
+	return c.doReqWithHTTP("DELETE", path, nil, true, c.HTTP)
+}
+
 func (c *Client) GetWithTimeout(path string, timeout time.Duration) ([]byte, error) {
 	if timeout <= 0 {
 		return c.Get(path)
@@ -177,6 +237,22 @@ func clientWithTimeout(base *http.Client, timeout time.Duration) *http.Client {
 	}
 }
 
+// AppBaseURL derives the web app's URL from the CLI API URL, which ends in
+// /api/cli.
+func AppBaseURL(apiURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api/cli")
+}
+
+// resolve joins path onto the CLI API URL. Paths under /api/ name routes
+// outside the CLI API, such as /api/approve/{id}, and resolve against the
+// app itself so they share the client's session and refresh handling.
+func (c *Client) resolve(path string) string {
+	if strings.HasPrefix(path, "/api/") {
+		return AppBaseURL(c.BaseURL) + path
+	}
+	return c.BaseURL + path
+}
+
 func (c *Client) doReqWithHTTP(method, path string, body interface{}, canRetry bool, httpClient *http.Client) ([]byte, error) {
 	return c.doReqCtx(context.Background(), method, path, body, canRetry, httpClient)
 }
@@ -199,14 +275,20 @@ func (c *Client) doReqCtx(ctx context.Context, method, path string, body interfa
 		bodyReader = bytes.NewBuffer(reqBody)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, c.resolve(path), bodyReader)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+	for name, values := range c.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	token := c.currentToken()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 	if actorSource := strings.TrimSpace(os.Getenv("ENVAULT_CLI_ACTOR_SOURCE")); actorSource != "" {
 		req.Header.Set("X-Envault-Actor-Source", actorSource)
@@ -219,9 +301,9 @@ func (c *Client) doReqCtx(ctx context.Context, method, path string, body interfa
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 401 && canRetry {
-		if c.Token != "" && !strings.HasPrefix(c.Token, "envault_svc_") {
+		if token != "" && !strings.HasPrefix(token, "envault_svc_") && !strings.HasPrefix(token, "envault_agt_") && !strings.HasPrefix(token, FederatedTokenPrefix) {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			errRefresh := c.refreshToken(httpClient)
+			errRefresh := c.refreshToken(httpClient, token)
 			if errRefresh == nil {
 				return c.doReqCtx(ctx, method, path, body, false, httpClient)
 			}
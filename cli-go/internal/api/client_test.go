@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -159,3 +161,103 @@ func TestGetWithContext_SuccessfulRequest(t *testing.T) {
 		t.Fatalf("unexpected body: %s", body)
 	}
 }
+
+func TestFederatedTokenIsAcceptedAndNotRefreshed(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer envault_oidc_ci" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	t.Setenv("ENVAULT_CLI_URL", srv.URL)
+	t.Setenv("ENVAULT_ALLOW_INSECURE_HTTP", "1")
+	t.Setenv("ENVAULT_TOKEN", "envault_oidc_ci")
+
+	client := NewClient()
+	if client.Token != "envault_oidc_ci" {
+		t.Fatalf("expected the federated token, got %q", client.Token)
+	}
+	var apiErr *APIError
+	if _, err := client.Get("/me"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the 401 without a refresh attempt, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected a single request, got %d", requests)
+	}
+}
+
+func TestResolveSendsAppRoutesToTheAppRoot(t *testing.T) {
+	client := &Client{BaseURL: "https://envault.tech/api/cli"}
+	if got := client.resolve("/projects"); got != "https://envault.tech/api/cli/projects" {
+		t.Fatalf("unexpected CLI API URL %q", got)
+	}
+	if got := client.resolve("/api/approve/a1"); got != "https://envault.tech/api/approve/a1" {
+		t.Fatalf("unexpected app URL %q", got)
+	}
+}
+
+func TestAgentTokenIsNotRefreshedAndHeadersAreSent(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Idempotency-Key") != "k1" {
+			t.Errorf("expected the extra header, got %q", r.Header.Get("Idempotency-Key"))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, Token: "envault_agt_x.y.z", Header: http.Header{"Idempotency-Key": {"k1"}}}
+	var apiErr *APIError
+	if _, err := client.Post("/secrets", map[string]string{}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the 401 without a refresh attempt, got %v", err)
+	}
+	if requests != 1 || client.Token != "envault_agt_x.y.z" {
+		t.Fatalf("expected a single request with the agent token kept, got %d requests and %q", requests, client.Token)
+	}
+}
+
+func TestConcurrentRequestsShareASingleRefresh(t *testing.T) {
+	var refreshes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/refresh" {
+			atomic.AddInt32(&refreshes, 1)
+			time.Sleep(20 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"access_token":"envault_at_new"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer envault_at_new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("ENVAULT_CREDENTIAL_STORE", "env")
+	t.Setenv("ENVAULT_REFRESH_TOKEN", "envault_rt_old")
+
+	client := &Client{BaseURL: srv.URL, Token: "envault_at_old"}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Get("/projects"); err != nil {
+				t.Errorf("request failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Fatalf("expected one refresh for concurrent 401s, got %d", n)
+	}
+	if client.Token != "envault_at_new" {
+		t.Fatalf("expected the refreshed token, got %q", client.Token)
+	}
+}
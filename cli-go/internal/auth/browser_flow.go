@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+	"github.com/pkg/browser"
+)
+
+// ErrBrowserUnavailable is returned by BrowserLogin when no loopback port or
+// browser could be used; the caller falls back to the device flow.
+var ErrBrowserUnavailable = errors.New("browser login unavailable")
+
+const (
+	browserLoginTimeout = 5 * time.Minute
+	callbackPath        = "/callback"
+
+	// browserLoginPage is served to the browser once the CLI has the code.
+	browserLoginPage = `<!doctype html><html><head><meta charset="utf-8"><title>Envault CLI</title></head>` +
+		`<body style="font-family:sans-serif;text-align:center;margin-top:4em"><h2>%s</h2><p>You can close this window and return to your terminal.</p></body></html>`
+)
+
+// openBrowser is replaced in tests.
+var openBrowser = browser.OpenURL
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// BrowserLogin signs in with the OAuth authorization code flow: it listens
+// on a random 127.0.0.1 port, opens the authorization page with a PKCE
+// challenge and state, and exchanges the code the browser brings back.
+func BrowserLogin() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("%w: cannot listen on 127.0.0.1: %v", ErrBrowserUnavailable, err)
+	}
+	defer listener.Close()
+
+	verifier, err := randomURLSafe(32)
+	if err != nil {
+		return err
+	}
+	state, err := randomURLSafe(16)
+	if err != nil {
+		return err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+
+	client := api.NewClient()
+	hostname, _ := os.Hostname()
+	query := url.Values{
+		"response_type":         {"code"},
+		"redirect_uri":          {redirectURI},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"state":                 {state},
+		"hostname":              {hostname},
+	}
+	authorizeURL := api.AppBaseURL(client.BaseURL) + "/auth/cli?" + query.Encode()
+
+	results := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(params.Get("state")), []byte(state)) != 1 {
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			return
+		}
+		result := callbackResult{code: params.Get("code")}
+		title := "Envault CLI is signed in"
+		switch {
+		case params.Get("error") == "access_denied":
+			result.err = fmt.Errorf("access denied by user")
+			title = "Sign-in denied"
+		case params.Get("error") != "":
+			result.err = fmt.Errorf("login failed: %s", params.Get("error"))
+			title = "Sign-in failed"
+		case result.code == "":
+			http.Error(w, "missing code", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, browserLoginPage, title)
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	if err := openBrowser(authorizeURL); err != nil {
+		return fmt.Errorf("%w: cannot open a browser: %v", ErrBrowserUnavailable, err)
+	}
+	fmt.Println(ui.ColorBlue("  Opened your browser to sign in."))
+	fmt.Printf("If it did not open, visit: %s\n", ui.ColorCyanUnderline(authorizeURL))
+
+	s := ui.NewLoader(ui.LoaderThemeAuth, "Handshake waiting for browser approval...")
+	s.Start()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-sigChan:
+		s.Stop()
+		return fmt.Errorf("login cancelled")
+	case <-time.After(browserLoginTimeout):
+		s.Stop()
+		return fmt.Errorf("timed out waiting for browser approval")
+	}
+	if result.err != nil {
+		s.Stop()
+		return result.err
+	}
+
+	respBytes, err := client.Post("/auth/token", map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          result.code,
+		"code_verifier": verifier,
+		"redirect_uri":  redirectURI,
+	})
+	s.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	var tokenResp TokenResponse
+	if err := json.Unmarshal(respBytes, &tokenResp); err != nil || tokenResp.AccessToken == "" {
+		return fmt.Errorf("invalid token response")
+	}
+
+	if err := saveSession(tokenResp.AccessToken, tokenResp.RefreshToken); err != nil {
+		return err
+	}
+	fmt.Println(ui.ColorGreen("[OK] Successfully authenticated! Token saved."))
+	if email, err := fetchEmail(tokenResp.AccessToken); err == nil && email != "" {
+		fmt.Printf("Logged in as: %s\n", ui.ColorBold(email))
+	}
+	return nil
+}
+
+func randomURLSafe(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
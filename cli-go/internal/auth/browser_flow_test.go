@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+)
+
+// approveInBrowser stands in for the user: it follows the authorization URL
+// back to the loopback callback, approving or denying the request.
+func approveInBrowser(t *testing.T, authorize *url.Values, callbackParams url.Values) func(string) error {
+	return func(rawURL string) error {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		*authorize = u.Query()
+		callbackParams.Set("state", authorize.Get("state"))
+		go func() {
+			resp, err := http.Get(authorize.Get("redirect_uri") + "?" + callbackParams.Encode())
+			if err != nil {
+				t.Errorf("callback request failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		return nil
+	}
+}
+
+func TestBrowserLoginExchangesCodeWithPKCE(t *testing.T) {
+	var authorize url.Values
+	var exchange map[string]string
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/token":
+			_ = json.NewDecoder(r.Body).Decode(&exchange)
+			_, _ = w.Write([]byte(`{"access_token":"envault_at_browser","refresh_token":"envault_rt_browser"}`))
+		case "/me":
+			_, _ = w.Write([]byte(`{"email":"dev@example.test"}`))
+		}
+	})
+	openBrowser = approveInBrowser(t, &authorize, url.Values{"code": {"auth-code"}})
+	t.Cleanup(func() { openBrowser = defaultOpenBrowser })
+
+	if err := BrowserLogin(); err != nil {
+		t.Fatalf("BrowserLogin failed: %v", err)
+	}
+
+	if !strings.HasPrefix(authorize.Get("redirect_uri"), "http://127.0.0.1:") || authorize.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request: %v", authorize)
+	}
+	sum := sha256.Sum256([]byte(exchange["code_verifier"]))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != authorize.Get("code_challenge") {
+		t.Fatal("code verifier does not match the challenge")
+	}
+	if exchange["code"] != "auth-code" || exchange["grant_type"] != "authorization_code" || exchange["redirect_uri"] != authorize.Get("redirect_uri") {
+		t.Fatalf("unexpected token exchange: %v", exchange)
+	}
+	if token, err := credstore.Get(credstore.AccountAccessToken); err != nil || token != "envault_at_browser" {
+		t.Fatalf("expected the access token to be stored, got %q, %v", token, err)
+	}
+}
+
+func TestBrowserLoginReportsDenial(t *testing.T) {
+	var authorize url.Values
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected API request to %s", r.URL.Path)
+	})
+	openBrowser = approveInBrowser(t, &authorize, url.Values{"error": {"access_denied"}})
+	t.Cleanup(func() { openBrowser = defaultOpenBrowser })
+
+	if err := BrowserLogin(); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("expected a denial, got %v", err)
+	}
+}
+
+func TestBrowserLoginWithoutBrowserIsUnavailable(t *testing.T) {
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {})
+	openBrowser = func(string) error { return errors.New("no display") }
+	t.Cleanup(func() { openBrowser = defaultOpenBrowser })
+
+	if err := BrowserLogin(); !errors.Is(err, ErrBrowserUnavailable) {
+		t.Fatalf("expected ErrBrowserUnavailable, got %v", err)
+	}
+}
+
+var defaultOpenBrowser = openBrowser
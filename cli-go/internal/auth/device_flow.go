@@ -2,25 +2,26 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"strings"
 	"time"
 
 	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
 	"github.com/DinanathDash/Envault/cli-go/internal/ui"
 	"github.com/atotto/clipboard"
 	"github.com/pkg/browser"
-	"github.com/spf13/viper"
-	"github.com/zalando/go-keyring"
 )
 
 type DeviceCodeResponse struct {
-	DeviceCode      string `json:"device_code"`
-	UserCode        string `json:"user_code"`
-	VerificationURI string `json:"verification_uri"`
-	Interval        int    `json:"interval"`
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
 }
 
 type TokenResponse struct {
@@ -34,7 +35,21 @@ type UserResponse struct {
 	Email string `json:"email"`
 }
 
-func Login() error {
+// LoginOptions adjusts the device flow.
+type LoginOptions struct {
+	// NoBrowser prints the verification URL instead of opening a browser or
+	// copying the code to the clipboard, for SSH sessions and remote VMs.
+	NoBrowser bool
+}
+
+// Poll timing; replaced in tests. slowDownStep is how much the interval grows
+// on each slow_down error, as RFC 8628 section 3.5 requires.
+var (
+	defaultPollInterval = 2 * time.Second
+	slowDownStep        = 5 * time.Second
+)
+
+func Login(opts LoginOptions) error {
 	client := api.NewClient()
 
 	fmt.Println(ui.ColorBlue("  Starting Device Authentication Flow...\n"))
@@ -68,25 +83,34 @@ func Login() error {
 	s.Stop()
 	fmt.Println(ui.ColorGreen("[OK] Device code generated."))
 
-	fmt.Printf("\nPlease visit: %s\n", ui.ColorCyanUnderline(codeResp.VerificationURI))
+	verificationURL := codeResp.VerificationURI
+	if codeResp.VerificationURIComplete != "" {
+		verificationURL = codeResp.VerificationURIComplete
+	}
+	fmt.Printf("\nPlease visit: %s\n", ui.ColorCyanUnderline(verificationURL))
 
 	// Box for User Code
 	boxContent := fmt.Sprintf("Authentication Code\n\n%s", ui.ColorGreenBold(codeResp.UserCode))
 	fmt.Println(ui.BoxStyle.Render(boxContent))
 
-	if err := clipboard.WriteAll(codeResp.UserCode); err == nil {
-		fmt.Println(ui.ColorDim("(Code copied to clipboard)"))
+	if !opts.NoBrowser {
+		if err := clipboard.WriteAll(codeResp.UserCode); err == nil {
+			fmt.Println(ui.ColorDim("(Code copied to clipboard)"))
+		}
+		_ = browser.OpenURL(verificationURL)
 	}
 
-	_ = browser.OpenURL(codeResp.VerificationURI)
-
 	// Poll Spinner
 	s = ui.NewLoader(ui.LoaderThemeAuth, "Handshake waiting for browser approval...")
 	s.Start()
 
 	interval := time.Duration(codeResp.Interval) * time.Second
 	if interval == 0 {
-		interval = 2 * time.Second
+		interval = defaultPollInterval
+	}
+	var deadline time.Time
+	if codeResp.ExpiresIn > 0 {
+		deadline = time.Now().Add(time.Duration(codeResp.ExpiresIn) * time.Second)
 	}
 
 	// Handle Ctrl+C
@@ -111,24 +135,43 @@ func Login() error {
 			// Continue polling
 		}
 
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			s.Stop()
+			return fmt.Errorf("code expired, please try again")
+		}
+
 		tokenBytes, err := client.Post("/auth/device/token", map[string]string{
 			"device_code": codeResp.DeviceCode,
 		})
 
 		if err != nil {
-			errStr := err.Error()
-			if strings.Contains(errStr, "authorization_pending") {
+			var apiErr *api.APIError
+			if !errors.As(err, &apiErr) {
+				// Network trouble: keep polling until the code expires.
 				continue
 			}
-			if strings.Contains(errStr, "access_denied") {
+			switch code := deviceFlowError(apiErr); code {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += slowDownStep
+				continue
+			case "access_denied":
 				s.Stop()
 				return fmt.Errorf("access denied by user")
-			}
-			if strings.Contains(errStr, "expired_token") {
+			case "expired_token":
 				s.Stop()
 				return fmt.Errorf("code expired, please try again")
+			default:
+				if apiErr.StatusCode == 429 || apiErr.StatusCode >= 500 {
+					continue
+				}
+				s.Stop()
+				if code == "" {
+					code = apiErr.Body
+				}
+				return fmt.Errorf("login failed: %s", code)
 			}
-			continue
 		}
 
 		var tokenResp TokenResponse
@@ -137,40 +180,12 @@ func Login() error {
 		}
 
 		if tokenResp.AccessToken != "" {
-			viper.Set("auth.token", tokenResp.AccessToken)
-			if err := viper.WriteConfig(); err != nil {
-				if err := viper.SafeWriteConfig(); err != nil {
-					s.Stop()
-					return fmt.Errorf("failed to save config: %w", err)
-				}
-			}
-
-			if tokenResp.RefreshToken != "" {
-				if err := keyring.Set("envault", "cli", tokenResp.RefreshToken); err != nil {
-					fmt.Println(ui.ColorYellow("\nWarning: Failed to save refresh token to system keyring. You may need to login again sooner."))
-				}
-			}
-
-			// Fetch User Info to show email
-			// Update client with new token first?
-			// The client struct reads from viper on NewClient, or we can update it manually.
-			// Let's just make a new client since token is now in viper?
-			// Wait, viper.Get reads from memory if set? Yes.
-			// But NewClient reads once.
-			// Re-instantiate client.
-			clientWithAuth := api.NewClient()
-			userBytes, err := clientWithAuth.Get("/me")
-			email := ""
-			if err == nil {
-				var userResp UserResponse
-				if err := json.Unmarshal(userBytes, &userResp); err == nil {
-					email = userResp.Email
-				}
-			}
-
 			s.Stop()
+			if err := saveSession(tokenResp.AccessToken, tokenResp.RefreshToken); err != nil {
+				return err
+			}
 			fmt.Println(ui.ColorGreen("[OK] Successfully authenticated! Token saved."))
-			if email != "" {
+			if email, err := fetchEmail(tokenResp.AccessToken); err == nil && email != "" {
 				fmt.Printf("Logged in as: %s\n", ui.ColorBold(email))
 			}
 			return nil
@@ -180,8 +195,66 @@ func Login() error {
 			if tokenResp.Error == "authorization_pending" {
 				continue
 			}
+			if tokenResp.Error == "slow_down" {
+				interval += slowDownStep
+				continue
+			}
 			s.Stop()
 			return fmt.Errorf("login failed: %s", tokenResp.Error)
 		}
 	}
 }
+
+// deviceFlowError extracts the OAuth error code from a failed poll, e.g.
+// {"error":"authorization_pending"}.
+func deviceFlowError(apiErr *api.APIError) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(apiErr.Body), &body); err != nil {
+		return ""
+	}
+	return body.Error
+}
+
+// saveSession stores the tokens of a new session. Failing to store the access
+// token is an error; a missing refresh token only shortens the session.
+func saveSession(accessToken, refreshToken string) error {
+	store, err := credstore.Default()
+	if err != nil {
+		return fmt.Errorf("credential store unavailable: %w", err)
+	}
+	if err := credstore.SaveAccessToken(accessToken); err != nil {
+		return fmt.Errorf("failed to save access token to %s: %w", store.Describe(), err)
+	}
+
+	if refreshToken != "" {
+		if err := credstore.Set(credstore.AccountRefreshToken, refreshToken); err != nil {
+			fmt.Println(ui.ColorYellow(fmt.Sprintf("\nWarning: Failed to save refresh token to %s. You may need to login again sooner.", store.Describe())))
+		}
+	} else if err := credstore.Delete(credstore.AccountRefreshToken); err != nil {
+		// A refresh token left over from another account would silently
+		// switch identities on the next refresh.
+		return fmt.Errorf("failed to remove the previous refresh token from %s: %w", store.Describe(), err)
+	}
+	if !store.Persistent() {
+		fmt.Println(ui.ColorYellow(fmt.Sprintf("\nWarning: The %s credential store does not persist, so this session ends with this process. Use an OS keyring, or set ENVAULT_PASSPHRASE or ENVAULT_KEY_FILE to keep it in an encrypted file.", store.Name())))
+	}
+	return nil
+}
+
+// fetchEmail returns the email of the account owning the access token. The
+// token is set explicitly so a service token in ENVAULT_TOKEN is not used.
+func fetchEmail(accessToken string) (string, error) {
+	client := api.NewClient()
+	client.Token = accessToken
+	userBytes, err := client.Get("/me")
+	if err != nil {
+		return "", err
+	}
+	var userResp UserResponse
+	if err := json.Unmarshal(userBytes, &userResp); err != nil {
+		return "", err
+	}
+	return userResp.Email, nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+)
+
+func setupDeviceFlow(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("ENVAULT_CLI_URL", srv.URL)
+	t.Setenv("ENVAULT_ALLOW_INSECURE_HTTP", "1")
+	t.Setenv("ENVAULT_TOKEN", "")
+	t.Setenv("ENVAULT_SERVICE_TOKEN", "")
+	t.Setenv("ENVAULT_CREDENTIAL_STORE", "env")
+
+	interval, step := defaultPollInterval, slowDownStep
+	defaultPollInterval, slowDownStep = 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { defaultPollInterval, slowDownStep = interval, step })
+}
+
+func TestLoginHonoursSlowDownAndCompleteURI(t *testing.T) {
+	var polls int32
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/device/code":
+			_, _ = w.Write([]byte(`{"device_code":"dc","user_code":"ABCD-EFGH","verification_uri":"https://example.test/device","verification_uri_complete":"https://example.test/device?code=ABCD-EFGH","expires_in":60}`))
+		case "/auth/device/token":
+			switch atomic.AddInt32(&polls, 1) {
+			case 1:
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"slow_down"}`))
+			case 2:
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+			default:
+				_, _ = w.Write([]byte(`{"access_token":"envault_at_new","refresh_token":"envault_rt_new"}`))
+			}
+		case "/me":
+			_, _ = w.Write([]byte(`{"email":"dev@example.test"}`))
+		}
+	})
+
+	if err := Login(LoginOptions{NoBrowser: true}); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if polls != 3 {
+		t.Fatalf("expected 3 polls, got %d", polls)
+	}
+	if token, err := credstore.Get(credstore.AccountAccessToken); err != nil || token != "envault_at_new" {
+		t.Fatalf("expected the access token to be stored, got %q, %v", token, err)
+	}
+}
+
+func TestLoginStopsPollingWhenCodeExpires(t *testing.T) {
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/auth/device/code" {
+			_, _ = w.Write([]byte(`{"device_code":"dc","user_code":"ABCD-EFGH","verification_uri":"https://example.test/device","expires_in":1}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+	})
+
+	start := time.Now()
+	err := Login(LoginOptions{NoBrowser: true})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected an expiry error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected polling to stop at expires_in, took %s", elapsed)
+	}
+}
+
+func TestSaveSessionWithoutRefreshTokenDropsThePreviousOne(t *testing.T) {
+	t.Setenv("ENVAULT_CREDENTIAL_STORE", "env")
+	t.Setenv("ENVAULT_REFRESH_TOKEN", "envault_rt_previous_account")
+
+	if err := saveSession("envault_at_new", ""); err != nil {
+		t.Fatalf("saveSession: %v", err)
+	}
+	if rt, err := credstore.Get(credstore.AccountRefreshToken); err == nil {
+		t.Fatalf("expected the previous refresh token to be removed, got %q", rt)
+	}
+}
+
+func TestParseTokenInput(t *testing.T) {
+	cases := []struct {
+		name, input, access, refresh string
+		wantErr                      bool
+	}{
+		{name: "pair", input: "envault_at_a\nenvault_rt_b\n", access: "envault_at_a", refresh: "envault_rt_b"},
+		{name: "reversed", input: "envault_rt_b envault_at_a", access: "envault_at_a", refresh: "envault_rt_b"},
+		{name: "access only", input: "envault_at_a", access: "envault_at_a"},
+		{name: "json", input: `{"access_token":"envault_at_a","refresh_token":"envault_rt_b"}`, access: "envault_at_a", refresh: "envault_rt_b"},
+		{name: "refresh only", input: "envault_rt_b", wantErr: true},
+		{name: "service token", input: "envault_svc_x", wantErr: true},
+		{name: "empty", input: "  \n", wantErr: true},
+	}
+	for _, tc := range cases {
+		access, refresh, err := parseTokenInput(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil || access != tc.access || refresh != tc.refresh {
+			t.Errorf("%s: got %q, %q, %v", tc.name, access, refresh, err)
+		}
+	}
+}
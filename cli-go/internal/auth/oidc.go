@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+)
+
+// DefaultOIDCAudience is the audience requested from GitHub Actions and
+// expected by the token exchange.
+const DefaultOIDCAudience = "envault"
+
+const (
+	oidcTokenFileEnvVar = "ENVAULT_OIDC_TOKEN_FILE"
+	jwtTokenType        = "urn:ietf:params:oauth:token-type:jwt"
+	oidcRequestTimeout  = 30 * time.Second
+)
+
+// OIDCOptions selects where the CI identity token comes from and what the
+// exchanged token is scoped to.
+type OIDCOptions struct {
+	// TokenFile reads the identity token from a file, e.g. a Kubernetes
+	// projected service account token. ENVAULT_OIDC_TOKEN_FILE is the
+	// fallback.
+	TokenFile   string
+	Audience    string
+	ProjectID   string
+	Environment string
+}
+
+// FederatedToken is a short-lived Envault token issued for a CI job.
+type FederatedToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	ProjectID   string `json:"project_id"`
+	Environment string `json:"environment"`
+}
+
+// OIDCLogin reads the CI provider's identity token and exchanges it for a
+// federated Envault token. It returns the token and the provider it used.
+func OIDCLogin(opts OIDCOptions) (FederatedToken, string, error) {
+	idToken, provider, err := readOIDCToken(opts)
+	if err != nil {
+		return FederatedToken{}, "", err
+	}
+
+	client := api.NewClient()
+	// The identity token is the only credential; never send a stored session
+	// or an older ENVAULT_TOKEN along with it.
+	client.Token = ""
+	payload := map[string]string{
+		"subject_token":      idToken,
+		"subject_token_type": jwtTokenType,
+		"provider":           provider,
+	}
+	if opts.ProjectID != "" {
+		payload["project_id"] = opts.ProjectID
+	}
+	if opts.Environment != "" {
+		payload["environment"] = opts.Environment
+	}
+
+	respBytes, err := client.Post("/auth/oidc/exchange", payload)
+	if err != nil {
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) {
+			return FederatedToken{}, provider, fmt.Errorf("token exchange refused (%d): %s", apiErr.StatusCode, strings.TrimSpace(apiErr.Body))
+		}
+		return FederatedToken{}, provider, fmt.Errorf("token exchange failed: %w", err)
+	}
+
+	var token FederatedToken
+	if err := json.Unmarshal(respBytes, &token); err != nil {
+		return FederatedToken{}, provider, fmt.Errorf("failed to parse token exchange response: %w", err)
+	}
+	if !strings.HasPrefix(token.AccessToken, api.FederatedTokenPrefix) {
+		return FederatedToken{}, provider, fmt.Errorf("token exchange returned an unexpected token type")
+	}
+	return token, provider, nil
+}
+
+// readOIDCToken finds the identity token: an explicit file first, then
+// GitHub Actions, then GitLab CI.
+func readOIDCToken(opts OIDCOptions) (string, string, error) {
+	path := opts.TokenFile
+	if path == "" {
+		path = os.Getenv(oidcTokenFileEnvVar)
+	}
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read OIDC token file: %w", err)
+		}
+		token := strings.TrimSpace(string(raw))
+		if token == "" {
+			return "", "", fmt.Errorf("OIDC token file %s is empty", path)
+		}
+		return token, "file", nil
+	}
+
+	if requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"); requestURL != "" {
+		audience := opts.Audience
+		if audience == "" {
+			audience = DefaultOIDCAudience
+		}
+		token, err := requestGitHubOIDCToken(requestURL, os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"), audience)
+		return token, "github", err
+	}
+
+	for _, name := range []string{"CI_JOB_JWT_V2", "CI_JOB_JWT"} {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token, "gitlab", nil
+		}
+	}
+
+	return "", "", errors.New("no OIDC token found: run in GitHub Actions with `permissions: id-token: write`, in GitLab CI with CI_JOB_JWT, or pass --oidc-token-file")
+}
+
+// requestGitHubOIDCToken asks the GitHub Actions runtime for an identity
+// token with the given audience.
+func requestGitHubOIDCToken(requestURL, requestToken, audience string) (string, error) {
+	if requestToken == "" {
+		return "", errors.New("ACTIONS_ID_TOKEN_REQUEST_TOKEN is not set; grant the job `permissions: id-token: write`")
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("audience", audience)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := (&http.Client{Timeout: oidcRequestTimeout}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request GitHub OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("GitHub OIDC token request failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var parsed struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil || parsed.Value == "" {
+		return "", errors.New("GitHub OIDC token response has no token")
+	}
+	return parsed.Value, nil
+}
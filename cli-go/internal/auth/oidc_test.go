@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func clearOIDCEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{oidcTokenFileEnvVar, "ACTIONS_ID_TOKEN_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_TOKEN", "CI_JOB_JWT_V2", "CI_JOB_JWT"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestOIDCLoginExchangesGitHubToken(t *testing.T) {
+	clearOIDCEnv(t)
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "bearer runtime-token" || r.URL.Query().Get("audience") != "envault" || r.URL.Query().Get("api-version") != "2.0" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"value":"github-jwt"}`))
+	}))
+	defer github.Close()
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", github.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "runtime-token")
+
+	var exchange map[string]string
+	var authHeader string
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/oidc/exchange" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		authHeader = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&exchange)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"envault_oidc_ci","expires_in":900,"project_id":"p1"}`))
+	})
+
+	token, provider, err := OIDCLogin(OIDCOptions{Audience: DefaultOIDCAudience, ProjectID: "p1", Environment: "ci"})
+	if err != nil {
+		t.Fatalf("OIDCLogin failed: %v", err)
+	}
+	if provider != "github" || token.AccessToken != "envault_oidc_ci" || token.ExpiresIn != 900 {
+		t.Fatalf("unexpected result: %s %+v", provider, token)
+	}
+	if exchange["subject_token"] != "github-jwt" || exchange["subject_token_type"] != jwtTokenType || exchange["project_id"] != "p1" || exchange["environment"] != "ci" {
+		t.Fatalf("unexpected exchange request: %v", exchange)
+	}
+	if authHeader != "" {
+		t.Fatalf("expected no bearer token on the exchange, got %q", authHeader)
+	}
+}
+
+func TestReadOIDCTokenSources(t *testing.T) {
+	clearOIDCEnv(t)
+	if _, _, err := readOIDCToken(OIDCOptions{}); err == nil || !strings.Contains(err.Error(), "no OIDC token") {
+		t.Fatalf("expected a missing token error, got %v", err)
+	}
+
+	t.Setenv("CI_JOB_JWT", "gitlab-jwt")
+	if token, provider, err := readOIDCToken(OIDCOptions{}); err != nil || token != "gitlab-jwt" || provider != "gitlab" {
+		t.Fatalf("expected the GitLab token, got %q %q %v", token, provider, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "token")
+	_ = os.WriteFile(path, []byte("file-jwt\n"), 0600)
+	if token, provider, err := readOIDCToken(OIDCOptions{TokenFile: path}); err != nil || token != "file-jwt" || provider != "file" {
+		t.Fatalf("expected the file token to win, got %q %q %v", token, provider, err)
+	}
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "https://example.test/token")
+	if _, _, err := readOIDCToken(OIDCOptions{}); err == nil || !strings.Contains(err.Error(), "id-token: write") {
+		t.Fatalf("expected a permissions hint, got %v", err)
+	}
+}
+
+func TestOIDCLoginRejectsUnexpectedTokenType(t *testing.T) {
+	clearOIDCEnv(t)
+	t.Setenv("CI_JOB_JWT_V2", "gitlab-jwt")
+	setupDeviceFlow(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"envault_at_personal"}`))
+	})
+	if _, _, err := OIDCLogin(OIDCOptions{}); err == nil {
+		t.Fatal("expected a personal token to be refused")
+	}
+}
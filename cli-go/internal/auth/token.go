@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/api"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+	"github.com/DinanathDash/Envault/cli-go/internal/ui"
+)
+
+const (
+	accessTokenPrefix  = "envault_at_"
+	refreshTokenPrefix = "envault_rt_"
+
+	// maxTokenInput bounds what LoginWithToken reads, so piping the wrong
+	// file in fails fast.
+	maxTokenInput = 64 << 10
+)
+
+// LoginWithToken stores a session read from r instead of running the device
+// flow. The input is either the JSON a device flow returns, with
+// access_token and refresh_token fields, or the tokens separated by
+// whitespace. The refresh token is optional. The access token is checked
+// against the server before the command reports success.
+func LoginWithToken(r io.Reader) error {
+	raw, err := io.ReadAll(io.LimitReader(r, maxTokenInput+1))
+	if err != nil {
+		return fmt.Errorf("failed to read tokens: %w", err)
+	}
+	if len(raw) > maxTokenInput {
+		return errors.New("token input is too large")
+	}
+	accessToken, refreshToken, err := parseTokenInput(string(raw))
+	if err != nil {
+		return err
+	}
+
+	if err := saveSession(accessToken, refreshToken); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		fmt.Println(ui.ColorYellow("Warning: No refresh token given, so this session ends when the access token expires."))
+	}
+
+	email, err := fetchEmail(accessToken)
+	if err != nil {
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == 401 || apiErr.StatusCode == 403) {
+			_, _ = credstore.RemoveSession()
+			return errors.New("the server rejected the access token")
+		}
+		fmt.Println(ui.ColorYellow(fmt.Sprintf("Warning: Could not verify the token with the server: %v", err)))
+	}
+
+	fmt.Println(ui.ColorGreen("[OK] Token saved."))
+	if email != "" {
+		fmt.Printf("Logged in as: %s\n", ui.ColorBold(email))
+	}
+	return nil
+}
+
+// parseTokenInput returns the access and refresh tokens from JSON or
+// whitespace-separated input. Tokens are recognized by prefix, so their
+// order does not matter.
+func parseTokenInput(input string) (string, string, error) {
+	input = strings.TrimSpace(input)
+	var tokens []string
+	if strings.HasPrefix(input, "{") {
+		var resp TokenResponse
+		if err := json.Unmarshal([]byte(input), &resp); err != nil {
+			return "", "", fmt.Errorf("failed to parse token JSON: %w", err)
+		}
+		tokens = []string{resp.AccessToken, resp.RefreshToken}
+	} else {
+		tokens = strings.Fields(input)
+	}
+
+	var accessToken, refreshToken string
+	for _, token := range tokens {
+		switch {
+		case token == "":
+		case strings.HasPrefix(token, accessTokenPrefix) && accessToken == "":
+			accessToken = token
+		case strings.HasPrefix(token, refreshTokenPrefix) && refreshToken == "":
+			refreshToken = token
+		default:
+			return "", "", fmt.Errorf("unexpected token input: expected an %s access token and an optional %s refresh token", accessTokenPrefix, refreshTokenPrefix)
+		}
+	}
+	if accessToken == "" {
+		return "", "", fmt.Errorf("no access token found on stdin: expected a token starting with %s", accessTokenPrefix)
+	}
+	return accessToken, refreshToken, nil
+}
@@ -0,0 +1,177 @@
+// Package bundle reads and writes portable secret bundles for machines with
+// no network access. A bundle holds one environment's secrets and metadata,
+// signed with the creator's Ed25519 key and then encrypted with age to X25519
+// recipients or a passphrase.
+package bundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+const (
+	payloadVersion = 1
+	// signatureContext separates bundle signatures from any other use of the
+	// signing key.
+	signatureContext = "envault-bundle/v1\x00"
+	signerPrefix     = "envault-signer:"
+)
+
+// ErrExpired is returned by Payload.CheckExpiry for a bundle past its expiry.
+var ErrExpired = errors.New("bundle has expired")
+
+// Payload is the signed content of a bundle.
+type Payload struct {
+	Version     int                   `json:"version"`
+	ProjectID   string                `json:"projectId"`
+	Environment string                `json:"environment"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	ExpiresAt   time.Time             `json:"expiresAt"`
+	Secrets     []offlinecache.Secret `json:"secrets"`
+}
+
+// CheckExpiry returns ErrExpired once now is past the bundle's expiry.
+func (p Payload) CheckExpiry(now time.Time) error {
+	if now.After(p.ExpiresAt) {
+		return fmt.Errorf("%w: it expired at %s", ErrExpired, p.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// signedDocument is the plaintext inside the age encryption.
+type signedDocument struct {
+	Payload   []byte `json:"payload"`
+	Signer    string `json:"signer"`
+	Signature []byte `json:"signature"`
+}
+
+// Seal signs the payload with key and encrypts it to the recipients.
+func Seal(p Payload, key ed25519.PrivateKey, recipients []age.Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("a bundle needs at least one recipient or a passphrase")
+	}
+	p.Version = payloadVersion
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	doc, err := json.Marshal(signedDocument{
+		Payload:   payload,
+		Signer:    FormatSigner(key.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(key, append([]byte(signatureContext), payload...)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if _, err := w.Write(doc); err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Open decrypts a bundle and verifies its signature. It returns the payload
+// and the signer in FormatSigner form; pinning the signer and enforcing the
+// expiry are left to the caller.
+func Open(data []byte, identities []age.Identity) (Payload, string, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return Payload{}, "", fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return Payload{}, "", fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+
+	var doc signedDocument
+	if err := json.Unmarshal(plaintext, &doc); err != nil {
+		return Payload{}, "", fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	signer, err := ParseSigner(doc.Signer)
+	if err != nil {
+		return Payload{}, "", err
+	}
+	if !ed25519.Verify(signer, append([]byte(signatureContext), doc.Payload...), doc.Signature) {
+		return Payload{}, "", errors.New("bundle signature is invalid")
+	}
+
+	var p Payload
+	if err := json.Unmarshal(doc.Payload, &p); err != nil {
+		return Payload{}, "", fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if p.Version != payloadVersion {
+		return Payload{}, "", fmt.Errorf("unsupported bundle version: %d", p.Version)
+	}
+	if p.ProjectID == "" || p.Environment == "" || p.ExpiresAt.IsZero() {
+		return Payload{}, "", errors.New("bundle is missing its project, environment or expiry")
+	}
+	return p, doc.Signer, nil
+}
+
+// FormatSigner encodes a signing public key for display and pinning.
+func FormatSigner(pub ed25519.PublicKey) string {
+	return signerPrefix + base64.RawURLEncoding.EncodeToString(pub)
+}
+
+// ParseSigner decodes a key produced by FormatSigner.
+func ParseSigner(s string) (ed25519.PublicKey, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(s), signerPrefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid bundle signer %q: expected %s<key>", s, signerPrefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid bundle signer %q", s)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParseRecipients parses age X25519 recipients (age1...).
+func ParseRecipients(specs []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(specs))
+	for _, spec := range specs {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", spec, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// ParseIdentities parses an age identity file (AGE-SECRET-KEY-1... lines).
+func ParseIdentities(r io.Reader) ([]age.Identity, error) {
+	identities, err := age.ParseIdentities(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity file: %w", err)
+	}
+	return identities, nil
+}
+
+// PassphraseRecipient encrypts a bundle with a passphrase instead of keys.
+func PassphraseRecipient(passphrase string) (age.Recipient, error) {
+	return age.NewScryptRecipient(passphrase)
+}
+
+// PassphraseIdentity decrypts a bundle created with PassphraseRecipient.
+func PassphraseIdentity(passphrase string) (age.Identity, error) {
+	return age.NewScryptIdentity(passphrase)
+}
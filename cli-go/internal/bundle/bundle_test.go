@@ -0,0 +1,158 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+	"github.com/DinanathDash/Envault/cli-go/internal/offlinecache"
+)
+
+func testPayload() Payload {
+	now := time.Now().UTC().Truncate(time.Second)
+	return Payload{
+		ProjectID:   "11111111-1111-4111-8111-111111111111",
+		Environment: "staging",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+		Secrets:     []offlinecache.Secret{{Key: "API_KEY", Value: "sk_live_bundle"}},
+	}
+}
+
+func TestSealAndOpenWithRecipient(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	recipients, err := ParseRecipients([]string{identity.Recipient().String()})
+	if err != nil {
+		t.Fatalf("ParseRecipients failed: %v", err)
+	}
+
+	data, err := Seal(testPayload(), key, recipients)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if bytes.Contains(data, []byte("sk_live_bundle")) || bytes.Contains(data, []byte("staging")) {
+		t.Fatal("bundle contains plaintext")
+	}
+
+	payload, signer, err := Open(data, []age.Identity{identity})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if signer != FormatSigner(key.Public().(ed25519.PublicKey)) {
+		t.Fatalf("unexpected signer %s", signer)
+	}
+	if payload.Environment != "staging" || len(payload.Secrets) != 1 || payload.Secrets[0].Value != "sk_live_bundle" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	if _, _, err := Open(data, []age.Identity{other}); err == nil {
+		t.Fatal("expected a foreign identity to fail")
+	}
+}
+
+func TestOpenRejectsTamperedPayload(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+
+	// Re-encrypt a validly signed document with a swapped payload, as anyone
+	// holding the recipient's public key could.
+	payload, _ := json.Marshal(testPayload())
+	forged := bytes.Replace(payload, []byte("sk_live_bundle"), []byte("sk_live_forged"), 1)
+	doc, _ := json.Marshal(signedDocument{
+		Payload:   forged,
+		Signer:    FormatSigner(key.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(key, append([]byte(signatureContext), payload...)),
+	})
+	var out bytes.Buffer
+	w, _ := age.Encrypt(&out, identity.Recipient())
+	_, _ = w.Write(doc)
+	_ = w.Close()
+
+	if _, _, err := Open(out.Bytes(), []age.Identity{identity}); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Fatalf("expected a signature error, got %v", err)
+	}
+}
+
+func TestSealAndOpenWithPassphrase(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	recipient, err := age.NewScryptRecipient("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient.SetWorkFactor(10)
+
+	data, err := Seal(testPayload(), key, []age.Recipient{recipient})
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	right, _ := PassphraseIdentity("correct horse")
+	if _, _, err := Open(data, []age.Identity{right}); err != nil {
+		t.Fatalf("Open with the passphrase failed: %v", err)
+	}
+	wrong, _ := PassphraseIdentity("wrong horse")
+	if _, _, err := Open(data, []age.Identity{wrong}); err == nil {
+		t.Fatal("expected the wrong passphrase to fail")
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	p := testPayload()
+	if err := p.CheckExpiry(p.CreatedAt); err != nil {
+		t.Fatalf("expected a fresh bundle to be valid, got %v", err)
+	}
+	if err := p.CheckExpiry(p.ExpiresAt.Add(time.Second)); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestParseSigner(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	parsed, err := ParseSigner(FormatSigner(pub))
+	if err != nil || !parsed.Equal(pub) {
+		t.Fatalf("expected the key back, got %v, %v", parsed, err)
+	}
+	for _, bad := range []string{"", "age1abc", "envault-signer:short"} {
+		if _, err := ParseSigner(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSigningKeyIsCreatedOnce(t *testing.T) {
+	defaultGet, defaultSet := credentialGet, credentialSet
+	t.Cleanup(func() {
+		credentialGet, credentialSet = defaultGet, defaultSet
+	})
+
+	stored := ""
+	credentialGet = func() (string, error) {
+		if stored == "" {
+			return "", credstore.ErrNotFound
+		}
+		return stored, nil
+	}
+	credentialSet = func(value string) error {
+		stored = value
+		return nil
+	}
+	first, err := SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey failed: %v", err)
+	}
+	second, err := SigningKey()
+	if err != nil || !first.Equal(second) {
+		t.Fatalf("expected the stored key to be reused, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
+)
+
+var (
+	credentialGet = func() (string, error) { return credstore.Get(credstore.AccountBundleKey) }
+	credentialSet = func(value string) error { return credstore.Set(credstore.AccountBundleKey, value) }
+)
+
+// SigningKey returns this machine's bundle signing key from the credential
+// store, creating it on first use.
+func SigningKey() (ed25519.PrivateKey, error) {
+	encoded, err := credentialGet()
+	if err == nil && encoded != "" {
+		seed, decodeErr := base64.StdEncoding.DecodeString(encoded)
+		if decodeErr != nil || len(seed) != ed25519.SeedSize {
+			return nil, errors.New("invalid bundle signing key in credential store")
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if err != nil && !errors.Is(err, credstore.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read bundle signing key: %w", err)
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to generate bundle signing key: %w", err)
+	}
+	if err := credentialSet(base64.StdEncoding.EncodeToString(seed)); err != nil {
+		return nil, fmt.Errorf("failed to store bundle signing key: %w", err)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,79 @@
+package credstore
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// envVarNames maps accounts to the variables the env backend reads.
+var envVarNames = map[string]string{
+	AccountAccessToken:  "ENVAULT_ACCESS_TOKEN",
+	AccountRefreshToken: "ENVAULT_REFRESH_TOKEN",
+	AccountCacheKey:     "ENVAULT_CACHE_KEY",
+	AccountBundleKey:    "ENVAULT_BUNDLE_SIGNING_KEY",
+}
+
+// envStore reads credentials from environment variables, for ephemeral CI
+// jobs. Values set during the run are kept in memory and lost at exit.
+type envStore struct {
+	mu     sync.Mutex
+	values map[string]string
+	// readEnv is false for the memory-only fallback "auto" ends up with.
+	readEnv bool
+}
+
+func newEnvStore() *envStore { return &envStore{values: map[string]string{}, readEnv: true} }
+
+// newMemoryStore is an envStore that ignores the environment.
+func newMemoryStore() *envStore { return &envStore{values: map[string]string{}} }
+
+func (*envStore) Name() string { return BackendEnv }
+func (s *envStore) Describe() string {
+	if !s.readEnv {
+		return "process memory; ENVAULT_ACCESS_TOKEN and the like are only read with ENVAULT_CREDENTIAL_STORE=env; not persisted"
+	}
+	return "environment variables (ENVAULT_ACCESS_TOKEN, ENVAULT_REFRESH_TOKEN, ENVAULT_CACHE_KEY); not persisted"
+}
+func (*envStore) Persistent() bool { return false }
+
+func (s *envStore) Get(account string) (string, error) {
+	s.mu.Lock()
+	value, ok := s.values[account]
+	s.mu.Unlock()
+	if ok {
+		if value == "" {
+			return "", ErrNotFound
+		}
+		return value, nil
+	}
+	if !s.readEnv {
+		return "", ErrNotFound
+	}
+	if value := strings.TrimSpace(os.Getenv(envVarName(account))); value != "" {
+		return value, nil
+	}
+	return "", ErrNotFound
+}
+
+func (s *envStore) Set(account, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[account] = value
+	return nil
+}
+
+func (s *envStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// An empty value masks the variable for the rest of the process.
+	s.values[account] = ""
+	return nil
+}
+
+func envVarName(account string) string {
+	if name, ok := envVarNames[account]; ok {
+		return name
+	}
+	return "ENVAULT_" + strings.ToUpper(strings.ReplaceAll(account, "-", "_"))
+}
@@ -0,0 +1,329 @@
+package credstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/filelock"
+	"github.com/spf13/viper"
+)
+
+const (
+	credentialsFileName = "credentials.enc"
+	credentialsLockName = "credentials.lock"
+	credentialsVersion  = 1
+	credentialsAAD      = "envault-credentials/v1"
+
+	kdfPassphrase    = "pbkdf2-sha256"
+	kdfKeyFile       = "hkdf-sha256"
+	pbkdf2Iterations = 600000
+	saltSize         = 16
+	fileKeySize      = 32
+)
+
+var userHomeDir = os.UserHomeDir
+
+// fileEnvelope is the on-disk format of credentials.enc. The plaintext is a
+// JSON object of account names to values.
+type fileEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// unlockMaterial is the passphrase or key file contents the file key is
+// derived from.
+type unlockMaterial struct {
+	kdf    string
+	secret []byte
+	origin string
+}
+
+// unlockSecret returns ENVAULT_PASSPHRASE, or else the contents of the key
+// file named by ENVAULT_KEY_FILE or credentials.key_file in config.toml.
+func unlockSecret() (unlockMaterial, error) {
+	if passphrase := os.Getenv(passphraseEnvVar); passphrase != "" {
+		return unlockMaterial{kdf: kdfPassphrase, secret: []byte(passphrase), origin: passphraseEnvVar}, nil
+	}
+
+	path, origin := strings.TrimSpace(os.Getenv(keyFileEnvVar)), keyFileEnvVar
+	if path == "" {
+		path, origin = strings.TrimSpace(viper.GetString("credentials.key_file")), "credentials.key_file"
+	}
+	if path == "" {
+		return unlockMaterial{}, fmt.Errorf("set %s or %s to unlock the credentials file", passphraseEnvVar, keyFileEnvVar)
+	}
+	secret, err := os.ReadFile(path)
+	if err != nil {
+		return unlockMaterial{}, fmt.Errorf("failed to read key file from %s: %w", origin, err)
+	}
+	secret = []byte(strings.TrimSpace(string(secret)))
+	if len(secret) == 0 {
+		return unlockMaterial{}, fmt.Errorf("key file from %s is empty", origin)
+	}
+	return unlockMaterial{kdf: kdfKeyFile, secret: secret, origin: origin + " (" + path + ")"}, nil
+}
+
+// fileStore keeps credentials in ~/.envault/credentials.enc, encrypted with
+// AES-256-GCM under a key derived from a passphrase or key file.
+type fileStore struct {
+	path     string
+	lockPath string
+	unlock   unlockMaterial
+
+	mu   sync.Mutex
+	keys map[string][]byte // derived keys by salt
+}
+
+func newFileStore() (*fileStore, error) {
+	unlock, err := unlockSecret()
+	if err != nil {
+		return nil, err
+	}
+	home, err := userHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	dir := filepath.Join(home, ".envault")
+	return &fileStore{
+		path:     filepath.Join(dir, credentialsFileName),
+		lockPath: filepath.Join(dir, credentialsLockName),
+		unlock:   unlock,
+		keys:     map[string][]byte{},
+	}, nil
+}
+
+func (*fileStore) Name() string { return BackendFile }
+func (s *fileStore) Describe() string {
+	return fmt.Sprintf("encrypted file %s, unlocked by %s", s.path, s.unlock.origin)
+}
+func (*fileStore) Persistent() bool { return true }
+
+func (s *fileStore) Get(account string) (string, error) {
+	values, _, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *fileStore) Set(account, value string) error {
+	return s.update(func(values map[string]string) bool {
+		values[account] = value
+		return true
+	})
+}
+
+func (s *fileStore) Delete(account string) error {
+	found := false
+	err := s.update(func(values map[string]string) bool {
+		_, found = values[account]
+		delete(values, account)
+		return found
+	})
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+// update applies change under the file lock and rewrites the file when
+// change reports a modification.
+func (s *fileStore) update(change func(map[string]string) bool) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	unlock, err := filelock.Lock(s.lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to lock credentials file: %w", err)
+	}
+	defer unlock()
+
+	values, salt, err := s.read()
+	if err != nil {
+		return err
+	}
+	if !change(values) {
+		return nil
+	}
+	return s.write(values, salt)
+}
+
+// read returns the stored values and the salt in use, or an empty map and a
+// nil salt when the file does not exist yet.
+func (s *fileStore) read() (map[string]string, []byte, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var env fileEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if env.Version != credentialsVersion {
+		return nil, nil, fmt.Errorf("unsupported credentials file version: %d", env.Version)
+	}
+	if env.KDF != s.unlock.kdf {
+		return nil, nil, fmt.Errorf("credentials file %s was created with a %s; %s is set instead", s.path, kdfLabel(env.KDF), kdfLabel(s.unlock.kdf))
+	}
+	salt, err := base64.StdEncoding.DecodeString(env.Salt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid credentials file salt: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid credentials file nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid credentials file ciphertext: %w", err)
+	}
+
+	gcm, err := s.cipher(salt, env.Iterations)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, nil, fmt.Errorf("invalid credentials file nonce size")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(credentialsAAD))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt credentials file: wrong %s", kdfLabel(s.unlock.kdf))
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	return values, salt, nil
+}
+
+func (s *fileStore) write(values map[string]string, salt []byte) error {
+	if salt == nil {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	iterations := 0
+	if s.unlock.kdf == kdfPassphrase {
+		iterations = pbkdf2Iterations
+	}
+	gcm, err := s.cipher(salt, iterations)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	env := fileEnvelope{
+		Version:    credentialsVersion,
+		KDF:        s.unlock.kdf,
+		Iterations: iterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, []byte(credentialsAAD))),
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials file: %w", err)
+	}
+	return writeFileAtomic(s.path, raw)
+}
+
+// cipher derives the file key for salt, caching it because PBKDF2 is slow
+// by design.
+func (s *fileStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cacheKey := fmt.Sprintf("%d:%x", iterations, salt)
+	key, ok := s.keys[cacheKey]
+	if !ok {
+		var err error
+		switch s.unlock.kdf {
+		case kdfPassphrase:
+			if iterations <= 0 {
+				return nil, fmt.Errorf("invalid credentials file iteration count: %d", iterations)
+			}
+			key, err = pbkdf2.Key(sha256.New, string(s.unlock.secret), salt, iterations, fileKeySize)
+		default:
+			key, err = hkdf.Key(sha256.New, s.unlock.secret, salt, credentialsAAD, fileKeySize)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive credentials key: %w", err)
+		}
+		s.keys[cacheKey] = key
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func kdfLabel(kdf string) string {
+	if kdf == kdfKeyFile {
+		return "key file (" + keyFileEnvVar + ")"
+	}
+	return "passphrase (" + passphraseEnvVar + ")"
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create credentials file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(0600); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to secure credentials file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync credentials file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close credentials file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace credentials file: %w", err)
+	}
+	return nil
+}
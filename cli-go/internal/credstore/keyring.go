@@ -0,0 +1,51 @@
+package credstore
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+const keyringService = "envault"
+
+// keyringProbeAccount is looked up, never written, to check that a keyring
+// service answers.
+const keyringProbeAccount = "credential-store-probe"
+
+type keyringStore struct{}
+
+func newKeyringStore() *keyringStore { return &keyringStore{} }
+
+func (*keyringStore) Name() string     { return BackendKeyring }
+func (*keyringStore) Describe() string { return "OS keyring" }
+func (*keyringStore) Persistent() bool { return true }
+
+func (*keyringStore) Get(account string) (string, error) {
+	value, err := keyring.Get(keyringService, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (*keyringStore) Set(account, value string) error {
+	return keyring.Set(keyringService, account, value)
+}
+
+func (*keyringStore) Delete(account string) error {
+	err := keyring.Delete(keyringService, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// probeKeyring returns nil when the OS keyring can be reached, for example
+// when a Secret Service is running on the D-Bus session bus on Linux.
+func probeKeyring() error {
+	_, err := keyring.Get(keyringService, keyringProbeAccount)
+	if err == nil || errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,232 @@
+// Package credstore keeps the CLI's credentials: the session access token,
+// the refresh token, the offline cache master key and the bundle signing key.
+// The backend is the OS keyring, an encrypted file or environment variables,
+// chosen by ENVAULT_CREDENTIAL_STORE or credentials.store in config.toml.
+package credstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Account names. The refresh token keeps the keyring account older releases
+// used so existing logins survive the upgrade.
+const (
+	AccountAccessToken  = "access-token"
+	AccountRefreshToken = "cli"
+	AccountCacheKey     = "offline-cache-master-key"
+	AccountBundleKey    = "bundle-signing-key"
+)
+
+// Backend names accepted by ENVAULT_CREDENTIAL_STORE.
+const (
+	BackendAuto    = "auto"
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+	BackendEnv     = "env"
+)
+
+const (
+	backendEnvVar    = "ENVAULT_CREDENTIAL_STORE"
+	passphraseEnvVar = "ENVAULT_PASSPHRASE"
+	keyFileEnvVar    = "ENVAULT_KEY_FILE"
+)
+
+// ErrNotFound is returned by Get when the account holds no credential.
+var ErrNotFound = errors.New("credential not found")
+
+// Store reads and writes credentials by account name.
+type Store interface {
+	// Name identifies the backend: "keyring", "file" or "env".
+	Name() string
+	// Describe says where credentials live, for doctor and warnings.
+	Describe() string
+	// Persistent reports whether Set survives the current process.
+	Persistent() bool
+	Get(account string) (string, error)
+	Set(account, value string) error
+	Delete(account string) error
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultStore Store
+	defaultErr   error
+
+	// keyringAvailable is replaced in tests.
+	keyringAvailable = probeKeyring
+)
+
+// Default returns the store selected for this process. The selection is made
+// once; an error means the configured backend cannot be used.
+func Default() (Store, error) {
+	defaultOnce.Do(func() {
+		defaultStore, defaultErr = Open(SelectedBackend())
+	})
+	return defaultStore, defaultErr
+}
+
+// SelectedBackend returns the configured backend name, "auto" when unset.
+func SelectedBackend() string {
+	name := strings.TrimSpace(os.Getenv(backendEnvVar))
+	if name == "" {
+		name = strings.TrimSpace(viper.GetString("credentials.store"))
+	}
+	if name == "" {
+		return BackendAuto
+	}
+	return strings.ToLower(name)
+}
+
+// Open returns the named backend. "auto" prefers the OS keyring, then the
+// encrypted file when a passphrase or key file is configured, and finally
+// process memory. Only an explicit "env" reads credentials from environment
+// variables: a session token in ENVAULT_ACCESS_TOKEN must be asked for, just
+// as ENVAULT_TOKEN never accepts one.
+func Open(name string) (Store, error) {
+	switch name {
+	case BackendKeyring:
+		if err := keyringAvailable(); err != nil {
+			return nil, fmt.Errorf("OS keyring unavailable: %w", err)
+		}
+		return newKeyringStore(), nil
+	case BackendFile:
+		return newFileStore()
+	case BackendEnv:
+		return newEnvStore(), nil
+	case BackendAuto:
+		if keyringAvailable() == nil {
+			return newKeyringStore(), nil
+		}
+		if _, err := unlockSecret(); err == nil {
+			return newFileStore()
+		}
+		return newMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown credential store %q: expected keyring, file, env or auto", name)
+	}
+}
+
+// Get reads an account from the default store.
+func Get(account string) (string, error) {
+	store, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return store.Get(account)
+}
+
+// Set writes an account to the default store.
+func Set(account, value string) error {
+	store, err := Default()
+	if err != nil {
+		return err
+	}
+	return store.Set(account, value)
+}
+
+// Delete removes an account from the default store. Deleting a missing
+// account is not an error.
+func Delete(account string) error {
+	store, err := Default()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(account); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// AccessToken returns the session access token, or "" when logged out. A
+// token that older releases left in plaintext in config.toml is moved into
+// the store the first time it is read.
+func AccessToken() string {
+	token, err := Get(AccountAccessToken)
+	if err == nil && token != "" {
+		return token
+	}
+
+	legacy := strings.TrimSpace(viper.GetString("auth.token"))
+	if legacy == "" {
+		return ""
+	}
+	store, storeErr := Default()
+	if storeErr != nil || !store.Persistent() {
+		return legacy
+	}
+	if store.Set(AccountAccessToken, legacy) == nil {
+		_ = clearLegacyToken()
+	}
+	return legacy
+}
+
+// SaveAccessToken stores the session access token and drops any plaintext
+// copy from config.toml.
+func SaveAccessToken(token string) error {
+	if err := Set(AccountAccessToken, token); err != nil {
+		return err
+	}
+	_ = clearLegacyToken()
+	return nil
+}
+
+func clearLegacyToken() error {
+	if viper.GetString("auth.token") == "" {
+		return nil
+	}
+	viper.Set("auth.token", "")
+	return viper.WriteConfig()
+}
+
+// RemoveSession deletes the session tokens from the store, any plaintext
+// token in config.toml and a refresh token older releases left in the OS
+// keyring when another backend is now selected. It returns a description of
+// each credential it removed.
+func RemoveSession() ([]string, error) {
+	store, err := Default()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	remove := func(s Store, account, label string) error {
+		if value, err := s.Get(account); err != nil || value == "" {
+			return nil
+		}
+		if err := s.Delete(account); err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to remove the %s from %s: %w", label, s.Describe(), err)
+		}
+		removed = append(removed, fmt.Sprintf("%s from %s", label, s.Describe()))
+		return nil
+	}
+
+	if err := remove(store, AccountAccessToken, "access token"); err != nil {
+		return removed, err
+	}
+	if err := remove(store, AccountRefreshToken, "refresh token"); err != nil {
+		return removed, err
+	}
+	if store.Name() != BackendKeyring && keyringAvailable() == nil {
+		if err := remove(newKeyringStore(), AccountRefreshToken, "refresh token"); err != nil {
+			return removed, err
+		}
+	}
+	if HasPlaintextToken() {
+		if err := clearLegacyToken(); err != nil {
+			return removed, fmt.Errorf("failed to remove the access token from config.toml: %w", err)
+		}
+		removed = append(removed, "plaintext access token from config.toml")
+	}
+	return removed, nil
+}
+
+// HasPlaintextToken reports whether config.toml still holds an access token.
+func HasPlaintextToken() bool {
+	return strings.TrimSpace(viper.GetString("auth.token")) != ""
+}
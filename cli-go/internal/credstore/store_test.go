@@ -0,0 +1,241 @@
+package credstore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func setupTestHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	userHomeDir = func() (string, error) { return home, nil }
+	keyringAvailable = func() error { return errors.New("no secret service") }
+	for _, name := range []string{backendEnvVar, passphraseEnvVar, keyFileEnvVar} {
+		t.Setenv(name, "")
+	}
+	resetDefault()
+	t.Cleanup(func() {
+		userHomeDir = os.UserHomeDir
+		keyringAvailable = probeKeyring
+		resetDefault()
+		viper.Reset()
+	})
+	return home
+}
+
+func resetDefault() {
+	defaultOnce = sync.Once{}
+	defaultStore, defaultErr = nil, nil
+}
+
+func TestFileStoreRoundTripWithPassphrase(t *testing.T) {
+	home := setupTestHome(t)
+	t.Setenv(passphraseEnvVar, "correct horse battery staple")
+
+	store, err := Open(BackendFile)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := store.Set(AccountRefreshToken, "refresh-secret-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set(AccountAccessToken, "envault_at_access"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(home, ".envault", credentialsFileName))
+	if err != nil {
+		t.Fatalf("credentials file missing: %v", err)
+	}
+	if bytes.Contains(raw, []byte("refresh-secret-value")) || bytes.Contains(raw, []byte("envault_at_access")) {
+		t.Fatalf("credentials file contains plaintext: %s", raw)
+	}
+	if info, _ := os.Stat(filepath.Join(home, ".envault", credentialsFileName)); info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	reopened, _ := Open(BackendFile)
+	if got, err := reopened.Get(AccountRefreshToken); err != nil || got != "refresh-secret-value" {
+		t.Fatalf("expected the refresh token back, got %q, %v", got, err)
+	}
+	if err := reopened.Delete(AccountRefreshToken); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := reopened.Get(AccountRefreshToken); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+	if got, _ := reopened.Get(AccountAccessToken); got != "envault_at_access" {
+		t.Fatalf("delete removed the wrong account, access token is %q", got)
+	}
+
+	t.Setenv(passphraseEnvVar, "wrong passphrase")
+	wrong, _ := Open(BackendFile)
+	if _, err := wrong.Get(AccountAccessToken); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected a wrong passphrase error, got %v", err)
+	}
+}
+
+func TestFileStoreWithKeyFile(t *testing.T) {
+	home := setupTestHome(t)
+	keyFile := filepath.Join(home, "envault.key")
+	if err := os.WriteFile(keyFile, []byte("a2V5LWZpbGUtY29udGVudHMtd2l0aC1lbm91Z2gtZW50cm9weQ==\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(keyFileEnvVar, keyFile)
+
+	store, err := Open(BackendFile)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := store.Set(AccountCacheKey, "cache-key"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, err := store.Get(AccountCacheKey); err != nil || got != "cache-key" {
+		t.Fatalf("expected the cache key back, got %q, %v", got, err)
+	}
+
+	// A file created with a key file must not be silently re-keyed by a passphrase.
+	t.Setenv(passphraseEnvVar, "passphrase")
+	other, _ := Open(BackendFile)
+	if _, err := other.Get(AccountCacheKey); err == nil || !strings.Contains(err.Error(), "key file") {
+		t.Fatalf("expected a key file mismatch error, got %v", err)
+	}
+}
+
+func TestOpenSelectsBackend(t *testing.T) {
+	setupTestHome(t)
+
+	if _, err := Open(BackendFile); err == nil {
+		t.Fatal("expected the file backend to need a passphrase or key file")
+	}
+	if _, err := Open(BackendKeyring); err == nil {
+		t.Fatal("expected the keyring backend to fail without a keyring")
+	}
+	if _, err := Open("vault"); err == nil {
+		t.Fatal("expected an unknown backend to fail")
+	}
+
+	t.Setenv("ENVAULT_ACCESS_TOKEN", "envault_at_from_env")
+	store, err := Open(BackendAuto)
+	if err != nil || store.Name() != BackendEnv {
+		t.Fatalf("expected auto to fall back to env, got %v, %v", store, err)
+	}
+	if _, err := store.Get(AccountAccessToken); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("the auto fallback must not read ENVAULT_ACCESS_TOKEN, got %v", err)
+	}
+	t.Setenv(passphraseEnvVar, "passphrase")
+	if store, _ := Open(BackendAuto); store.Name() != BackendFile {
+		t.Fatalf("expected auto to pick the file store with a passphrase, got %s", store.Name())
+	}
+	keyringAvailable = func() error { return nil }
+	if store, _ := Open(BackendAuto); store.Name() != BackendKeyring {
+		t.Fatalf("expected auto to prefer the keyring, got %s", store.Name())
+	}
+
+	t.Setenv(backendEnvVar, "ENV")
+	if got := SelectedBackend(); got != BackendEnv {
+		t.Fatalf("expected env from %s, got %s", backendEnvVar, got)
+	}
+}
+
+func TestEnvStoreReadsVariablesAndKeepsWritesInMemory(t *testing.T) {
+	setupTestHome(t)
+	t.Setenv("ENVAULT_REFRESH_TOKEN", "from-env")
+
+	store := newEnvStore()
+	if store.Persistent() {
+		t.Fatal("the env store must not claim to persist")
+	}
+	if got, err := store.Get(AccountRefreshToken); err != nil || got != "from-env" {
+		t.Fatalf("expected the variable's value, got %q, %v", got, err)
+	}
+	if _, err := store.Get(AccountAccessToken); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	_ = store.Set(AccountAccessToken, "in-memory")
+	if got, _ := store.Get(AccountAccessToken); got != "in-memory" {
+		t.Fatalf("expected the in-memory value, got %q", got)
+	}
+	_ = store.Delete(AccountRefreshToken)
+	if _, err := store.Get(AccountRefreshToken); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected delete to hide the variable, got %v", err)
+	}
+}
+
+func TestAccessTokenMovesPlaintextTokenIntoStore(t *testing.T) {
+	home := setupTestHome(t)
+	t.Setenv(passphraseEnvVar, "passphrase")
+
+	configPath := filepath.Join(home, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[auth]\ntoken = \"envault_at_legacy\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := AccessToken(); got != "envault_at_legacy" {
+		t.Fatalf("expected the legacy token, got %q", got)
+	}
+	if stored, err := Get(AccountAccessToken); err != nil || stored != "envault_at_legacy" {
+		t.Fatalf("expected the token in the store, got %q, %v", stored, err)
+	}
+	raw, _ := os.ReadFile(configPath)
+	if strings.Contains(string(raw), "envault_at_legacy") || HasPlaintextToken() {
+		t.Fatalf("expected the plaintext token to be removed from config.toml:\n%s", raw)
+	}
+}
+
+func TestRemoveSessionDeletesTokensAndPlaintextCopy(t *testing.T) {
+	home := setupTestHome(t)
+	t.Setenv(passphraseEnvVar, "passphrase")
+
+	configPath := filepath.Join(home, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[auth]\ntoken = \"envault_at_legacy\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(AccountAccessToken, "envault_at_current"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(AccountRefreshToken, "envault_rt_current"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(AccountCacheKey, "cache-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := RemoveSession()
+	if err != nil {
+		t.Fatalf("RemoveSession failed: %v", err)
+	}
+	if len(removed) != 3 || !strings.HasPrefix(removed[0], "access token from encrypted file") || !strings.HasPrefix(removed[1], "refresh token") || !strings.Contains(removed[2], "config.toml") {
+		t.Fatalf("unexpected removals: %q", removed)
+	}
+	for _, account := range []string{AccountAccessToken, AccountRefreshToken} {
+		if _, err := Get(account); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected %s to be removed, got %v", account, err)
+		}
+	}
+	if value, err := Get(AccountCacheKey); err != nil || value != "cache-key" {
+		t.Fatalf("expected the cache key to be kept, got %q, %v", value, err)
+	}
+	if HasPlaintextToken() {
+		t.Fatal("expected the plaintext token to be cleared")
+	}
+
+	if removed, err := RemoveSession(); err != nil || len(removed) != 0 {
+		t.Fatalf("expected nothing left to remove, got %q, %v", removed, err)
+	}
+}
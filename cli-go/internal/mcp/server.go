@@ -0,0 +1,216 @@
+// Package mcp implements the server side of the Model Context Protocol over
+// stdio: newline-delimited JSON-RPC 2.0 messages on stdin and stdout. It
+// knows nothing about Envault; tools are registered by the caller.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the newest protocol revision the server speaks. A
+// client asking for another revision is answered with this one, as the
+// specification requires, and decides itself whether to continue.
+const ProtocolVersion = "2025-06-18"
+
+// maxMessageSize bounds a single JSON-RPC message read from stdin.
+const maxMessageSize = 4 << 20
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Handler runs a tool with its raw JSON arguments. The result is returned to
+// the client as JSON text; an error is returned as a tool error the model can
+// read, not as a protocol error.
+type Handler func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+// Tool is a tool the server offers.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{}
+	Handler     Handler
+}
+
+// Server answers MCP requests for a fixed set of tools.
+type Server struct {
+	Name         string
+	Version      string
+	Instructions string
+
+	tools []Tool
+	index map[string]Tool
+}
+
+// NewServer returns a server that reports name and version to clients.
+func NewServer(name, version string) *Server {
+	return &Server{Name: name, Version: version, index: map[string]Tool{}}
+}
+
+// AddTool registers a tool. Tools are listed in the order they are added.
+func (s *Server) AddTool(tool Tool) {
+	if _, exists := s.index[tool.Name]; !exists {
+		s.tools = append(s.tools, tool)
+	}
+	s.index[tool.Name] = tool
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError"`
+}
+
+// Serve reads requests from in and writes responses to out until in is
+// closed or ctx is cancelled. Requests are answered one at a time, in order.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				return <-readErr
+			}
+			if len(line) == 0 {
+				continue
+			}
+			if resp, reply := s.handle(ctx, line); reply {
+				if err := encoder.Encode(resp); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// handle answers one message. Notifications get no reply.
+func (s *Server) handle(ctx context.Context, line []byte) (response, bool) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorResponse(json.RawMessage("null"), codeParseError, "Parse error"), true
+	}
+	if len(req.ID) == 0 {
+		return response{}, false
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, codeInvalidRequest, "Invalid request"), true
+	}
+
+	switch req.Method {
+	case "initialize":
+		result := map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": s.Name, "version": s.Version},
+		}
+		if s.Instructions != "" {
+			result["instructions"] = s.Instructions
+		}
+		return response{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+	case "ping":
+		return response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}, true
+	case "tools/list":
+		tools := make([]map[string]interface{}, 0, len(s.tools))
+		for _, tool := range s.tools {
+			schema := tool.InputSchema
+			if schema == nil {
+				schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			}
+			tools = append(tools, map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"inputSchema": schema,
+			})
+		}
+		return response{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{"tools": tools}}, true
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, codeInvalidParams, "Invalid params"), true
+		}
+		tool, ok := s.index[params.Name]
+		if !ok {
+			return errorResponse(req.ID, codeInvalidParams, fmt.Sprintf("Unknown tool: %s", params.Name)), true
+		}
+		if len(params.Arguments) == 0 || string(params.Arguments) == "null" {
+			params.Arguments = json.RawMessage("{}")
+		}
+		return response{JSONRPC: "2.0", ID: req.ID, Result: callTool(ctx, tool, params.Arguments)}, true
+	default:
+		return errorResponse(req.ID, codeMethodNotFound, fmt.Sprintf("Method not found: %s", req.Method)), true
+	}
+}
+
+func callTool(ctx context.Context, tool Tool, args json.RawMessage) callResult {
+	result, err := tool.Handler(ctx, args)
+	if err != nil {
+		return callResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	text, ok := result.(string)
+	if !ok {
+		data, marshalErr := json.MarshalIndent(result, "", "  ")
+		if marshalErr != nil {
+			return callResult{Content: []textContent{{Type: "text", Text: marshalErr.Error()}}, IsError: true}
+		}
+		text = string(data)
+	}
+	return callResult{Content: []textContent{{Type: "text", Text: text}}}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) response {
+	return response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
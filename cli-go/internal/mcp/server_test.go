@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		Tools []struct {
+			Name        string                 `json:"name"`
+			InputSchema map[string]interface{} `json:"inputSchema"`
+		} `json:"tools"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	} `json:"result"`
+	Error *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+func TestServeAnswersRequestsAndSkipsNotifications(t *testing.T) {
+	server := NewServer("envault", "1.2.3")
+	server.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echo the name",
+		Handler: func(_ context.Context, args json.RawMessage) (interface{}, error) {
+			var in struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(args, &in)
+			if in.Name == "" {
+				return nil, errors.New("name is required")
+			}
+			return map[string]string{"hello": in.Name}, nil
+		},
+	})
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"name":"bot"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`,
+		`not json`,
+	}, "\n") + "\n"
+	var out bytes.Buffer
+	if err := server.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	var responses []testReply
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var reply testReply
+		if err := json.Unmarshal([]byte(line), &reply); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		responses = append(responses, reply)
+	}
+
+	if len(responses) != 6 {
+		t.Fatalf("expected 6 responses (no reply to the notification), got %d:\n%s", len(responses), out.String())
+	}
+	if responses[0].Result.ProtocolVersion != ProtocolVersion || responses[0].Result.ServerInfo.Version != "1.2.3" {
+		t.Fatalf("unexpected initialize result: %+v", responses[0].Result)
+	}
+	if len(responses[1].Result.Tools) != 1 || responses[1].Result.Tools[0].Name != "echo" || responses[1].Result.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("unexpected tools/list result: %+v", responses[1].Result.Tools)
+	}
+	if responses[2].Result.IsError || !strings.Contains(responses[2].Result.Content[0].Text, `"hello": "bot"`) {
+		t.Fatalf("unexpected tool result: %+v", responses[2].Result)
+	}
+	if !responses[3].Result.IsError || responses[3].Result.Content[0].Text != "name is required" {
+		t.Fatalf("expected the handler error as a tool error, got %+v", responses[3].Result)
+	}
+	if responses[4].Error == nil || responses[4].Error.Code != codeMethodNotFound {
+		t.Fatalf("expected method not found, got %+v", responses[4])
+	}
+	if responses[5].Error == nil || responses[5].Error.Code != codeParseError || string(responses[5].ID) != "null" {
+		t.Fatalf("expected a parse error with a null id, got %+v", responses[5])
+	}
+}
@@ -1,76 +1,112 @@
 package offlinecache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/DinanathDash/Envault/cli-go/internal/filelock"
 )
 
 var userHomeDir = os.UserHomeDir
 
+// Each project/environment pair is stored in its own encrypted file under
+// ~/.envault/offline_cache/, so concurrent runs for different environments
+// never touch the same file. Writers hold an advisory lock on the directory,
+// write to a uniquely named temp file and rename it into place; readers rely
+// on the rename being atomic and take no lock.
+
 func Save(projectID, environment string, secrets []Secret) error {
-	entryKey, err := makeEntryKey(projectID, environment)
+	projectID, environment, err := normalizeEntry(projectID, environment)
 	if err != nil {
 		return err
 	}
 
-	payload := cachePayload{Entries: map[string]cacheEntry{}}
-	current, err := loadPayload()
-	if err == nil {
-		payload = current
-	} else if !errors.Is(err, os.ErrNotExist) {
+	dir, err := cacheDir(true)
+	if err != nil {
 		return err
 	}
-
-	payload.Entries[entryKey] = cacheEntry{
-		Secrets:  cloneSecrets(secrets),
-		CachedAt: time.Now().UTC(),
+	unlock, err := lockCacheDir(dir)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	plaintext, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to encode cache payload: %w", err)
+	if err := migrateLegacyLocked(dir); err != nil {
+		return err
 	}
 
-	key, err := getOrCreateMasterKey()
+	return writeEntryLocked(dir, projectID, environment, cacheEntry{
+		Secrets:  cloneSecrets(secrets),
+		CachedAt: time.Now().UTC(),
+	})
+}
+
+// Import stores secrets obtained out of band, such as from a bundle, keeping
+// their original time and an expiry after which Load refuses them. An entry
+// cached after cachedAt is only replaced when replaceNewer is set.
+func Import(projectID, environment string, secrets []Secret, cachedAt, expiresAt time.Time, replaceNewer bool) error {
+	projectID, environment, err := normalizeEntry(projectID, environment)
 	if err != nil {
 		return err
 	}
 
-	encrypted, err := encryptPayload(plaintext, key)
+	dir, err := cacheDir(true)
 	if err != nil {
 		return err
 	}
-
-	path, err := cacheFilePath(true)
+	unlock, err := lockCacheDir(dir)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	tempPath := path + ".tmp"
-	if writeErr := os.WriteFile(tempPath, encrypted, 0600); writeErr != nil {
-		return fmt.Errorf("failed to write temp cache file: %w", writeErr)
+	if err := migrateLegacyLocked(dir); err != nil {
+		return err
 	}
 
-	if renameErr := os.Rename(tempPath, path); renameErr != nil {
-		_ = os.Remove(tempPath)
-		return fmt.Errorf("failed to persist cache file: %w", renameErr)
+	if !replaceNewer {
+		key, err := getOrCreateMasterKey()
+		if err != nil {
+			return err
+		}
+		// An existing entry that cannot be read holds nothing worth keeping,
+		// so it is simply replaced.
+		existing, err := readEntryInfo(filepath.Join(dir, entryFileName(projectID, environment)), key)
+		if err == nil && existing.CachedAt.After(cachedAt) {
+			return fmt.Errorf("%w (cached at %s)", ErrNewerEntry, existing.CachedAt.Format(time.RFC3339))
+		}
 	}
 
-	return nil
+	return writeEntryLocked(dir, projectID, environment, cacheEntry{
+		Secrets:   cloneSecrets(secrets),
+		CachedAt:  cachedAt.UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	})
 }
 
 func Load(projectID, environment string) ([]Secret, time.Time, error) {
-	entryKey, err := makeEntryKey(projectID, environment)
+	projectID, environment, err := normalizeEntry(projectID, environment)
 	if err != nil {
 		return nil, time.Time{}, err
 	}
 
-	payload, err := loadPayload()
+	dir, err := cacheDir(false)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if err := migrateLegacy(dir); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, entryFileName(projectID, environment)))
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, time.Time{}, ErrCacheMiss
@@ -78,74 +114,414 @@ func Load(projectID, environment string) ([]Secret, time.Time, error) {
 		return nil, time.Time{}, err
 	}
 
-	entry, ok := payload.Entries[entryKey]
-	if !ok {
-		return nil, time.Time{}, ErrCacheMiss
+	key, err := getOrCreateMasterKey()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	entry, err := decodeEntry(raw, key, projectID, environment)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		return nil, time.Time{}, fmt.Errorf("%w: it expired at %s", ErrEntryExpired, entry.ExpiresAt.Local().Format(time.RFC3339))
 	}
 
 	return cloneSecrets(entry.Secrets), entry.CachedAt, nil
 }
 
-func loadPayload() (cachePayload, error) {
-	path, err := cacheFilePath(false)
+// List describes every readable cache entry, sorted by project and
+// environment, and returns the entries it had to skip. It never returns
+// secret values.
+func List() ([]EntryInfo, []UnreadableEntry, error) {
+	dir, err := cacheDir(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := migrateLegacy(dir); err != nil {
+		return nil, nil, err
+	}
+
+	files, err := entryFiles(dir)
+	if err != nil || len(files) == 0 {
+		return []EntryInfo{}, nil, err
+	}
+	key, err := getOrCreateMasterKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	infos := make([]EntryInfo, 0, len(files))
+	var unreadable []UnreadableEntry
+	for _, path := range files {
+		info, err := readEntryInfo(path, key)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			unreadable = append(unreadable, UnreadableEntry{File: filepath.Base(path), Err: err})
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sortEntries(infos)
+	return infos, unreadable, nil
+}
+
+// Purge deletes the entries matching projectID and environment, where an
+// empty value matches anything, and returns what was deleted. An unreadable
+// entry is deleted by its file name: when both values are empty, or when it
+// is the file for exactly projectID and environment. Other unreadable entries
+// are returned with Removed unset.
+func Purge(projectID, environment string) ([]EntryInfo, []UnreadableEntry, error) {
+	dir, err := cacheDir(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if _, legacyErr := os.Stat(legacyCacheFilePath(dir)); errors.Is(legacyErr, os.ErrNotExist) {
+			return []EntryInfo{}, nil, nil
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	unlock, err := lockCacheDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	if err := migrateLegacyLocked(dir); err != nil {
+		return nil, nil, err
+	}
+
+	files, err := entryFiles(dir)
+	if err != nil || len(files) == 0 {
+		return []EntryInfo{}, nil, err
+	}
+	key, err := getOrCreateMasterKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projectID = strings.TrimSpace(projectID)
+	environment = strings.ToLower(strings.TrimSpace(environment))
+	exactFile := ""
+	if projectID != "" && environment != "" {
+		exactFile = entryFileName(projectID, environment)
+	}
+	purged := []EntryInfo{}
+	var unreadable []UnreadableEntry
+	for _, path := range files {
+		info, err := readEntryInfo(path, key)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			entry := UnreadableEntry{File: filepath.Base(path), Err: err}
+			if (projectID == "" && environment == "") || entry.File == exactFile {
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return nil, nil, fmt.Errorf("failed to remove cache entry: %w", err)
+				}
+				entry.Removed = true
+			}
+			unreadable = append(unreadable, entry)
+			continue
+		}
+		if (projectID != "" && info.ProjectID != projectID) || (environment != "" && info.Environment != environment) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		purged = append(purged, info)
+	}
+	return purged, unreadable, nil
+}
+
+func sortEntries(infos []EntryInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].ProjectID != infos[j].ProjectID {
+			return infos[i].ProjectID < infos[j].ProjectID
+		}
+		return infos[i].Environment < infos[j].Environment
+	})
+}
+
+// Wipe deletes every cache entry, including a legacy single-file cache, and
+// then the master key, without decrypting anything; entries that cannot be
+// read are removed too. It returns the number of entries deleted and whether
+// a master key was removed.
+func Wipe() (int, bool, error) {
+	dir, err := cacheDir(false)
 	if err != nil {
-		return cachePayload{}, err
+		return 0, false, err
+	}
+
+	removed := 0
+	if _, err := os.Stat(dir); err == nil {
+		unlock, err := lockCacheDir(dir)
+		if err != nil {
+			return 0, false, err
+		}
+		defer unlock()
+
+		files, err := entryFiles(dir)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, path := range files {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return removed, false, fmt.Errorf("failed to remove cache entry: %w", err)
+			}
+			removed++
+		}
+	}
+	if err := os.Remove(legacyCacheFilePath(dir)); err == nil {
+		removed++
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return removed, false, fmt.Errorf("failed to remove %s: %w", legacyCacheFileName, err)
 	}
 
+	if encoded, err := credentialGet(); err != nil || encoded == "" {
+		return removed, false, nil
+	}
+	if err := credentialDelete(); err != nil {
+		return removed, false, fmt.Errorf("failed to remove cache key from credential store: %w", err)
+	}
+	return removed, true, nil
+}
+
+// readEntryInfo describes the entry at path. A nil key reads the master key
+// from the credential store.
+func readEntryInfo(path string, key []byte) (EntryInfo, error) {
 	raw, err := os.ReadFile(path)
 	if err != nil {
-		return cachePayload{}, err
+		return EntryInfo{}, err
+	}
+	if key == nil {
+		if key, err = getOrCreateMasterKey(); err != nil {
+			return EntryInfo{}, err
+		}
+	}
+	projectID, environment, err := envelopeEntry(raw)
+	if err != nil {
+		return EntryInfo{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if filepath.Base(path) != entryFileName(projectID, environment) {
+		return EntryInfo{}, fmt.Errorf("%s: cache entry does not match its file name", filepath.Base(path))
+	}
+	entry, err := decodeEntry(raw, key, projectID, environment)
+	if err != nil {
+		return EntryInfo{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	info := EntryInfo{
+		ProjectID:   projectID,
+		Environment: environment,
+		CachedAt:    entry.CachedAt,
+		ExpiresAt:   entry.ExpiresAt,
+		Keys:        make([]string, len(entry.Secrets)),
+	}
+	for i, s := range entry.Secrets {
+		info.Keys[i] = s.Key
+		info.Size += len(s.Key) + len(s.Value)
+	}
+	return info, nil
+}
+
+func decodeEntry(raw, key []byte, projectID, environment string) (cacheEntry, error) {
+	plaintext, err := decryptPayload(raw, key, projectID, environment)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(plaintext, &entry); err != nil {
+		return cacheEntry{}, fmt.Errorf("failed to parse cache entry: %w", err)
+	}
+	return entry, nil
+}
+
+// writeEntryLocked encrypts the entry and atomically replaces its file. The
+// caller holds the directory lock.
+func writeEntryLocked(dir, projectID, environment string, entry cacheEntry) error {
+	plaintext, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
 	}
 
 	key, err := getOrCreateMasterKey()
 	if err != nil {
-		return cachePayload{}, err
+		return err
 	}
 
-	plaintext, err := decryptPayload(raw, key)
+	encrypted, err := encryptPayload(plaintext, key, projectID, environment)
 	if err != nil {
-		return cachePayload{}, err
+		return err
 	}
 
-	var payload cachePayload
+	return writeFileAtomic(filepath.Join(dir, entryFileName(projectID, environment)), encrypted)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp cache file: %w", err)
+	}
+	tempPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write temp cache file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write temp cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write temp cache file: %w", err)
+	}
+	_ = os.Chmod(tempPath, 0600)
+
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to persist cache file: %w", err)
+	}
+	return nil
+}
+
+// migrateLegacy moves entries from the single offline_cache.enc file used by
+// earlier versions into per-entry files, then removes it.
+func migrateLegacy(dir string) error {
+	if _, err := os.Stat(legacyCacheFilePath(dir)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	unlock, err := lockCacheDir(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return migrateLegacyLocked(dir)
+}
+
+func migrateLegacyLocked(dir string) error {
+	legacyPath := legacyCacheFilePath(dir)
+	raw, err := os.ReadFile(legacyPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	key, err := getOrCreateMasterKey()
+	if err != nil {
+		return err
+	}
+	plaintext, err := decryptLegacyPayload(raw, key)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", legacyCacheFileName, err)
+	}
+	var payload legacyCachePayload
 	if err := json.Unmarshal(plaintext, &payload); err != nil {
-		return cachePayload{}, fmt.Errorf("failed to parse cache payload: %w", err)
+		return fmt.Errorf("failed to migrate %s: failed to parse cache payload: %w", legacyCacheFileName, err)
 	}
 
-	if payload.Entries == nil {
-		payload.Entries = map[string]cacheEntry{}
+	for entryKey, entry := range payload.Entries {
+		rawProject, rawEnvironment, _ := strings.Cut(entryKey, ":")
+		projectID, environment, err := normalizeEntry(rawProject, rawEnvironment)
+		if err != nil {
+			continue
+		}
+		// An entry written since the legacy file was last updated is newer.
+		existing, readErr := os.ReadFile(filepath.Join(dir, entryFileName(projectID, environment)))
+		if readErr == nil {
+			if current, decodeErr := decodeEntry(existing, key, projectID, environment); decodeErr == nil && !current.CachedAt.Before(entry.CachedAt) {
+				continue
+			}
+		}
+		if err := writeEntryLocked(dir, projectID, environment, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(legacyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", legacyCacheFileName, err)
+	}
+	return nil
+}
+
+func entryFiles(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
 	}
-	return payload, nil
+
+	files := []string{}
+	for _, e := range dirEntries {
+		if e.Type().IsRegular() && strings.HasSuffix(e.Name(), entryFileSuffix) {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// entryFileName derives a fixed-length file name from the entry's identity,
+// so environment names never need escaping.
+func entryFileName(projectID, environment string) string {
+	sum := sha256.Sum256([]byte(projectID + ":" + environment))
+	return hex.EncodeToString(sum[:16]) + entryFileSuffix
 }
 
-func cacheFilePath(createDir bool) (string, error) {
+func lockCacheDir(dir string) (func(), error) {
+	unlock, err := filelock.Lock(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock offline cache: %w", err)
+	}
+	return unlock, nil
+}
+
+func cacheDir(create bool) (string, error) {
 	home, err := userHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve home directory: %w", err)
 	}
 
-	configDir := filepath.Join(home, ".envault")
-	if createDir {
-		if err := os.MkdirAll(configDir, 0700); err != nil {
-			return "", fmt.Errorf("failed to create config directory: %w", err)
+	dir := filepath.Join(home, ".envault", cacheDirName)
+	if create {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create cache directory: %w", err)
 		}
 	}
+	return dir, nil
+}
 
-	return filepath.Join(configDir, cacheFileName), nil
+func legacyCacheFilePath(dir string) string {
+	return filepath.Join(filepath.Dir(dir), legacyCacheFileName)
 }
 
-func makeEntryKey(projectID, environment string) (string, error) {
+func normalizeEntry(projectID, environment string) (string, string, error) {
 	projectID = strings.TrimSpace(projectID)
 	environment = strings.ToLower(strings.TrimSpace(environment))
 
 	if projectID == "" {
-		return "", errors.New("project ID is required")
+		return "", "", errors.New("project ID is required")
 	}
 	if environment == "" {
-		return "", errors.New("environment is required")
+		return "", "", errors.New("environment is required")
 	}
 
-	return projectID + ":" + environment, nil
+	return projectID, environment, nil
 }
 
 func cloneSecrets(secrets []Secret) []Secret {
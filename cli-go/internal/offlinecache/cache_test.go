@@ -1,13 +1,19 @@
 package offlinecache
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
-	"github.com/zalando/go-keyring"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
 )
 
 func setupTestEnv(t *testing.T) {
@@ -18,29 +24,34 @@ func setupTestEnv(t *testing.T) {
 		return tempHome, nil
 	}
 
-	store := map[string]string{}
-	keyringGet = func(service, user string) (string, error) {
-		value, ok := store[service+":"+user]
-		if !ok {
-			return "", keyring.ErrNotFound
+	stored := ""
+	credentialGet = func() (string, error) {
+		if stored == "" {
+			return "", credstore.ErrNotFound
 		}
-		return value, nil
+		return stored, nil
 	}
-	keyringSet = func(service, user, password string) error {
-		store[service+":"+user] = password
+	credentialSet = func(value string) error {
+		stored = value
+		return nil
+	}
+	credentialDelete = func() error {
+		stored = ""
 		return nil
 	}
 
 	t.Cleanup(func() {
 		userHomeDir = os.UserHomeDir
-		keyringGet = defaultKeyringGet
-		keyringSet = defaultKeyringSet
+		credentialGet = defaultCredentialGet
+		credentialSet = defaultCredentialSet
+		credentialDelete = defaultCredentialDelete
 	})
 }
 
 var (
-	defaultKeyringGet = keyringGet
-	defaultKeyringSet = keyringSet
+	defaultCredentialGet    = credentialGet
+	defaultCredentialSet    = credentialSet
+	defaultCredentialDelete = credentialDelete
 )
 
 func TestSaveAndLoadRoundTrip(t *testing.T) {
@@ -100,7 +111,7 @@ func TestCacheFileIsEncryptedEnvelope(t *testing.T) {
 	}
 
 	home, _ := userHomeDir()
-	path := filepath.Join(home, ".envault", cacheFileName)
+	path := filepath.Join(home, ".envault", cacheDirName, entryFileName(projectID, "development"))
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		t.Fatalf("read cache file failed: %v", err)
@@ -121,7 +132,7 @@ func TestLoadCorruptedCacheFails(t *testing.T) {
 		t.Fatalf("mkdir failed: %v", err)
 	}
 
-	path := filepath.Join(dir, cacheFileName)
+	path := filepath.Join(dir, legacyCacheFileName)
 	if err := os.WriteFile(path, []byte("not-encrypted-json"), 0o600); err != nil {
 		t.Fatalf("write failed: %v", err)
 	}
@@ -131,3 +142,253 @@ func TestLoadCorruptedCacheFails(t *testing.T) {
 		t.Fatalf("expected error for corrupted cache")
 	}
 }
+
+func TestListAndPurge(t *testing.T) {
+	setupTestEnv(t)
+
+	projectA := "11111111-1111-4111-8111-111111111111"
+	projectB := "22222222-2222-4222-8222-222222222222"
+	for _, e := range []struct{ project, env string }{{projectA, "development"}, {projectA, "production"}, {projectB, "development"}} {
+		if err := Save(e.project, e.env, []Secret{{Key: "TOKEN", Value: "abc"}}); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	entries, _, err := List()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].ProjectID != projectA || entries[0].Environment != "development" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Size != len("TOKEN")+len("abc") || len(entries[0].Keys) != 1 || entries[0].Keys[0] != "TOKEN" {
+		t.Fatalf("unexpected entry details: %+v", entries[0])
+	}
+
+	purged, _, err := Purge("", "development")
+	if err != nil || len(purged) != 2 {
+		t.Fatalf("expected 2 purged entries, got %+v (%v)", purged, err)
+	}
+	if _, _, err := Load(projectA, "production"); err != nil {
+		t.Fatalf("expected non-matching entry to survive, got %v", err)
+	}
+
+	if _, _, err := Purge(projectA, ""); err != nil {
+		t.Fatalf("purge failed: %v", err)
+	}
+	if entries, _, err := List(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty listing, got %+v (%v)", entries, err)
+	}
+}
+
+func TestWipeRemovesEntriesAndMasterKey(t *testing.T) {
+	setupTestEnv(t)
+
+	if removed, keyRemoved, err := Wipe(); err != nil || removed != 0 || keyRemoved {
+		t.Fatalf("expected nothing to wipe, got %d, %v, %v", removed, keyRemoved, err)
+	}
+
+	projectID := "11111111-1111-4111-8111-111111111111"
+	for _, env := range []string{"development", "production"} {
+		if err := Save(projectID, env, []Secret{{Key: "TOKEN", Value: "abc"}}); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	removed, keyRemoved, err := Wipe()
+	if err != nil || removed != 2 || !keyRemoved {
+		t.Fatalf("expected 2 entries and the key removed, got %d, %v, %v", removed, keyRemoved, err)
+	}
+	if _, err := credentialGet(); !errors.Is(err, credstore.ErrNotFound) {
+		t.Fatalf("expected the master key to be gone, got %v", err)
+	}
+	if entries, _, err := List(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty cache, got %+v (%v)", entries, err)
+	}
+}
+
+func TestSwappedEntryFileFailsToDecrypt(t *testing.T) {
+	setupTestEnv(t)
+
+	projectID := "11111111-1111-4111-8111-111111111111"
+	if err := Save(projectID, "development", []Secret{{Key: "A", Value: "dev"}}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if err := Save(projectID, "production", []Secret{{Key: "A", Value: "prod"}}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	home, _ := userHomeDir()
+	dir := filepath.Join(home, ".envault", cacheDirName)
+	devRaw, err := os.ReadFile(filepath.Join(dir, entryFileName(projectID, "development")))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, entryFileName(projectID, "production")), devRaw, 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	if _, _, err := Load(projectID, "production"); err == nil {
+		t.Fatalf("expected a swapped entry file to fail to decrypt")
+	}
+
+	entries, unreadable, err := List()
+	if err != nil || len(entries) != 1 || entries[0].Environment != "development" {
+		t.Fatalf("expected the readable entry to be listed, got %+v (%v)", entries, err)
+	}
+	if len(unreadable) != 1 || unreadable[0].File != entryFileName(projectID, "production") {
+		t.Fatalf("expected the swapped file to be reported, got %+v", unreadable)
+	}
+
+	purged, unreadable, err := Purge(projectID, "production")
+	if err != nil || len(purged) != 0 || len(unreadable) != 1 || !unreadable[0].Removed {
+		t.Fatalf("expected the swapped file to be purged by name, got %+v %+v (%v)", purged, unreadable, err)
+	}
+	if entries, unreadable, err := List(); err != nil || len(entries) != 1 || len(unreadable) != 0 {
+		t.Fatalf("expected only the development entry to remain, got %+v %+v (%v)", entries, unreadable, err)
+	}
+}
+
+func TestLegacyCacheIsMigrated(t *testing.T) {
+	setupTestEnv(t)
+
+	projectID := "11111111-1111-4111-8111-111111111111"
+	cachedAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	payload := legacyCachePayload{Entries: map[string]cacheEntry{
+		projectID + ":development": {Secrets: []Secret{{Key: "A", Value: "1"}}, CachedAt: cachedAt},
+		projectID + ":production":  {Secrets: []Secret{{Key: "B", Value: "2"}}, CachedAt: cachedAt},
+	}}
+	writeLegacyCache(t, payload)
+
+	secrets, loadedAt, err := Load(projectID, "production")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0] != (Secret{Key: "B", Value: "2"}) || !loadedAt.Equal(cachedAt) {
+		t.Fatalf("unexpected migrated entry: %+v at %v", secrets, loadedAt)
+	}
+
+	home, _ := userHomeDir()
+	if _, err := os.Stat(filepath.Join(home, ".envault", legacyCacheFileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected the legacy cache file to be removed, stat err=%v", err)
+	}
+	if entries, _, err := List(); err != nil || len(entries) != 2 {
+		t.Fatalf("expected both legacy entries after migration, got %+v (%v)", entries, err)
+	}
+}
+
+func TestConcurrentSavesKeepEveryEntry(t *testing.T) {
+	setupTestEnv(t)
+
+	var mu sync.Mutex
+	stored := ""
+	credentialGet = func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stored == "" {
+			return "", credstore.ErrNotFound
+		}
+		return stored, nil
+	}
+	credentialSet = func(value string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stored = value
+		return nil
+	}
+
+	projectID := "11111111-1111-4111-8111-111111111111"
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Save(projectID, fmt.Sprintf("env-%d", i%8), []Secret{{Key: "N", Value: fmt.Sprint(i)}})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	entries, _, err := List()
+	if err != nil || len(entries) != 8 {
+		t.Fatalf("expected 8 entries, got %d (%v)", len(entries), err)
+	}
+	home, _ := userHomeDir()
+	files, _ := os.ReadDir(filepath.Join(home, ".envault", cacheDirName))
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".tmp") {
+			t.Fatalf("leftover temp file: %s", f.Name())
+		}
+	}
+}
+
+func writeLegacyCache(t *testing.T, payload legacyCachePayload) {
+	t.Helper()
+
+	key, err := getOrCreateMasterKey()
+	if err != nil {
+		t.Fatalf("master key: %v", err)
+	}
+	plaintext, _ := json.Marshal(payload)
+	gcm, err := newGCM(key)
+	if err != nil {
+		t.Fatalf("gcm: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, _ = rand.Read(nonce)
+	raw, _ := json.Marshal(encryptedEnvelope{
+		Version:    legacyCacheVersion,
+		Algorithm:  "AES-256-GCM",
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, nil)),
+	})
+
+	home, _ := userHomeDir()
+	dir := filepath.Join(home, ".envault")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, legacyCacheFileName), raw, 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+func TestImportKeepsTimeAndEnforcesExpiry(t *testing.T) {
+	setupTestEnv(t)
+
+	projectID := "11111111-1111-4111-8111-111111111111"
+	createdAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	secrets := []Secret{{Key: "API_KEY", Value: "bundled"}}
+
+	if err := Import(projectID, "staging", secrets, createdAt, time.Now().Add(time.Hour), false); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	loaded, cachedAt, err := Load(projectID, "staging")
+	if err != nil || len(loaded) != 1 || !cachedAt.Equal(createdAt) {
+		t.Fatalf("expected the bundled entry with its creation time, got %v, %v, %v", loaded, cachedAt, err)
+	}
+
+	if err := Save(projectID, "staging", []Secret{{Key: "API_KEY", Value: "fresh"}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := Import(projectID, "staging", secrets, createdAt, time.Now().Add(time.Hour), false); !errors.Is(err, ErrNewerEntry) {
+		t.Fatalf("expected ErrNewerEntry, got %v", err)
+	}
+
+	if err := Import(projectID, "staging", secrets, createdAt, time.Now().Add(-time.Minute), true); err != nil {
+		t.Fatalf("Import with replaceNewer failed: %v", err)
+	}
+	if _, _, err := Load(projectID, "staging"); !errors.Is(err, ErrEntryExpired) {
+		t.Fatalf("expected ErrEntryExpired, got %v", err)
+	}
+	infos, _, err := List()
+	if err != nil || len(infos) != 1 || infos[0].ExpiresAt.IsZero() {
+		t.Fatalf("expected List to report the expiry, got %+v, %v", infos, err)
+	}
+}
@@ -6,25 +6,31 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+// encryptedEnvelope is the on-disk format of a cache entry. ProjectID and
+// Environment identify the entry for listing; they are also bound to the
+// ciphertext as additional data, so an entry copied over another entry's file
+// or relabelled fails to decrypt.
 type encryptedEnvelope struct {
-	Version    int    `json:"version"`
-	Algorithm  string `json:"algorithm"`
-	Nonce      string `json:"nonce"`
-	Ciphertext string `json:"ciphertext"`
+	Version     int    `json:"version"`
+	Algorithm   string `json:"algorithm"`
+	ProjectID   string `json:"projectId,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Nonce       string `json:"nonce"`
+	Ciphertext  string `json:"ciphertext"`
 }
 
-func encryptPayload(plaintext []byte, key []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher: %w", err)
-	}
+func additionalData(projectID, environment string) []byte {
+	return []byte("envault-offline-cache/v2\x00" + projectID + "\x00" + environment)
+}
 
-	gcm, err := cipher.NewGCM(block)
+func encryptPayload(plaintext []byte, key []byte, projectID, environment string) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gcm: %w", err)
+		return nil, err
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
@@ -32,12 +38,14 @@ func encryptPayload(plaintext []byte, key []byte) ([]byte, error) {
 		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, additionalData(projectID, environment))
 	envelope := encryptedEnvelope{
-		Version:    cacheVersion,
-		Algorithm:  "AES-256-GCM",
-		Nonce:      base64.StdEncoding.EncodeToString(nonce),
-		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+		Version:     cacheVersion,
+		Algorithm:   "AES-256-GCM",
+		ProjectID:   projectID,
+		Environment: environment,
+		Nonce:       base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext:  base64.StdEncoding.EncodeToString(ciphertext),
 	}
 
 	raw, err := json.Marshal(envelope)
@@ -47,20 +55,55 @@ func encryptPayload(plaintext []byte, key []byte) ([]byte, error) {
 	return raw, nil
 }
 
-func decryptPayload(raw []byte, key []byte) ([]byte, error) {
+// decryptPayload opens an entry written for projectID and environment.
+func decryptPayload(raw []byte, key []byte, projectID, environment string) ([]byte, error) {
+	envelope, err := parseEnvelope(raw, cacheVersion)
+	if err != nil {
+		return nil, err
+	}
+	return openEnvelope(envelope, key, additionalData(projectID, environment))
+}
+
+// envelopeEntry returns the project and environment an entry claims to hold.
+// They are only trustworthy once decryptPayload succeeds with them.
+func envelopeEntry(raw []byte) (string, string, error) {
+	envelope, err := parseEnvelope(raw, cacheVersion)
+	if err != nil {
+		return "", "", err
+	}
+	if envelope.ProjectID == "" || envelope.Environment == "" {
+		return "", "", errors.New("cache entry has no project or environment")
+	}
+	return envelope.ProjectID, envelope.Environment, nil
+}
+
+// decryptLegacyPayload opens the single-file cache of earlier versions, which
+// used no additional data.
+func decryptLegacyPayload(raw []byte, key []byte) ([]byte, error) {
+	envelope, err := parseEnvelope(raw, legacyCacheVersion)
+	if err != nil {
+		return nil, err
+	}
+	return openEnvelope(envelope, key, nil)
+}
+
+func parseEnvelope(raw []byte, version int) (encryptedEnvelope, error) {
 	var envelope encryptedEnvelope
 	if err := json.Unmarshal(raw, &envelope); err != nil {
-		return nil, fmt.Errorf("failed to parse encrypted payload: %w", err)
+		return encryptedEnvelope{}, fmt.Errorf("failed to parse encrypted payload: %w", err)
 	}
 
-	if envelope.Version != cacheVersion {
-		return nil, fmt.Errorf("unsupported cache version: %d", envelope.Version)
+	if envelope.Version != version {
+		return encryptedEnvelope{}, fmt.Errorf("unsupported cache version: %d", envelope.Version)
 	}
 
 	if envelope.Algorithm != "AES-256-GCM" {
-		return nil, fmt.Errorf("unsupported cache algorithm: %s", envelope.Algorithm)
+		return encryptedEnvelope{}, fmt.Errorf("unsupported cache algorithm: %s", envelope.Algorithm)
 	}
+	return envelope, nil
+}
 
+func openEnvelope(envelope encryptedEnvelope, key []byte, ad []byte) ([]byte, error) {
 	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
 	if err != nil {
 		return nil, fmt.Errorf("invalid nonce encoding: %w", err)
@@ -71,23 +114,31 @@ func decryptPayload(raw []byte, key []byte) ([]byte, error) {
 		return nil, fmt.Errorf("invalid ciphertext encoding: %w", err)
 	}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gcm: %w", err)
+		return nil, err
 	}
 	if len(nonce) != gcm.NonceSize() {
 		return nil, fmt.Errorf("invalid nonce length: %d", len(nonce))
 	}
 
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, ad)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
 	}
 
 	return plaintext, nil
 }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	return gcm, nil
+}
@@ -6,22 +6,33 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/zalando/go-keyring"
+	"github.com/DinanathDash/Envault/cli-go/internal/credstore"
 )
 
-const (
-	keychainService = "envault"
-	keychainAccount = "offline-cache-master-key"
-	masterKeySize   = 32
-)
+const masterKeySize = 32
 
 var (
-	keyringGet = keyring.Get
-	keyringSet = keyring.Set
+	credentialGet    = func() (string, error) { return credstore.Get(credstore.AccountCacheKey) }
+	credentialSet    = setMasterKey
+	credentialDelete = func() error { return credstore.Delete(credstore.AccountCacheKey) }
 )
 
+// setMasterKey stores a new cache key. A key held only in memory would leave
+// the entries written with it unreadable by the next run, so a store that does
+// not persist is refused; ENVAULT_CACHE_KEY supplies the key instead.
+func setMasterKey(value string) error {
+	store, err := credstore.Default()
+	if err != nil {
+		return err
+	}
+	if !store.Persistent() {
+		return fmt.Errorf("the %s credential store does not persist; set ENVAULT_CACHE_KEY to a base64-encoded 32-byte key or ENVAULT_PASSPHRASE", store.Name())
+	}
+	return store.Set(credstore.AccountCacheKey, value)
+}
+
 func getOrCreateMasterKey() ([]byte, error) {
-	encoded, err := keyringGet(keychainService, keychainAccount)
+	encoded, err := credentialGet()
 	if err == nil && encoded != "" {
 		key, decodeErr := base64.StdEncoding.DecodeString(encoded)
 		if decodeErr != nil {
@@ -33,8 +44,8 @@ func getOrCreateMasterKey() ([]byte, error) {
 		return key, nil
 	}
 
-	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
-		return nil, fmt.Errorf("failed to read cache key from keychain: %w", err)
+	if err != nil && !errors.Is(err, credstore.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read cache key from credential store: %w", err)
 	}
 
 	key := make([]byte, masterKeySize)
@@ -42,8 +53,8 @@ func getOrCreateMasterKey() ([]byte, error) {
 		return nil, fmt.Errorf("failed to generate cache key: %w", readErr)
 	}
 
-	if setErr := keyringSet(keychainService, keychainAccount, base64.StdEncoding.EncodeToString(key)); setErr != nil {
-		return nil, fmt.Errorf("failed to store cache key in keychain: %w", setErr)
+	if setErr := credentialSet(base64.StdEncoding.EncodeToString(key)); setErr != nil {
+		return nil, fmt.Errorf("failed to store cache key in credential store: %w", setErr)
 	}
 
 	return key, nil
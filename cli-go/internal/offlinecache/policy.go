@@ -0,0 +1,66 @@
+package offlinecache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy limits how old an entry may be when it is used. A hard policy
+// refuses older entries; a soft one only warns about them.
+type Policy struct {
+	MaxAge time.Duration
+	Hard   bool
+	// Origin names where the policy was configured, for messages.
+	Origin string
+}
+
+// ParsePolicy builds a policy from a max age such as "72h" or "7d" and a mode
+// of "hard" (the default) or "soft". An empty max age means no limit.
+func ParsePolicy(maxAge, mode, origin string) (Policy, error) {
+	policy := Policy{Hard: true, Origin: origin}
+
+	maxAge = strings.TrimSpace(maxAge)
+	if maxAge != "" {
+		d, err := ParseMaxAge(maxAge)
+		if err != nil {
+			return Policy{}, fmt.Errorf("%s: %w", origin, err)
+		}
+		policy.MaxAge = d
+	}
+
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "hard":
+	case "soft":
+		policy.Hard = false
+	default:
+		return Policy{}, fmt.Errorf("%s: invalid max age mode %q (expected hard or soft)", origin, mode)
+	}
+	return policy, nil
+}
+
+// ParseMaxAge accepts Go durations ("36h", "90m") and whole days ("7d").
+func ParseMaxAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid max age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid max age %q", value)
+	}
+	return d, nil
+}
+
+// Exceeded reports whether an entry cached at cachedAt is too old at now.
+func (p Policy) Exceeded(cachedAt, now time.Time) bool {
+	if p.MaxAge <= 0 {
+		return false
+	}
+	return cachedAt.IsZero() || now.Sub(cachedAt) > p.MaxAge
+}
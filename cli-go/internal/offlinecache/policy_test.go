@@ -0,0 +1,36 @@
+package offlinecache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("7d", "", "envault.json")
+	if err != nil || p.MaxAge != 7*24*time.Hour || !p.Hard {
+		t.Fatalf("expected a hard 7d policy, got %+v (%v)", p, err)
+	}
+	p, err = ParsePolicy("36h", "soft", "config")
+	if err != nil || p.MaxAge != 36*time.Hour || p.Hard {
+		t.Fatalf("expected a soft 36h policy, got %+v (%v)", p, err)
+	}
+	for _, bad := range [][2]string{{"soon", ""}, {"0d", ""}, {"-1h", ""}, {"1h", "strict"}} {
+		if _, err := ParsePolicy(bad[0], bad[1], "test"); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestPolicyExceeded(t *testing.T) {
+	now := time.Now()
+	p := Policy{MaxAge: time.Hour}
+	if p.Exceeded(now.Add(-30*time.Minute), now) {
+		t.Fatalf("a 30m old entry must not exceed a 1h policy")
+	}
+	if !p.Exceeded(now.Add(-2*time.Hour), now) {
+		t.Fatalf("a 2h old entry must exceed a 1h policy")
+	}
+	if (Policy{}).Exceeded(now.Add(-24*365*time.Hour), now) {
+		t.Fatalf("a policy without max age must never be exceeded")
+	}
+}
@@ -6,22 +6,59 @@ import (
 )
 
 const (
-	cacheFileName = "offline_cache.enc"
-	cacheVersion  = 1
+	cacheDirName        = "offline_cache"
+	entryFileSuffix     = ".enc"
+	lockFileName        = ".lock"
+	legacyCacheFileName = "offline_cache.enc"
+
+	legacyCacheVersion = 1
+	cacheVersion       = 2
 )
 
 var ErrCacheMiss = errors.New("offline cache entry not found")
 
+// ErrEntryExpired is returned by Load for an entry past its expiry.
+var ErrEntryExpired = errors.New("offline cache entry has expired")
+
+// ErrNewerEntry is returned by Import when the cache already holds newer
+// secrets for the environment.
+var ErrNewerEntry = errors.New("offline cache already holds a newer entry")
+
+// EntryInfo describes a cache entry without its values. Size is the number of
+// bytes of key names and values held in the entry. ExpiresAt is zero unless
+// the entry was imported from a bundle.
+type EntryInfo struct {
+	ProjectID   string
+	Environment string
+	CachedAt    time.Time
+	ExpiresAt   time.Time
+	Keys        []string
+	Size        int
+}
+
+// UnreadableEntry is a cache file that could not be decrypted or does not
+// match its file name, such as a corrupt entry or one swapped in from another
+// environment. List and Purge skip it instead of failing; Removed reports
+// whether Purge deleted it.
+type UnreadableEntry struct {
+	File    string
+	Err     error
+	Removed bool
+}
+
 type Secret struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
 
 type cacheEntry struct {
-	Secrets  []Secret  `json:"secrets"`
-	CachedAt time.Time `json:"cached_at"`
+	Secrets   []Secret  `json:"secrets"`
+	CachedAt  time.Time `json:"cached_at"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
 }
 
-type cachePayload struct {
+// legacyCachePayload is the content of the single offline_cache.enc file
+// written before entries were split into their own files.
+type legacyCachePayload struct {
 	Entries map[string]cacheEntry `json:"entries"`
 }
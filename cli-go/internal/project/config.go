@@ -4,12 +4,54 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 )
 
 type Config struct {
-	ProjectId          string            `json:"projectId"`
-	DefaultEnvironment string            `json:"defaultEnvironment,omitempty"`
-	EnvironmentFiles   map[string]string `json:"environmentFiles,omitempty"`
+	ProjectId           string                `json:"projectId"`
+	DefaultEnvironment  string                `json:"defaultEnvironment,omitempty"`
+	EnvironmentFiles    map[string]string     `json:"environmentFiles,omitempty"`
+	EnvironmentMappings map[string]KeyMapping `json:"environmentMappings,omitempty"`
+	Sources             []Source              `json:"sources,omitempty"`
+	Cache               *CachePolicy          `json:"cache,omitempty"`
+}
+
+// CachePolicy limits how long the offline cache may stand in for the API.
+// MaxAge takes a duration such as "72h" or "7d"; MaxAgeMode is "hard"
+// (refuse older entries, the default) or "soft" (warn).
+type CachePolicy struct {
+	MaxAge     string `json:"maxAge,omitempty"`
+	MaxAgeMode string `json:"maxAgeMode,omitempty"`
+}
+
+// Source is an additional project environment whose secrets are layered
+// under the linked project's. An empty Environment means the environment
+// being run.
+type Source struct {
+	ProjectId   string `json:"projectId"`
+	Environment string `json:"environment,omitempty"`
+}
+
+// KeyMapping changes the names under which an environment's secrets are
+// exposed to a process. A key listed in Rename takes exactly that name;
+// every other key has StripPrefix removed and then AddPrefix prepended.
+type KeyMapping struct {
+	Rename      map[string]string `json:"rename,omitempty"`
+	StripPrefix string            `json:"stripPrefix,omitempty"`
+	AddPrefix   string            `json:"addPrefix,omitempty"`
+}
+
+// Apply returns the name under which key is exposed.
+func (m KeyMapping) Apply(key string) string {
+	if renamed, ok := m.Rename[key]; ok && renamed != "" {
+		return renamed
+	}
+	return m.AddPrefix + strings.TrimPrefix(key, m.StripPrefix)
+}
+
+// IsZero reports whether the mapping leaves every key unchanged.
+func (m KeyMapping) IsZero() bool {
+	return len(m.Rename) == 0 && m.StripPrefix == "" && m.AddPrefix == ""
 }
 
 func ReadConfig() (Config, error) {
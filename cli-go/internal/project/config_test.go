@@ -139,3 +139,46 @@ func TestWriteConfig_FilePermissions(t *testing.T) {
 		t.Errorf("envault.json should not be executable, got mode %o", mode)
 	}
 }
+
+func TestKeyMapping_Apply(t *testing.T) {
+	m := KeyMapping{
+		Rename:      map[string]string{"DATABASE_URL": "PRISMA_DATABASE_URL"},
+		StripPrefix: "APP_",
+		AddPrefix:   "NEXT_PUBLIC_",
+	}
+	cases := map[string]string{
+		"DATABASE_URL": "PRISMA_DATABASE_URL",
+		"APP_API_HOST": "NEXT_PUBLIC_API_HOST",
+		"REGION":       "NEXT_PUBLIC_REGION",
+	}
+	for in, want := range cases {
+		if got := m.Apply(in); got != want {
+			t.Errorf("Apply(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if (KeyMapping{}).Apply("KEY") != "KEY" || !(KeyMapping{}).IsZero() {
+		t.Errorf("zero mapping must leave keys unchanged")
+	}
+}
+
+func TestReadConfig_EnvironmentMappings(t *testing.T) {
+	tmp := t.TempDir()
+	chdir(t, tmp)
+
+	raw := `{"projectId":"aaaaaaaa-bbbb-4ccc-8ddd-eeeeeeeeeeee","environmentMappings":{"production":{"rename":{"DATABASE_URL":"PRISMA_DATABASE_URL"},"stripPrefix":"APP_"}}}`
+	if err := os.WriteFile("envault.json", []byte(raw), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := ReadConfig()
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	m := cfg.EnvironmentMappings["production"]
+	if m.Rename["DATABASE_URL"] != "PRISMA_DATABASE_URL" || m.StripPrefix != "APP_" {
+		t.Fatalf("unexpected mapping: %+v", m)
+	}
+	if _, err := json.Marshal(cfg); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+}
@@ -36,7 +36,10 @@ type ProjectsResponse struct {
 // SelectProject handles the interactive flow to select or create a project.
 // Returns the selected Project ID.
 func SelectProject() (string, error) {
-	client := api.NewClient()
+	client, err := api.LoadClient()
+	if err != nil {
+		return "", err
+	}
 	s := ui.NewLoader(ui.LoaderThemeFetch, "VaultPulse fetching your projects...")
 	s.Start()
 
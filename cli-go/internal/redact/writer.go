@@ -0,0 +1,215 @@
+// Package redact removes secret values from streamed output.
+package redact
+
+import (
+	"encoding/base64"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MinValueLength is the shortest secret value that is redacted. Shorter values
+// ("1", "true", "dev") would mask ordinary output far too eagerly.
+const MinValueLength = 6
+
+// holdDelay bounds how long a trailing partial match is held back before it
+// is shown as heldMask, so interactive output never stalls on it.
+const holdDelay = 50 * time.Millisecond
+
+// heldMask stands in for a partial match that was held for holdDelay. Every
+// replacement starts with it, so a secret completed by a later write reads
+// as ***KEY*** all the same.
+const heldMask = "***"
+
+type pattern struct {
+	needle      []byte
+	replacement []byte
+}
+
+// Writer replaces every occurrence of a secret value, and of its base64 and
+// URL-encoded forms, with ***KEY*** before passing output to the underlying
+// writer. Matches split across writes are still caught: bytes that could be
+// the start of a secret are held back until the next write. After a short
+// delay they are shown masked, but still matched against what follows.
+type Writer struct {
+	out     io.Writer
+	byFirst map[byte][]pattern
+
+	mu      sync.Mutex
+	pending []byte
+	// masked counts the leading pending bytes already written as heldMask.
+	masked int
+	timer  *time.Timer
+	err    error
+}
+
+// NewWriter returns a Writer that redacts the given key/value pairs.
+func NewWriter(out io.Writer, secrets map[string]string) *Writer {
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	seen := map[string]bool{}
+	byFirst := map[byte][]pattern{}
+	for _, key := range keys {
+		value := secrets[key]
+		if len(value) < MinValueLength {
+			continue
+		}
+		replacement := []byte("***" + key + "***")
+		for _, form := range encodedForms(value) {
+			if seen[form] {
+				continue
+			}
+			seen[form] = true
+			byFirst[form[0]] = append(byFirst[form[0]], pattern{needle: []byte(form), replacement: replacement})
+		}
+	}
+	// Prefer the longest match at any position.
+	for b := range byFirst {
+		sort.SliceStable(byFirst[b], func(i, j int) bool {
+			return len(byFirst[b][i].needle) > len(byFirst[b][j].needle)
+		})
+	}
+
+	return &Writer{out: out, byFirst: byFirst}
+}
+
+func encodedForms(value string) []string {
+	return []string{
+		value,
+		base64.StdEncoding.EncodeToString([]byte(value)),
+		base64.RawStdEncoding.EncodeToString([]byte(value)),
+		base64.URLEncoding.EncodeToString([]byte(value)),
+		base64.RawURLEncoding.EncodeToString([]byte(value)),
+		url.QueryEscape(value),
+		url.PathEscape(value),
+	}
+}
+
+// Write redacts p and writes everything that cannot be part of a secret. It
+// always reports len(p) bytes written unless the underlying writer failed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+
+	w.pending = append(w.pending, p...)
+	emit, keep := w.redact(w.pending, false, w.masked)
+	w.masked = max(0, w.masked-(len(w.pending)-len(keep)))
+	w.pending = append(w.pending[:0], keep...)
+	if len(emit) > 0 {
+		if _, err := w.out.Write(emit); err != nil {
+			w.err = err
+			return 0, err
+		}
+	}
+	if len(w.pending) > w.masked {
+		w.timer = time.AfterFunc(holdDelay, w.maskHeld)
+	}
+	return len(p), nil
+}
+
+// maskHeld shows a partial match that has been held for holdDelay as
+// heldMask. The bytes stay pending, so a secret whose rest arrives later is
+// still recognised instead of leaking across the two writes.
+func (w *Writer) maskHeld() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.timer = nil
+	if w.err != nil || len(w.pending) <= w.masked {
+		return
+	}
+	if w.masked == 0 {
+		if _, err := w.out.Write([]byte(heldMask)); err != nil {
+			w.err = err
+			return
+		}
+	}
+	w.masked = len(w.pending)
+}
+
+// Flush writes any held-back bytes that have not been masked yet; the
+// stream has ended, so they can no longer complete a secret.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.err != nil || len(w.pending) == 0 {
+		return w.err
+	}
+	emit, _ := w.redact(w.pending, true, w.masked)
+	w.pending = w.pending[:0]
+	w.masked = 0
+	if _, err := w.out.Write(emit); err != nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// redact replaces complete matches in buf. Unless final is set, a tail of buf
+// that is a proper prefix of some secret is returned as keep instead. The
+// first skip bytes were already written as heldMask, so they are not written
+// again, and a match starting among them continues that mask.
+func (w *Writer) redact(buf []byte, final bool, skip int) (emit, keep []byte) {
+	emit = make([]byte, 0, len(buf))
+	for i := 0; i < len(buf); {
+		candidates := w.byFirst[buf[i]]
+		matched := false
+		for _, p := range candidates {
+			if hasPrefix(buf[i:], p.needle) {
+				if i < skip {
+					emit = append(emit, p.replacement[len(heldMask):]...)
+				} else {
+					emit = append(emit, p.replacement...)
+				}
+				i += len(p.needle)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if !final {
+			for _, p := range candidates {
+				if len(buf)-i < len(p.needle) && hasPrefix(p.needle, buf[i:]) {
+					return emit, buf[i:]
+				}
+			}
+		}
+		if i >= skip {
+			emit = append(emit, buf[i])
+		}
+		i++
+	}
+	return emit, nil
+}
+
+func hasPrefix(s, prefix []byte) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+const testSecret = "s3cr3t/value+with=chars"
+
+func TestWriter_RedactsValueAndEncodedForms(t *testing.T) {
+	cases := map[string]string{
+		"raw":         testSecret,
+		"base64":      base64.StdEncoding.EncodeToString([]byte(testSecret)),
+		"base64url":   base64.RawURLEncoding.EncodeToString([]byte(testSecret)),
+		"queryEscape": url.QueryEscape(testSecret),
+		"pathEscape":  url.PathEscape(testSecret),
+	}
+	for name, form := range cases {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewWriter(&out, map[string]string{"API_KEY": testSecret})
+			_, _ = w.Write([]byte("token=" + form + "\n"))
+			_ = w.Flush()
+
+			if got, want := out.String(), "token=***API_KEY***\n"; got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestWriter_MatchesAcrossWriteBoundaries(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, map[string]string{"API_KEY": testSecret})
+
+	input := "before " + testSecret + " after " + testSecret
+	for i := 0; i < len(input); i++ {
+		_, _ = w.Write([]byte{input[i]})
+	}
+	_ = w.Flush()
+
+	if got, want := out.String(), "before ***API_KEY*** after ***API_KEY***"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if strings.Contains(out.String(), "s3cr3t") {
+		t.Fatalf("secret leaked: %q", out.String())
+	}
+}
+
+func TestWriter_WritesUnrelatedOutputImmediately(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, map[string]string{"API_KEY": testSecret})
+
+	_, _ = w.Write([]byte("$ prompt> "))
+	if got := out.String(); got != "$ prompt> " {
+		t.Fatalf("expected output to pass through without buffering, got %q", got)
+	}
+}
+
+func TestWriter_MasksHeldPrefixAfterDelay(t *testing.T) {
+	var out syncBuffer
+	w := NewWriter(&out, map[string]string{"API_KEY": testSecret})
+
+	_, _ = w.Write([]byte("progress s3c"))
+	if got := out.String(); got != "progress " {
+		t.Fatalf("expected possible secret prefix to be held back, got %q", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for out.String() != "progress ***" {
+		if time.Now().After(deadline) {
+			t.Fatalf("held bytes were not masked, got %q", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	_, _ = w.Write([]byte("ond pass\n"))
+	_ = w.Flush()
+	if got, want := out.String(), "progress ***ond pass\n"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriter_RedactsSecretSplitAcrossDelayedWrites(t *testing.T) {
+	var out syncBuffer
+	w := NewWriter(&out, map[string]string{"API_KEY": testSecret})
+
+	half := len(testSecret) / 2
+	_, _ = w.Write([]byte("token=" + testSecret[:half]))
+	time.Sleep(3 * holdDelay)
+	_, _ = w.Write([]byte(testSecret[half:] + "\n"))
+	_ = w.Flush()
+
+	if got, want := out.String(), "token=***API_KEY***\n"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriter_PrefersLongestMatch(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, map[string]string{
+		"SHORT": "abcdef",
+		"LONG":  "abcdefghij",
+	})
+	_, _ = w.Write([]byte("abcdefghij abcdef"))
+	_ = w.Flush()
+
+	if got, want := out.String(), "***LONG*** ***SHORT***"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriter_IgnoresShortValues(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, map[string]string{"DEBUG": "true", "PORT": "3000"})
+	_, _ = w.Write([]byte("debug=true port=3000"))
+	_ = w.Flush()
+
+	if got, want := out.String(), "debug=true port=3000"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
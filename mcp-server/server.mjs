@@ -244,22 +244,120 @@ function resolveBaseUrl() {
   return candidate.replace(/\/+$/, "");
 }
 
-function getStandaloneToken() {
+// A command that prints a fresh token, as a JSON argv array. `envault mcp
+// install` writes one (`envault mcp token ...`) so that no token is stored in
+// the client config; the token is fetched when first needed and again
+// shortly before it expires.
+const TOKEN_REFRESH_MARGIN_MS = 2 * 60 * 1000;
+let commandToken = null;
+let pendingCommandToken = null;
+
+function parseTokenCommand() {
+  const raw = (process.env.ENVAULT_TOKEN_COMMAND || "").trim();
+  if (!raw) return null;
+  const argv = safeJsonParse(raw, null);
+  if (
+    Array.isArray(argv) &&
+    argv.length > 0 &&
+    argv.every((part) => typeof part === "string")
+  ) {
+    return argv;
+  }
+  return raw.split(/\s+/);
+}
+
+function tokenExpiresAt(token) {
+  const parts = token.replace(/^envault_agt_/, "").split(".");
+  if (parts.length !== 3) return null;
+  const claims = safeJsonParse(
+    Buffer.from(parts[1], "base64url").toString("utf8"),
+    null,
+  );
+  return typeof claims?.exp === "number" ? claims.exp * 1000 : null;
+}
+
+function usesTokenCommand() {
+  return !(process.env.ENVAULT_TOKEN || "").trim() && Boolean(parseTokenCommand());
+}
+
+function hasStandaloneToken() {
+  return Boolean((process.env.ENVAULT_TOKEN || "").trim() || parseTokenCommand());
+}
+
+async function runTokenCommand(argv) {
+  try {
+    const { stdout } = await execFileAsync(argv[0], argv.slice(1), {
+      env: { ...process.env, ENVAULT_CLI_ACTOR_SOURCE: "mcp" },
+      timeout: 30000,
+      maxBuffer: 64 * 1024,
+    });
+    const token = stdout.trim().split(/\r?\n/).pop()?.trim() || "";
+    if (!token.startsWith("envault_")) {
+      return { token: null, error: "ENVAULT_TOKEN_COMMAND did not print a token." };
+    }
+    commandToken = { token, expiresAt: tokenExpiresAt(token) };
+    return { token };
+  } catch (error) {
+    commandToken = null;
+    const detail = (
+      error?.stderr?.trim?.() ||
+      error?.message ||
+      "Unknown error"
+    ).replace(/\.?$/, ".");
+    return {
+      token: null,
+      error: `ENVAULT_TOKEN_COMMAND failed: ${detail} Run \`envault login\` and restart the MCP server.`,
+    };
+  }
+}
+
+async function getStandaloneToken({ forceRefresh = false } = {}) {
   const token = (process.env.ENVAULT_TOKEN || "").trim();
-  return token || null;
+  if (token) return { token };
+
+  const argv = parseTokenCommand();
+  if (!argv) return { token: null };
+
+  const fresh =
+    commandToken &&
+    (commandToken.expiresAt === null ||
+      commandToken.expiresAt - Date.now() > TOKEN_REFRESH_MARGIN_MS);
+  if (fresh && !forceRefresh) return { token: commandToken.token };
+
+  if (!pendingCommandToken) {
+    pendingCommandToken = runTokenCommand(argv).finally(() => {
+      pendingCommandToken = null;
+    });
+  }
+  return pendingCommandToken;
 }
 
-async function callEnvaultApi({ pathName, method = "GET", query, body }) {
-  const token = getStandaloneToken();
-  if (!token) {
+async function callEnvaultApi(request) {
+  const credential = await getStandaloneToken();
+  if (!credential.token) {
     return {
       ok: false,
       status: 401,
       error:
+        credential.error ||
         "ENVAULT_TOKEN is not configured. Add ENVAULT_TOKEN in MCP env for standalone mode.",
     };
   }
 
+  const result = await sendEnvaultApi(request, credential.token);
+  if (result.status !== 401 || !usesTokenCommand()) {
+    return result;
+  }
+
+  // The token may have been revoked or rotated; fetch a new one once.
+  const refreshed = await getStandaloneToken({ forceRefresh: true });
+  if (!refreshed.token) {
+    return { ...result, error: refreshed.error || result.error };
+  }
+  return sendEnvaultApi(request, refreshed.token);
+}
+
+async function sendEnvaultApi({ pathName, method = "GET", query, body }, token) {
   const baseUrl = resolveBaseUrl();
   const url = new URL(`${baseUrl}${pathName}`);
   if (query && typeof query === "object") {
@@ -318,6 +416,14 @@ function resolveAgentId() {
 }
 
 async function delegateAgentToken(projectId) {
+  // A token from ENVAULT_TOKEN_COMMAND is already a scoped agent token.
+  if (usesTokenCommand()) {
+    const credential = await getStandaloneToken();
+    if (credential.token?.startsWith("envault_agt_")) {
+      return { ok: true, status: 200, token: credential.token };
+    }
+  }
+
   const result = await callEnvaultApi({
     pathName: "/api/sdk/auth/delegate",
     method: "POST",
@@ -1006,7 +1112,7 @@ server.setRequestHandler(CallToolRequestSchema, async (request) => {
   const { name, arguments: args = {} } = request.params;
   const cwd = process.cwd();
   const config = await readEnvaultConfig(cwd);
-  const standaloneToken = getStandaloneToken();
+  const standaloneToken = hasStandaloneToken();
 
   if (name === "envault_status" || name === "envault_context") {
     if (standaloneToken) {
@@ -1589,6 +1695,13 @@ async function main() {
 
   const transport = new StdioServerTransport();
   await server.connect(transport);
+
+  if (usesTokenCommand()) {
+    const credential = await getStandaloneToken();
+    if (credential.error) {
+      process.stderr.write(`[envault-mcp-server] ${credential.error}\n`);
+    }
+  }
 }
 
 const invokedAsScript = (() => {
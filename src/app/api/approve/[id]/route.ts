@@ -4,6 +4,13 @@ import { createServerClient } from "@supabase/ssr";
 import { createClient } from "@supabase/supabase-js";
 import { verifyHmacSignature } from "@/lib/utils/hmac";
 import { validateCliToken } from "@/lib/auth/cli-auth";
+import { applyApprovedMutations } from "@/lib/auth/approval-mutations";
+import {
+  ApprovalConstraintsSchema,
+  requestedEnvironment,
+  requestedKeys,
+  type ApprovalConstraints,
+} from "@/lib/auth/approvals";
 
 // The service role is needed to update the `pending_approvals` status due to RLS locking down inserts/updates
 const supabaseService = createClient(
@@ -11,28 +18,6 @@ const supabaseService = createClient(
   process.env.SUPABASE_SERVICE_ROLE_KEY!,
 );
 
-type PendingMutation = {
-  key: string;
-  value?: string;
-  action: "upsert" | "delete";
-};
-
-type PendingPayload = {
-  mutations: PendingMutation[];
-};
-
-type ProjectEnvironment = {
-  id: string;
-  slug: string;
-  is_default: boolean | null;
-};
-
-type ActorSnapshot = {
-  id: string;
-  name: string;
-  email: string;
-};
-
 type ApprovalActor = {
   id: string;
   email: string;
@@ -59,8 +44,10 @@ export async function POST(
 
   const rawBody = await req.text();
   let action: unknown;
+  let reason: unknown;
+  let constraints: unknown;
   try {
-    action = JSON.parse(rawBody).action;
+    ({ action, reason, constraints } = JSON.parse(rawBody));
   } catch {
     return NextResponse.json(
       { error: "Invalid action payload" },
@@ -75,6 +62,42 @@ export async function POST(
     );
   }
 
+  if (
+    reason !== undefined &&
+    (typeof reason !== "string" || reason.length > 500)
+  ) {
+    return NextResponse.json(
+      { error: "Reason must be a string of at most 500 characters" },
+      { status: 400 },
+    );
+  }
+  const decisionReason =
+    typeof reason === "string" && reason.trim() ? reason.trim() : null;
+
+  // Constraints from `envault approve --for/--keys/--env` narrow what this
+  // approval covers and, with a duration, turn it into a time-boxed grant.
+  let approvalConstraints: ApprovalConstraints = {};
+  if (constraints !== undefined) {
+    if (action !== "approve") {
+      return NextResponse.json(
+        { error: "Constraints only apply to approvals" },
+        { status: 400 },
+      );
+    }
+    const parsedConstraints = ApprovalConstraintsSchema.safeParse(constraints);
+    if (!parsedConstraints.success) {
+      return NextResponse.json(
+        {
+          error:
+            parsedConstraints.error.issues[0]?.message ||
+            "Invalid approval constraints",
+        },
+        { status: 400 },
+      );
+    }
+    approvalConstraints = parsedConstraints.data;
+  }
+
   const nextStatus = action === "approve" ? "approved" : "rejected";
 
   let actor: ApprovalActor;
@@ -265,261 +288,71 @@ export async function POST(
         ? payloadAnyForAudit.environmentSlug
         : null;
 
-  // 6. Execute vault mutations (when approved), then mark approval status.
-  // NOTE: This is best-effort with compensating rollback, but not fully crash-atomic.
-  // Future hardening: move this step into a single Postgres RPC transaction.
-  if (action === "approve") {
-    const payload = pendingApproval.payload_data as PendingPayload;
-    const mutations = Array.isArray(payload?.mutations)
-      ? payload.mutations
-      : [];
-
-    if (mutations.length === 0) {
-      return NextResponse.json(
-        { error: "Invalid pending payload: missing mutations" },
-        { status: 400 },
-      );
-    }
-
-    const payloadAny = pendingApproval.payload_data as Record<string, unknown>;
-    const requestedEnvironmentSlug =
-      typeof payloadAny.environment === "string"
-        ? payloadAny.environment
-        : typeof payloadAny.environmentSlug === "string"
-          ? payloadAny.environmentSlug
-          : typeof project?.default_environment_slug === "string"
-            ? project.default_environment_slug
-            : null;
-
-    const { data: projectEnvironments, error: envError } = await supabaseService
-      .from("project_environments")
-      .select("id, slug, is_default")
-      .eq("project_id", pendingApproval.project_id)
-      .order("is_default", { ascending: false })
-      .order("slug", { ascending: true });
-
-    if (envError || !projectEnvironments || projectEnvironments.length === 0) {
-      return NextResponse.json(
-        { error: "Failed to resolve target environment for approved mutation" },
-        { status: 500 },
-      );
-    }
-
-    const environments = projectEnvironments as ProjectEnvironment[];
-    const hasRequestedEnvironment =
-      typeof requestedEnvironmentSlug === "string" &&
-      requestedEnvironmentSlug.length > 0;
-
-    if (
-      hasRequestedEnvironment &&
-      !environments.some((env) => env.slug === requestedEnvironmentSlug)
-    ) {
+  // Constraints must cover the whole request.
+  const requestKeys = requestedKeys(pendingApproval.payload_data);
+  const requestEnvironment = requestedEnvironment(pendingApproval.payload_data);
+  if (approvalConstraints.keys) {
+    const allowedKeys = approvalConstraints.keys;
+    const outside = requestKeys.filter((key) => !allowedKeys.includes(key));
+    if (outside.length > 0) {
       return NextResponse.json(
         {
-          error: `Requested environment '${requestedEnvironmentSlug}' was not found in this project`,
+          error: `The request also changes ${outside.join(", ")}, which the approved keys do not include`,
         },
         { status: 400 },
       );
     }
+  }
+  if (
+    approvalConstraints.environment &&
+    requestEnvironment &&
+    requestEnvironment !== approvalConstraints.environment
+  ) {
+    return NextResponse.json(
+      {
+        error: `The request targets '${requestEnvironment}', not '${approvalConstraints.environment}'`,
+      },
+      { status: 400 },
+    );
+  }
 
-    const targetEnvironment =
-      environments.find((env) => env.slug === requestedEnvironmentSlug) ||
-      environments.find((env) => env.slug === project?.default_environment_slug) ||
-      environments.find((env) => env.is_default) ||
-      environments[0];
-
-    if (!targetEnvironment) {
-      return NextResponse.json(
-        { error: "No project environment available for approved mutation" },
-        { status: 500 },
-      );
-    }
-
-
-    const { data: actorProfile } = await supabaseService
-      .from("profiles")
-      .select("username")
-      .eq("id", actor.id)
-      .maybeSingle();
-
-    const actorSnapshot: ActorSnapshot = {
-      id: actor.id,
-      name:
-        actorProfile?.username ||
-        actor.username ||
-        "Unknown User",
-      email: actor.email,
-    };
-
-    const agentAttributionName = `Envault Agent via ${actorSnapshot.name}`;
-
-    const keys = mutations.map((m) => m.key);
-    const { data: existingSecrets, error: existingError } =
-      await supabaseService
-        .from("secrets")
-        .select("id, key, value, user_id, project_id, environment_id")
-        .eq("project_id", pendingApproval.project_id)
-        .in("key", keys);
-
-    if (existingError) {
+  // 6. Execute vault mutations (when approved), then mark approval status.
+  let appliedEnvironment: string | null = null;
+  if (action === "approve") {
+    const payloadData =
+      approvalConstraints.environment && !requestEnvironment
+        ? {
+            ...(pendingApproval.payload_data as Record<string, unknown>),
+            environment: approvalConstraints.environment,
+          }
+        : pendingApproval.payload_data;
+    const result = await applyApprovedMutations(
+      supabaseService,
+      { project_id: pendingApproval.project_id, payload_data: payloadData },
+      project,
+      actor,
+    );
+    if ("error" in result) {
       return NextResponse.json(
-        { error: "Failed to load current secrets for rollback planning" },
-        { status: 500 },
+        { error: result.error },
+        { status: result.status },
       );
     }
+    appliedEnvironment = result.environment;
+  }
 
-    const beforeMap = new Map<
-      string,
-      {
-        id: string;
-        key: string;
-        value: string;
-        user_id: string | null;
-        project_id: string;
-        environment_id: string | null;
-      }
-    >();
-    for (const secret of existingSecrets || []) {
-      if (secret.environment_id === targetEnvironment.id) {
-        beforeMap.set(secret.key, secret);
-        continue;
-      }
-
-      // Legacy rows may still carry a null environment_id. Reuse them to avoid duplicate inserts.
-      if (!beforeMap.has(secret.key) && secret.environment_id === null) {
-        beforeMap.set(secret.key, secret);
-      }
-    }
-    const createdKeys = new Set<string>();
-    const touchedKeys = new Set<string>();
-
-    for (const mutation of mutations) {
-      touchedKeys.add(mutation.key);
-      if (!beforeMap.has(mutation.key) && mutation.action === "upsert") {
-        createdKeys.add(mutation.key);
-      }
-
-      if (mutation.action === "upsert") {
-        if (typeof mutation.value !== "string") {
-          await rollbackMutations(
-            pendingApproval.project_id,
-            targetEnvironment.id,
-            actor.id,
-            beforeMap,
-            createdKeys,
-            touchedKeys,
-          );
-          return NextResponse.json(
-            { error: `Invalid upsert mutation for key ${mutation.key}` },
-            { status: 400 },
-          );
-        }
-
-        const existingSecret = beforeMap.get(mutation.key);
-
-        if (existingSecret) {
-          const isSameEnvironment =
-            existingSecret.environment_id === targetEnvironment.id;
-          const isSameValue = existingSecret.value === mutation.value;
-
-          // No-op update: same key/value already present in the target environment.
-          // Keep the operation idempotent and avoid rewriting attribution/timestamps.
-          if (isSameEnvironment && isSameValue) {
-            continue;
-          }
-
-          const { error: updateSecretError } = await supabaseService
-            .from("secrets")
-            .update({
-              environment_id: targetEnvironment.id,
-              value: mutation.value,
-              key_id: (mutation.value as string).split(":")[1] || null,
-              last_updated_by: actor.id,
-              last_updated_by_user_id_snapshot: actorSnapshot.id,
-              last_updated_by_name: agentAttributionName,
-              last_updated_by_email: actorSnapshot.email,
-              last_updated_at: new Date().toISOString(),
-            })
-            .eq("id", existingSecret.id)
-            .eq("project_id", pendingApproval.project_id);
-
-          if (updateSecretError) {
-            await rollbackMutations(
-              pendingApproval.project_id,
-              targetEnvironment.id,
-              actor.id,
-              beforeMap,
-              createdKeys,
-              touchedKeys,
-            );
-            return NextResponse.json(
-              { error: "Failed to execute approved secret mutation" },
-              { status: 500 },
-            );
-          }
-          continue;
-        }
-
-        const { error: insertSecretError } = await supabaseService
-          .from("secrets")
-          .insert({
-            project_id: pendingApproval.project_id,
-            environment_id: targetEnvironment.id,
-            user_id: actor.id,
-            key: mutation.key,
-            value: mutation.value,
-            key_id: (mutation.value as string).split(":")[1] || null,
-            created_by_user_id_snapshot: actorSnapshot.id,
-            created_by_name: agentAttributionName,
-            created_by_email: actorSnapshot.email,
-            last_updated_by: actor.id,
-            last_updated_by_user_id_snapshot: actorSnapshot.id,
-            last_updated_by_name: agentAttributionName,
-            last_updated_by_email: actorSnapshot.email,
-            last_updated_at: new Date().toISOString(),
-          });
-
-        if (insertSecretError) {
-          await rollbackMutations(
-            pendingApproval.project_id,
-            targetEnvironment.id,
-            actor.id,
-            beforeMap,
-            createdKeys,
-            touchedKeys,
-          );
-          return NextResponse.json(
-            { error: "Failed to execute approved secret mutation" },
-            { status: 500 },
-          );
-        }
-      }
-
-      if (mutation.action === "delete") {
-        const { error: deleteError } = await supabaseService
-          .from("secrets")
-          .delete()
-          .eq("project_id", pendingApproval.project_id)
-          .eq("environment_id", targetEnvironment.id)
-          .eq("key", mutation.key);
-
-        if (deleteError) {
-          await rollbackMutations(
-            pendingApproval.project_id,
-            targetEnvironment.id,
-            actor.id,
-            beforeMap,
-            createdKeys,
-            touchedKeys,
-          );
-          return NextResponse.json(
-            { error: "Failed to execute approved secret mutation" },
-            { status: 500 },
-          );
+  const grant =
+    action === "approve" && approvalConstraints.duration_seconds
+      ? {
+          expires_at: new Date(
+            Date.now() + approvalConstraints.duration_seconds * 1000,
+          ).toISOString(),
+          keys: approvalConstraints.keys ?? requestKeys,
+          environment: appliedEnvironment,
+          revoked_at: null,
+          active: true,
         }
-      }
-    }
-  }
+      : null;
 
   // 5. Audit Log Injection
   // We insert into audit_logs showing a human issued an agent action.
@@ -540,6 +373,9 @@ export async function POST(
       environment: auditEnvironment,
       mutation_count: auditMutationKeys.length,
       keys: auditMutationKeys,
+      reason: decisionReason,
+      constraints: constraints === undefined ? null : approvalConstraints,
+      grant_expires_at: grant?.expires_at ?? null,
       agent_id: pendingApproval.agent_id,
       agent_label: "Envault Agent",
     },
@@ -551,7 +387,19 @@ export async function POST(
 
   const { error: updateError } = await supabaseService
     .from("pending_approvals")
-    .update({ status: nextStatus })
+    .update({
+      status: nextStatus,
+      decided_by: actor.id,
+      decided_at: new Date().toISOString(),
+      decision_reason: decisionReason,
+      ...(grant
+        ? {
+            grant_expires_at: grant.expires_at,
+            grant_keys: grant.keys,
+            grant_environment: grant.environment,
+          }
+        : {}),
+    })
     .eq("id", approvalId)
     .eq("status", "pending");
 
@@ -565,64 +413,9 @@ export async function POST(
   return NextResponse.json({
     success: true,
     message: `Request has been ${nextStatus}`,
+    environment: appliedEnvironment ?? requestEnvironment,
+    keys: requestKeys,
+    reason: decisionReason,
+    grant,
   });
 }
-
-async function rollbackMutations(
-  projectId: string,
-  environmentId: string,
-  actingUserId: string,
-  beforeMap: Map<
-    string,
-    {
-      id: string;
-      key: string;
-      value: string;
-      user_id: string | null;
-      project_id: string;
-      environment_id: string | null;
-    }
-  >,
-  createdKeys: Set<string>,
-  touchedKeys: Set<string>,
-) {
-  // Remove rows created during this attempt.
-  if (createdKeys.size > 0) {
-    await supabaseService
-      .from("secrets")
-      .delete()
-      .eq("project_id", projectId)
-      .eq("environment_id", environmentId)
-      .in("key", Array.from(createdKeys));
-  }
-
-  // Restore previous values for rows that existed before.
-  const restoreRows = Array.from(touchedKeys)
-    .map((key) => beforeMap.get(key))
-    .filter(
-      (
-        row,
-      ): row is {
-        id: string;
-        key: string;
-        value: string;
-        user_id: string | null;
-        project_id: string;
-        environment_id: string | null;
-      } => Boolean(row),
-    )
-    .map((row) => ({
-      id: row.id,
-      key: row.key,
-      value: row.value,
-      project_id: row.project_id,
-      environment_id: row.environment_id || environmentId,
-      user_id: row.user_id || actingUserId,
-    }));
-
-  if (restoreRows.length > 0) {
-    await supabaseService.from("secrets").upsert(restoreRows, {
-      onConflict: "id",
-    });
-  }
-}
@@ -0,0 +1,92 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import {
+  APPROVAL_COLUMNS,
+  canReviewApproval,
+  summarizeApproval,
+  type ApprovalRow,
+} from "@/lib/auth/approvals";
+import { authorizeReviewer } from "../../authorize";
+
+// Ends a time-boxed grant early. Requests it already applied stay applied;
+// the agent's next request goes back to human review.
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ approvalId: string }> },
+) {
+  const { approvalId } = await params;
+  const auth = await authorizeReviewer(request);
+  if (auth instanceof NextResponse) return auth;
+
+  const supabase = createAdminClient();
+  const { data } = await supabase
+    .from("pending_approvals")
+    .select(APPROVAL_COLUMNS)
+    .eq("id", approvalId)
+    .maybeSingle();
+
+  if (
+    !data ||
+    !(await canReviewApproval(supabase, data.project_id, auth.userId))
+  ) {
+    return NextResponse.json(
+      { error: "Approval request not found" },
+      { status: 404 },
+    );
+  }
+
+  const row = data as ApprovalRow;
+  if (!row.grant_expires_at) {
+    return NextResponse.json(
+      { error: "This approval has no time-boxed grant to revoke" },
+      { status: 400 },
+    );
+  }
+  if (row.revoked_at) {
+    return NextResponse.json(
+      { error: "This grant has already been revoked" },
+      { status: 409 },
+    );
+  }
+  if (new Date(row.grant_expires_at) <= new Date()) {
+    return NextResponse.json(
+      { error: "This grant has already expired" },
+      { status: 409 },
+    );
+  }
+
+  const revokedAt = new Date().toISOString();
+  const { error } = await supabase
+    .from("pending_approvals")
+    .update({ revoked_at: revokedAt, revoked_by: auth.userId })
+    .eq("id", approvalId)
+    .is("revoked_at", null);
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+
+  const { error: auditError } = await supabase.from("audit_logs").insert({
+    project_id: row.project_id,
+    actor_id: auth.userId,
+    actor_type: "user",
+    agent_id: row.agent_id,
+    action: "AGENT_GRANT_REVOKED",
+    metadata: {
+      source: "agent_grant",
+      approval_id: approvalId,
+      keys: row.grant_keys,
+      environment: row.grant_environment,
+      grant_expires_at: row.grant_expires_at,
+      agent_id: row.agent_id,
+      agent_label: "Envault Agent",
+    },
+  });
+  if (auditError) {
+    console.error("[Agent Approval] Failed to write audit log:", auditError);
+  }
+
+  return NextResponse.json({
+    approval: summarizeApproval({ ...row, revoked_at: revokedAt }, null),
+  });
+}
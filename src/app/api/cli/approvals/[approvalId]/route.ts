@@ -0,0 +1,46 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import {
+  APPROVAL_COLUMNS,
+  canReviewApproval,
+  summarizeApproval,
+  type ApprovalRow,
+} from "@/lib/auth/approvals";
+import { authorizeReviewer } from "../authorize";
+
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ approvalId: string }> },
+) {
+  const { approvalId } = await params;
+  const auth = await authorizeReviewer(request);
+  if (auth instanceof NextResponse) return auth;
+
+  const supabase = createAdminClient();
+  const { data } = await supabase
+    .from("pending_approvals")
+    .select(APPROVAL_COLUMNS)
+    .eq("id", approvalId)
+    .maybeSingle();
+
+  // Requests in projects the caller cannot review are reported as missing.
+  if (
+    !data ||
+    !(await canReviewApproval(supabase, data.project_id, auth.userId))
+  ) {
+    return NextResponse.json(
+      { error: "Approval request not found" },
+      { status: 404 },
+    );
+  }
+
+  const { data: project } = await supabase
+    .from("projects")
+    .select("name")
+    .eq("id", data.project_id)
+    .maybeSingle();
+
+  return NextResponse.json({
+    approval: summarizeApproval(data as ApprovalRow, project?.name ?? null),
+  });
+}
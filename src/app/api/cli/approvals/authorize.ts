@@ -0,0 +1,25 @@
+import { validateCliToken } from "@/lib/auth/cli-auth";
+import { NextResponse } from "next/server";
+import { humanApiLimit } from "@/lib/infra/ratelimit";
+
+// Agent requests are reviewed by people; service tokens cannot see them.
+export async function authorizeReviewer(
+  request: Request,
+): Promise<{ userId: string } | NextResponse> {
+  const result = await validateCliToken(request);
+  if ("status" in result) return result;
+
+  if (result.type !== "user") {
+    return NextResponse.json(
+      { error: "Service tokens cannot review approval requests." },
+      { status: 403 },
+    );
+  }
+
+  const { success } = await humanApiLimit.limit(`cli_human_${result.userId}`);
+  if (!success) {
+    return NextResponse.json({ error: "Too many requests." }, { status: 429 });
+  }
+
+  return { userId: result.userId };
+}
@@ -0,0 +1,83 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import {
+  APPROVAL_COLUMNS,
+  APPROVAL_TTL_MS,
+  summarizeApproval,
+  type ApprovalRow,
+} from "@/lib/auth/approvals";
+import { authorizeReviewer } from "./authorize";
+
+type JoinedProject = { id: string; name: string };
+
+// Lists the pending agent requests and the active time-boxed grants in every
+// project the caller can review (owner or editor), optionally narrowed to
+// one project with ?projectId=.
+export async function GET(request: Request) {
+  const auth = await authorizeReviewer(request);
+  if (auth instanceof NextResponse) return auth;
+
+  const supabase = createAdminClient();
+  const projectFilter = new URL(request.url).searchParams.get("projectId");
+
+  const { data: owned } = await supabase
+    .from("projects")
+    .select("id, name")
+    .eq("user_id", auth.userId);
+  const { data: memberships } = await supabase
+    .from("project_members")
+    .select("projects(id, name)")
+    .eq("user_id", auth.userId)
+    .in("role", ["owner", "editor"]);
+
+  const projectNames = new Map<string, string>();
+  for (const project of owned || []) {
+    projectNames.set(project.id, project.name);
+  }
+  for (const membership of memberships || []) {
+    const joined = (membership as { projects: unknown }).projects as
+      | JoinedProject
+      | JoinedProject[]
+      | null;
+    const project = Array.isArray(joined) ? joined[0] : joined;
+    if (project) projectNames.set(project.id, project.name);
+  }
+
+  let projectIds = Array.from(projectNames.keys());
+  if (projectFilter) {
+    projectIds = projectIds.filter((id) => id === projectFilter);
+  }
+  if (projectIds.length === 0) {
+    return NextResponse.json({ approvals: [], grants: [] });
+  }
+
+  const { data, error } = await supabase
+    .from("pending_approvals")
+    .select(APPROVAL_COLUMNS)
+    .in("project_id", projectIds)
+    .eq("status", "pending")
+    .gt("created_at", new Date(Date.now() - APPROVAL_TTL_MS).toISOString())
+    .order("created_at", { ascending: true });
+
+  const { data: grants, error: grantsError } = await supabase
+    .from("pending_approvals")
+    .select(APPROVAL_COLUMNS)
+    .in("project_id", projectIds)
+    .is("revoked_at", null)
+    .gt("grant_expires_at", new Date().toISOString())
+    .order("grant_expires_at", { ascending: true });
+
+  if (error || grantsError) {
+    return NextResponse.json(
+      { error: (error || grantsError)?.message },
+      { status: 500 },
+    );
+  }
+
+  const summarize = (row: ApprovalRow) =>
+    summarizeApproval(row, projectNames.get(row.project_id) ?? null);
+  return NextResponse.json({
+    approvals: ((data || []) as ApprovalRow[]).map(summarize),
+    grants: ((grants || []) as ApprovalRow[]).map(summarize),
+  });
+}
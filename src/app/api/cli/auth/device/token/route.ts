@@ -1,6 +1,6 @@
 import { createAdminClient } from "@/lib/supabase/admin";
 import { NextResponse } from "next/server";
-import crypto from "crypto";
+import { issueCliSession } from "@/lib/auth/cli-session";
 
 export async function POST(request: Request) {
   try {
@@ -47,66 +47,16 @@ export async function POST(request: Request) {
 
     if (session.status === "approved" && session.user_id) {
       // Success! Generate a long-lived Refresh Token and short-lived Access Token
-
-      // 1. Generate Refresh Token (1 month)
-      const secretRefreshToken = "envault_rt_" + crypto.randomUUID();
-      const deviceName = session.device_info?.hostname || "Unknown Device";
-      const refreshTokenName = `CLI Refresh Token on ${deviceName}`;
-      const rtHash = crypto
-        .createHash("sha256")
-        .update(secretRefreshToken)
-        .digest("hex");
-      const rtExpiresAt = new Date(Date.now() + 30 * 24 * 60 * 60 * 1000);
-
-      const { error: rtError } = await supabase
-        .from("personal_access_tokens")
-        .upsert(
-          {
-            user_id: session.user_id,
-            name: refreshTokenName,
-            token_hash: rtHash,
-            last_used_at: new Date().toISOString(),
-            expires_at: rtExpiresAt.toISOString(),
-            metadata: session.device_info || {},
-          },
-          { onConflict: "user_id, name" },
-        );
-
-      if (rtError) {
-        console.error("Error creating Refresh Token:", rtError);
-        return NextResponse.json(
-          { error: "Failed to generate refresh token" },
-          { status: 500 },
+      let tokens;
+      try {
+        tokens = await issueCliSession(
+          supabase,
+          session.user_id,
+          session.device_info,
         );
-      }
-
-      // 2. Generate Access Token (1 hour)
-      const secretAccessToken = "envault_at_" + crypto.randomUUID();
-      const accessTokenName = `CLI Access Token on ${deviceName}`;
-      const atHash = crypto
-        .createHash("sha256")
-        .update(secretAccessToken)
-        .digest("hex");
-      const atExpiresAt = new Date(Date.now() + 60 * 60 * 1000); // 1 hour
-
-      const { error: atError } = await supabase
-        .from("personal_access_tokens")
-        .upsert(
-          {
-            user_id: session.user_id,
-            name: accessTokenName,
-            token_hash: atHash,
-            last_used_at: new Date().toISOString(),
-            expires_at: atExpiresAt.toISOString(),
-            metadata: session.device_info || {},
-          },
-          { onConflict: "user_id, name" },
-        );
-
-      if (atError) {
-        console.error("Error creating Access Token:", atError);
+      } catch (issueError) {
         return NextResponse.json(
-          { error: "Failed to generate access token" },
+          { error: (issueError as Error).message },
           { status: 500 },
         );
       }
@@ -128,12 +78,7 @@ export async function POST(request: Request) {
         console.error("Failed to delete used session:", deleteError);
       }
 
-      return NextResponse.json({
-        access_token: secretAccessToken,
-        refresh_token: secretRefreshToken,
-        expires_in: 3600, // 1 hour in seconds
-        token_type: "Bearer",
-      });
+      return NextResponse.json(tokens);
     }
 
     return NextResponse.json({ error: "unknown_error" }, { status: 500 });
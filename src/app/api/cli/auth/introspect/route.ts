@@ -0,0 +1,191 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import crypto from "crypto";
+import jwt from "jsonwebtoken";
+import { apiRateLimit } from "@/lib/infra/ratelimit";
+import { findAgentToken } from "@/lib/auth/agent-tokens";
+import { FEDERATED_TOKEN_PREFIX } from "@/lib/auth/oidc-federation";
+
+type Introspection = {
+  active: boolean;
+  type?: "personal" | "refresh" | "service" | "agent" | "federated";
+  name?: string;
+  userId?: string;
+  email?: string;
+  agentId?: string;
+  projectId?: string;
+  projectName?: string;
+  projects?: string[];
+  // null means every environment the user's project roles allow.
+  environments?: string[] | null;
+  // Agent tokens issued with --keys only cover those keys.
+  keys?: string[] | null;
+  scopes?: string[];
+  createdAt?: string | null;
+  expiresAt?: string | null;
+  lastUsedAt?: string | null;
+};
+
+// Describes a CLI token for `envault whoami` and `envault token inspect`,
+// in the spirit of RFC 7662: holding the token is enough to inspect it, and
+// unknown, revoked or expired tokens are reported as inactive rather than
+// as errors.
+export async function POST(request: Request) {
+  try {
+    const ip = request.headers.get("x-forwarded-for") || "unknown";
+    const { success } = await apiRateLimit.limit(`cli_introspect_${ip}`);
+    if (!success) {
+      return NextResponse.json({ error: "Too many requests" }, { status: 429 });
+    }
+
+    const body = (await request.json().catch(() => ({}))) as {
+      token?: string;
+    };
+    const token = typeof body.token === "string" ? body.token.trim() : "";
+    if (!token) {
+      return NextResponse.json({ error: "Missing token" }, { status: 400 });
+    }
+
+    const result = await introspect(token);
+    if (
+      result.active &&
+      result.expiresAt &&
+      new Date(result.expiresAt) < new Date()
+    ) {
+      return NextResponse.json({ ...result, active: false });
+    }
+    return NextResponse.json(result);
+  } catch (error) {
+    console.error("Token introspection error:", error);
+    return NextResponse.json(
+      { error: "Internal Server Error" },
+      { status: 500 },
+    );
+  }
+}
+
+async function introspect(token: string): Promise<Introspection> {
+  const supabase = createAdminClient();
+  const tokenHash = crypto.createHash("sha256").update(token).digest("hex");
+
+  if (token.startsWith("envault_svc_")) {
+    const { data } = await supabase
+      .from("service_tokens")
+      .select(
+        "name, project_id, environment, created_at, expires_at, last_used_at",
+      )
+      .eq("token_hash", tokenHash)
+      .maybeSingle();
+    if (!data) return { active: false, type: "service" };
+
+    const { data: project } = await supabase
+      .from("projects")
+      .select("name")
+      .eq("id", data.project_id)
+      .maybeSingle();
+
+    return {
+      active: true,
+      type: "service",
+      name: data.name,
+      projectId: data.project_id,
+      projectName: project?.name,
+      environments: data.environment ? [data.environment] : null,
+      scopes: ["secrets:read"],
+      createdAt: data.created_at,
+      expiresAt: data.expires_at,
+      lastUsedAt: data.last_used_at,
+    };
+  }
+
+  if (token.startsWith(FEDERATED_TOKEN_PREFIX)) {
+    const { data } = await supabase
+      .from("federated_tokens")
+      .select(
+        "project_id, environment, subject, created_at, expires_at, last_used_at",
+      )
+      .eq("token_hash", tokenHash)
+      .maybeSingle();
+    if (!data) return { active: false, type: "federated" };
+
+    const { data: project } = await supabase
+      .from("projects")
+      .select("name")
+      .eq("id", data.project_id)
+      .maybeSingle();
+
+    return {
+      active: true,
+      type: "federated",
+      name: data.subject,
+      projectId: data.project_id,
+      projectName: project?.name,
+      environments: [data.environment],
+      scopes: ["secrets:read"],
+      createdAt: data.created_at,
+      expiresAt: data.expires_at,
+      lastUsedAt: data.last_used_at,
+    };
+  }
+
+  if (token.startsWith("envault_agt_")) {
+    const secret = process.env.ENVAULT_AGENT_SECRET;
+    if (!secret) return { active: false, type: "agent" };
+    try {
+      const payload = jwt.verify(
+        token.replace(/^envault_agt_/, ""),
+        secret,
+      ) as jwt.JwtPayload;
+      const record = payload.jti
+        ? await findAgentToken(supabase, payload.jti)
+        : null;
+      if (payload.jti && (!record || record.revoked_at)) {
+        return { active: false, type: "agent" };
+      }
+      return {
+        active: true,
+        type: "agent",
+        userId: payload.sub,
+        agentId: payload.act,
+        projects: Array.isArray(payload.projects) ? payload.projects : [],
+        environments: typeof payload.env === "string" ? [payload.env] : null,
+        keys: Array.isArray(payload.keys) ? payload.keys : null,
+        scopes: ["secrets:read"],
+        lastUsedAt: record?.last_used_at ?? null,
+        createdAt: payload.iat
+          ? new Date(payload.iat * 1000).toISOString()
+          : null,
+        expiresAt: payload.exp
+          ? new Date(payload.exp * 1000).toISOString()
+          : null,
+      };
+    } catch {
+      return { active: false, type: "agent" };
+    }
+  }
+
+  const type = token.startsWith("envault_rt_") ? "refresh" : "personal";
+  const { data } = await supabase
+    .from("personal_access_tokens")
+    .select("user_id, name, created_at, expires_at, last_used_at")
+    .eq("token_hash", tokenHash)
+    .maybeSingle();
+  if (!data) return { active: false, type };
+
+  const { data: userData } = await supabase.auth.admin.getUserById(
+    data.user_id,
+  );
+
+  return {
+    active: true,
+    type,
+    name: data.name,
+    userId: data.user_id,
+    email: userData?.user?.email,
+    environments: null,
+    scopes: type === "refresh" ? ["session:refresh"] : ["user"],
+    createdAt: data.created_at,
+    expiresAt: data.expires_at,
+    lastUsedAt: data.last_used_at,
+  };
+}
@@ -0,0 +1,160 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import crypto from "crypto";
+import type jwt from "jsonwebtoken";
+import { apiRateLimit } from "@/lib/infra/ratelimit";
+import {
+  FEDERATED_TOKEN_PREFIX,
+  FEDERATED_TOKEN_SECONDS,
+  TRUST_POLICY_COLUMNS,
+  type TrustPolicyRow,
+  claimedIssuer,
+  subjectMatches,
+  verifyIdentityToken,
+} from "@/lib/auth/oidc-federation";
+
+const JWT_TOKEN_TYPE = "urn:ietf:params:oauth:token-type:jwt";
+
+// Token exchange for `envault login --oidc`, in the spirit of RFC 8693: a CI
+// job's identity token that one of the project's trust policies accepts is
+// exchanged for a short-lived envault_oidc_ token for the policy's
+// environment.
+export async function POST(request: Request) {
+  try {
+    const ip = request.headers.get("x-forwarded-for") || "unknown";
+    const { success } = await apiRateLimit.limit(`cli_oidc_${ip}`);
+    if (!success) {
+      return NextResponse.json({ error: "Too many requests" }, { status: 429 });
+    }
+
+    const body = (await request.json().catch(() => ({}))) as {
+      subject_token?: unknown;
+      subject_token_type?: unknown;
+      project_id?: unknown;
+      environment?: unknown;
+    };
+    const subjectToken =
+      typeof body.subject_token === "string" ? body.subject_token.trim() : "";
+    const projectId =
+      typeof body.project_id === "string" ? body.project_id.trim() : "";
+    const environment =
+      typeof body.environment === "string" && body.environment.trim()
+        ? body.environment.trim()
+        : null;
+
+    if (body.subject_token_type !== JWT_TOKEN_TYPE || !subjectToken) {
+      return NextResponse.json(
+        { error: "A JWT subject_token is required." },
+        { status: 400 },
+      );
+    }
+    if (!projectId) {
+      return NextResponse.json(
+        { error: "Pass --project or run inside a linked project." },
+        { status: 400 },
+      );
+    }
+
+    const issuer = claimedIssuer(subjectToken);
+    if (!issuer) {
+      return NextResponse.json(
+        { error: "The identity token names no issuer." },
+        { status: 401 },
+      );
+    }
+
+    const supabase = createAdminClient();
+    const { data: policies, error } = await supabase
+      .from("oidc_trust_policies")
+      .select(TRUST_POLICY_COLUMNS)
+      .eq("project_id", projectId)
+      .eq("issuer", issuer);
+    if (error) {
+      console.error("Error loading OIDC trust policies:", error);
+      return NextResponse.json(
+        { error: "Internal Server Error" },
+        { status: 500 },
+      );
+    }
+
+    // Policies for one issuer may differ in audience, subject and
+    // environment; the first one the token fully satisfies wins.
+    let matched: { policy: TrustPolicyRow; claims: jwt.JwtPayload } | null =
+      null;
+    for (const policy of (policies || []) as TrustPolicyRow[]) {
+      if (environment && policy.environment !== environment) continue;
+      let claims: jwt.JwtPayload;
+      try {
+        claims = await verifyIdentityToken(subjectToken, policy);
+      } catch (err) {
+        console.warn("OIDC identity token rejected:", err);
+        continue;
+      }
+      if (
+        typeof claims.sub === "string" &&
+        subjectMatches(policy.subject_pattern, claims.sub)
+      ) {
+        matched = { policy, claims };
+        break;
+      }
+    }
+    if (!matched) {
+      return NextResponse.json(
+        {
+          error: `No trust policy of this project accepts this identity token from ${issuer}.`,
+        },
+        { status: 403 },
+      );
+    }
+
+    const { policy, claims } = matched;
+    const now = Math.floor(Date.now() / 1000);
+    const expiresIn = Math.min(
+      FEDERATED_TOKEN_SECONDS,
+      typeof claims.exp === "number" ? claims.exp - now : 0,
+    );
+    if (expiresIn < 60) {
+      return NextResponse.json(
+        {
+          error: "The identity token has no expiry or expires within a minute.",
+        },
+        { status: 401 },
+      );
+    }
+
+    const rawToken = `${FEDERATED_TOKEN_PREFIX}${crypto.randomBytes(32).toString("hex")}`;
+    const tokenHash = crypto.createHash("sha256").update(rawToken).digest("hex");
+    const { error: insertError } = await supabase
+      .from("federated_tokens")
+      .insert({
+        project_id: projectId,
+        policy_id: policy.id,
+        environment: policy.environment,
+        subject: claims.sub,
+        token_hash: tokenHash,
+        expires_at: new Date((now + expiresIn) * 1000).toISOString(),
+      });
+    if (insertError) {
+      console.error("Error issuing federated token:", insertError);
+      return NextResponse.json(
+        { error: "Internal Server Error" },
+        { status: 500 },
+      );
+    }
+
+    return NextResponse.json({
+      access_token: rawToken,
+      issued_token_type: "urn:ietf:params:oauth:token-type:access_token",
+      token_type: "Bearer",
+      expires_in: expiresIn,
+      project_id: projectId,
+      environment: policy.environment,
+    });
+  } catch (error) {
+    console.error("OIDC token exchange error:", error);
+    return NextResponse.json(
+      { error: "Internal Server Error" },
+      { status: 500 },
+    );
+  }
+}
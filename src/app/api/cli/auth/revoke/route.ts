@@ -0,0 +1,108 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import crypto from "crypto";
+import { apiRateLimit } from "@/lib/infra/ratelimit";
+
+const CLI_ACCESS_TOKEN_PREFIX = "CLI Access Token on ";
+const CLI_REFRESH_TOKEN_PREFIX = "CLI Refresh Token on ";
+
+function hashToken(token: string) {
+  return crypto.createHash("sha256").update(token).digest("hex");
+}
+
+// Revokes the CLI tokens presented, or with all_devices every CLI session of
+// their owner. Holding a token is proof enough: a leaked token can always be
+// used to log its session out.
+export async function POST(request: Request) {
+  try {
+    const ip = request.headers.get("x-forwarded-for") || "unknown";
+    const { success } = await apiRateLimit.limit(`cli_revoke_${ip}`);
+    if (!success) {
+      return NextResponse.json({ error: "Too many requests" }, { status: 429 });
+    }
+
+    const body = (await request.json().catch(() => ({}))) as {
+      refresh_token?: string;
+      all_devices?: boolean;
+    };
+    const { refresh_token, all_devices } = body;
+
+    const authHeader = request.headers.get("Authorization");
+    const accessToken = authHeader?.startsWith("Bearer ")
+      ? authHeader.substring(7)
+      : "";
+
+    const hashes = [accessToken, refresh_token]
+      .filter(
+        (token): token is string =>
+          typeof token === "string" &&
+          (token.startsWith("envault_at_") || token.startsWith("envault_rt_")),
+      )
+      .map(hashToken);
+
+    if (hashes.length === 0) {
+      return NextResponse.json(
+        { error: "Missing access or refresh token" },
+        { status: 400 },
+      );
+    }
+
+    const supabase = createAdminClient();
+
+    const { data: tokens, error: lookupError } = await supabase
+      .from("personal_access_tokens")
+      .select("id, user_id")
+      .in("token_hash", hashes);
+
+    if (lookupError) {
+      console.error("Error looking up tokens to revoke:", lookupError);
+      return NextResponse.json(
+        { error: "Failed to revoke tokens" },
+        { status: 500 },
+      );
+    }
+
+    if (!tokens || tokens.length === 0) {
+      // Already revoked or expired and cleaned up; nothing left to do.
+      return NextResponse.json({ revoked: 0 });
+    }
+
+    const userId = tokens[0].user_id;
+    let query = supabase
+      .from("personal_access_tokens")
+      .delete({ count: "exact" })
+      .eq("user_id", userId);
+
+    if (all_devices) {
+      query = query.or(
+        `name.ilike.${CLI_ACCESS_TOKEN_PREFIX}%,name.ilike.${CLI_REFRESH_TOKEN_PREFIX}%`,
+      );
+    } else {
+      // Revoke exactly the tokens presented. Token names only carry the
+      // device hostname, which other sessions may share, so they must not
+      // select what is deleted.
+      const ids = tokens
+        .filter((token) => token.user_id === userId)
+        .map((token) => token.id);
+      query = query.in("id", ids);
+    }
+
+    const { error: deleteError, count } = await query;
+
+    if (deleteError) {
+      console.error("Error revoking tokens:", deleteError);
+      return NextResponse.json(
+        { error: "Failed to revoke tokens" },
+        { status: 500 },
+      );
+    }
+
+    return NextResponse.json({ revoked: count ?? 0 });
+  } catch (error) {
+    console.error("Token revoke error:", error);
+    return NextResponse.json(
+      { error: "Internal Server Error" },
+      { status: 500 },
+    );
+  }
+}
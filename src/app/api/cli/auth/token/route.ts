@@ -0,0 +1,85 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import crypto from "crypto";
+import { issueCliSession } from "@/lib/auth/cli-session";
+
+// Token endpoint for `envault login --browser`: exchanges a single-use
+// authorization code and its PKCE verifier (RFC 7636) for a CLI session.
+export async function POST(request: Request) {
+  try {
+    const body = (await request.json().catch(() => ({}))) as {
+      grant_type?: string;
+      code?: string;
+      code_verifier?: string;
+      redirect_uri?: string;
+    };
+    const { grant_type, code, code_verifier, redirect_uri } = body;
+
+    if (grant_type !== "authorization_code") {
+      return NextResponse.json(
+        { error: "unsupported_grant_type" },
+        { status: 400 },
+      );
+    }
+    if (!code || !code_verifier || !redirect_uri) {
+      return NextResponse.json({ error: "invalid_request" }, { status: 400 });
+    }
+
+    const supabase = createAdminClient();
+    const codeHash = crypto.createHash("sha256").update(code).digest("hex");
+
+    // Delete on read so a code can never be exchanged twice.
+    const { data: grant, error } = await supabase
+      .from("cli_authorization_codes")
+      .delete()
+      .eq("code_hash", codeHash)
+      .select("*")
+      .maybeSingle();
+
+    if (error) {
+      console.error("Error redeeming CLI authorization code:", error);
+      return NextResponse.json(
+        { error: "Internal Server Error" },
+        { status: 500 },
+      );
+    }
+
+    if (!grant || new Date(grant.expires_at) < new Date()) {
+      return NextResponse.json({ error: "invalid_grant" }, { status: 400 });
+    }
+
+    const challenge = crypto
+      .createHash("sha256")
+      .update(code_verifier)
+      .digest("base64url");
+    const expected = Buffer.from(grant.code_challenge);
+    const actual = Buffer.from(challenge);
+    if (
+      grant.redirect_uri !== redirect_uri ||
+      expected.length !== actual.length ||
+      !crypto.timingSafeEqual(expected, actual)
+    ) {
+      return NextResponse.json({ error: "invalid_grant" }, { status: 400 });
+    }
+
+    try {
+      const tokens = await issueCliSession(
+        supabase,
+        grant.user_id,
+        grant.device_info,
+      );
+      return NextResponse.json(tokens);
+    } catch (issueError) {
+      return NextResponse.json(
+        { error: (issueError as Error).message },
+        { status: 500 },
+      );
+    }
+  } catch (error) {
+    console.error("CLI token exchange error:", error);
+    return NextResponse.json(
+      { error: "Internal Server Error" },
+      { status: 500 },
+    );
+  }
+}
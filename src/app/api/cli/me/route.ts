@@ -8,10 +8,13 @@ export async function GET(request: Request) {
     return result;
   }
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     return NextResponse.json({
       id: result.projectId,
-      email: "Service Token (CI)",
+      email:
+        result.type === "federated"
+          ? "Federated Token (CI)"
+          : "Service Token (CI)",
     });
   }
 
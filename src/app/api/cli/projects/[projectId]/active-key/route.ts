@@ -26,7 +26,7 @@ export async function GET(
   const { projectId } = await params;
   const supabase = createAdminClient();
 
-  if (result.type !== "service") {
+  if (result.type === "user") {
     const role = await getProjectRole(supabase, projectId, result.userId);
     if (!role) {
       return NextResponse.json({ error: "Unauthorized" }, { status: 403 });
@@ -0,0 +1,76 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import {
+  AGENT_TOKEN_COLUMNS,
+  summarizeAgentToken,
+  type AgentTokenRow,
+} from "@/lib/auth/agent-tokens";
+import { authorizeAgentTokenIssuer } from "../authorize";
+
+// Revokes an agent token. The record is kept so the audit trail still names
+// the agent; the project owner can revoke anyone's agent tokens, members
+// only their own.
+export async function DELETE(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string; tokenId: string }> },
+) {
+  const { projectId, tokenId } = await params;
+  const auth = await authorizeAgentTokenIssuer(request, projectId);
+  if (auth instanceof NextResponse) return auth;
+
+  const supabase = createAdminClient();
+  const { data } = await supabase
+    .from("agent_tokens")
+    .select(AGENT_TOKEN_COLUMNS)
+    .eq("id", tokenId)
+    .eq("project_id", projectId)
+    .maybeSingle();
+
+  const row = data as AgentTokenRow | null;
+  if (!row || (auth.role !== "owner" && row.created_by !== auth.userId)) {
+    return NextResponse.json(
+      { error: "Agent token not found." },
+      { status: 404 },
+    );
+  }
+  if (row.revoked_at) {
+    return NextResponse.json(
+      { error: "This agent token has already been revoked." },
+      { status: 409 },
+    );
+  }
+
+  const revokedAt = new Date().toISOString();
+  const { error } = await supabase
+    .from("agent_tokens")
+    .update({ revoked_at: revokedAt, revoked_by: auth.userId })
+    .eq("id", tokenId)
+    .is("revoked_at", null);
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+
+  const { error: auditError } = await supabase.from("audit_logs").insert({
+    project_id: projectId,
+    actor_id: auth.userId,
+    actor_type: "user",
+    agent_id: row.agent_id,
+    action: "AGENT_TOKEN_REVOKED",
+    metadata: {
+      source: "agent_token",
+      token_id: row.id,
+      environment: row.environment,
+      keys: row.keys,
+      agent_id: row.agent_id,
+    },
+  });
+  if (auditError) {
+    console.error("[Agent Tokens] Failed to write audit log:", auditError);
+  }
+
+  return NextResponse.json({
+    success: true,
+    token: summarizeAgentToken({ ...row, revoked_at: revokedAt }),
+  });
+}
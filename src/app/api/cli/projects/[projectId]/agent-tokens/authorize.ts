@@ -0,0 +1,41 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { validateCliToken } from "@/lib/auth/cli-auth";
+import { NextResponse } from "next/server";
+import { getProjectRole, type ProjectRole } from "@/lib/auth/permissions";
+import { humanApiLimit } from "@/lib/infra/ratelimit";
+
+// Agent tokens act on behalf of the person who issues them, so they are
+// issued with a personal CLI session by a member of the project.
+export async function authorizeAgentTokenIssuer(
+  request: Request,
+  projectId: string,
+): Promise<{ userId: string; role: ProjectRole } | NextResponse> {
+  const result = await validateCliToken(request);
+  if ("status" in result) return result;
+
+  if (result.type !== "user") {
+    return NextResponse.json(
+      { error: "Service tokens cannot manage agent tokens." },
+      { status: 403 },
+    );
+  }
+
+  const { success } = await humanApiLimit.limit(`cli_human_${result.userId}`);
+  if (!success) {
+    return NextResponse.json({ error: "Too many requests." }, { status: 429 });
+  }
+
+  const role = await getProjectRole(
+    createAdminClient(),
+    projectId,
+    result.userId,
+  );
+  if (!role) {
+    return NextResponse.json(
+      { error: "Forbidden: You do not have access to this project" },
+      { status: 403 },
+    );
+  }
+
+  return { userId: result.userId, role };
+}
@@ -0,0 +1,154 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import { z } from "zod";
+import {
+  AGENT_TOKEN_COLUMNS,
+  DEFAULT_AGENT_TOKEN_SECONDS,
+  IssueAgentTokenSchema,
+  agentTokenSecret,
+  signAgentToken,
+  summarizeAgentToken,
+  type AgentTokenRow,
+} from "@/lib/auth/agent-tokens";
+import { getProjectEnvironments } from "@/lib/utils/cli-environments";
+import { authorizeAgentTokenIssuer } from "./authorize";
+
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string }> },
+) {
+  const { projectId } = await params;
+  const auth = await authorizeAgentTokenIssuer(request, projectId);
+  if (auth instanceof NextResponse) return auth;
+
+  const url = new URL(request.url);
+  let query = createAdminClient()
+    .from("agent_tokens")
+    .select(AGENT_TOKEN_COLUMNS)
+    .eq("project_id", projectId)
+    .order("created_at", { ascending: false });
+
+  const agentId = url.searchParams.get("agentId");
+  if (agentId) {
+    query = query.eq("agent_id", agentId);
+  }
+  if (url.searchParams.get("all") !== "true") {
+    query = query
+      .is("revoked_at", null)
+      .gt("expires_at", new Date().toISOString());
+  }
+
+  const { data, error } = await query;
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+
+  return NextResponse.json({
+    tokens: ((data || []) as AgentTokenRow[]).map(summarizeAgentToken),
+  });
+}
+
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string }> },
+) {
+  const { projectId } = await params;
+  const auth = await authorizeAgentTokenIssuer(request, projectId);
+  if (auth instanceof NextResponse) return auth;
+
+  let payload: z.infer<typeof IssueAgentTokenSchema>;
+  try {
+    payload = IssueAgentTokenSchema.parse(await request.json());
+  } catch (error) {
+    const message =
+      error instanceof z.ZodError
+        ? error.issues[0]?.message || "Invalid request body"
+        : "Invalid request body";
+    return NextResponse.json({ error: message }, { status: 400 });
+  }
+
+  const secret = agentTokenSecret();
+  if (!secret) {
+    return NextResponse.json(
+      { error: "Agent token secret is not configured" },
+      { status: 500 },
+    );
+  }
+
+  const supabase = createAdminClient();
+
+  if (payload.environment) {
+    const environments = await getProjectEnvironments(supabase, projectId);
+    if (!environments.some((env) => env.slug === payload.environment)) {
+      return NextResponse.json(
+        { error: `Environment "${payload.environment}" not found.` },
+        { status: 400 },
+      );
+    }
+  }
+
+  const ttlSeconds = payload.ttl_seconds ?? DEFAULT_AGENT_TOKEN_SECONDS;
+  const { data, error } = await supabase
+    .from("agent_tokens")
+    .insert({
+      project_id: projectId,
+      agent_id: payload.agent_id,
+      environment: payload.environment ?? null,
+      keys: payload.keys ?? null,
+      created_by: auth.userId,
+      expires_at: new Date(Date.now() + ttlSeconds * 1000).toISOString(),
+    })
+    .select(AGENT_TOKEN_COLUMNS)
+    .single();
+
+  if (error || !data) {
+    return NextResponse.json(
+      { error: error?.message || "Failed to issue agent token" },
+      { status: 500 },
+    );
+  }
+
+  const row = data as AgentTokenRow;
+  let rotated: string[] = [];
+  if (payload.rotate) {
+    // Rotation only ever replaces the caller's own tokens; other members'
+    // tokens for the same agent name are theirs to revoke.
+    const { data: revoked } = await supabase
+      .from("agent_tokens")
+      .update({ revoked_at: new Date().toISOString(), revoked_by: auth.userId })
+      .eq("project_id", projectId)
+      .eq("agent_id", row.agent_id)
+      .eq("created_by", auth.userId)
+      .neq("id", row.id)
+      .is("revoked_at", null)
+      .gt("expires_at", new Date().toISOString())
+      .select("id");
+    rotated = (revoked || []).map((r: { id: string }) => r.id);
+  }
+
+  const { error: auditError } = await supabase.from("audit_logs").insert({
+    project_id: projectId,
+    actor_id: auth.userId,
+    actor_type: "user",
+    agent_id: row.agent_id,
+    action: "AGENT_TOKEN_ISSUED",
+    metadata: {
+      source: "agent_token",
+      token_id: row.id,
+      environment: row.environment,
+      keys: row.keys,
+      expires_at: row.expires_at,
+      rotated_token_ids: rotated,
+      agent_id: row.agent_id,
+    },
+  });
+  if (auditError) {
+    console.error("[Agent Tokens] Failed to write audit log:", auditError);
+  }
+
+  return NextResponse.json({
+    token: signAgentToken(row, secret),
+    tokenRecord: summarizeAgentToken(row),
+    rotated,
+  });
+}
@@ -17,9 +17,9 @@ export async function GET(
 
   // Bifurcated Rate Limiting
   const ip = (await headers()).get("x-forwarded-for") || "unknown";
-  if (result.type === "service") {
+  if (result.type !== "user") {
     const { success } = await machineApiLimit.limit(
-      `cli_machine_${result.tokenId}`,
+      `cli_machine_${result.type}_${result.tokenId}`,
     );
     if (!success)
       return NextResponse.json(
@@ -39,7 +39,7 @@ export async function GET(
   const { projectId } = await params;
   const supabase = createAdminClient();
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     if (result.projectId !== projectId) {
       return NextResponse.json({ error: "Unauthorized" }, { status: 403 });
     }
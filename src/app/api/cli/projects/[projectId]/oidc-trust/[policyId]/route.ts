@@ -0,0 +1,36 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import { authorizeServiceTokenOwner } from "../../service-tokens/authorize";
+
+// Removing a policy also revokes the federated tokens issued under it.
+export async function DELETE(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string; policyId: string }> },
+) {
+  const { projectId, policyId } = await params;
+  const auth = await authorizeServiceTokenOwner(
+    request,
+    projectId,
+    "OIDC trust policies",
+  );
+  if (auth instanceof NextResponse) return auth;
+
+  const { data, error } = await createAdminClient()
+    .from("oidc_trust_policies")
+    .delete()
+    .eq("id", policyId)
+    .eq("project_id", projectId)
+    .select("id, issuer, subject_pattern");
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+  if (!data || data.length === 0) {
+    return NextResponse.json(
+      { error: "Trust policy not found." },
+      { status: 404 },
+    );
+  }
+
+  return NextResponse.json({ success: true, policy: data[0] });
+}
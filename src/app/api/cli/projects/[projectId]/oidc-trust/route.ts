@@ -0,0 +1,70 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import { z } from "zod";
+import {
+  CreateTrustPolicySchema,
+  TRUST_POLICY_COLUMNS,
+} from "@/lib/auth/oidc-federation";
+import { authorizeServiceTokenOwner } from "../service-tokens/authorize";
+
+const MANAGED = "OIDC trust policies";
+
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string }> },
+) {
+  const { projectId } = await params;
+  const auth = await authorizeServiceTokenOwner(request, projectId, MANAGED);
+  if (auth instanceof NextResponse) return auth;
+
+  const { data: policies, error } = await createAdminClient()
+    .from("oidc_trust_policies")
+    .select(TRUST_POLICY_COLUMNS)
+    .eq("project_id", projectId)
+    .order("created_at", { ascending: false });
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+
+  return NextResponse.json({ policies });
+}
+
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string }> },
+) {
+  const { projectId } = await params;
+  const auth = await authorizeServiceTokenOwner(request, projectId, MANAGED);
+  if (auth instanceof NextResponse) return auth;
+
+  let payload: z.infer<typeof CreateTrustPolicySchema>;
+  try {
+    payload = CreateTrustPolicySchema.parse(await request.json());
+  } catch (error) {
+    const message =
+      error instanceof z.ZodError
+        ? error.issues[0]?.message || "Invalid request body"
+        : "Invalid request body";
+    return NextResponse.json({ error: message }, { status: 400 });
+  }
+
+  const { data, error } = await createAdminClient()
+    .from("oidc_trust_policies")
+    .insert({
+      project_id: projectId,
+      issuer: payload.issuer.replace(/\/$/, ""),
+      audience: payload.audience,
+      subject_pattern: payload.subject_pattern,
+      environment: payload.environment,
+      created_by: auth.userId,
+    })
+    .select(TRUST_POLICY_COLUMNS)
+    .single();
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+
+  return NextResponse.json({ policy: data });
+}
@@ -25,7 +25,7 @@ export async function POST(
   }
 
   // Service tokens cannot request access - they should already have it
-  if (result.type === "service") {
+  if (result.type !== "user") {
     return NextResponse.json(
       { error: "Service tokens cannot submit access requests." },
       { status: 403 },
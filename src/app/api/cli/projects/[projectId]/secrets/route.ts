@@ -28,9 +28,9 @@ export async function GET(
 
   // Bifurcated Rate Limiting
   const ip = (await headers()).get("x-forwarded-for") || "unknown";
-  if (result.type === "service") {
+  if (result.type !== "user") {
     const { success } = await machineApiLimit.limit(
-      `cli_machine_${result.tokenId}`,
+      `cli_machine_${result.type}_${result.tokenId}`,
     );
     if (!success)
       return NextResponse.json(
@@ -90,7 +90,7 @@ export async function GET(
   }[] = [];
   let userId = "";
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     if (result.projectId !== projectId) {
       return NextResponse.json({ error: "Unauthorized" }, { status: 403 });
     }
@@ -376,7 +376,7 @@ export async function GET(
   const projectSlug = projectData?.slug || projectId;
 
   let notifUserId = userId;
-  if (!notifUserId && result.type === "service") {
+  if (!notifUserId && result.type !== "user") {
     const { data: pData } = await supabase
       .from("projects")
       .select("user_id")
@@ -424,15 +424,17 @@ export async function GET(
   // Audit Log: Batch Read (Machine Only - humans via CLI also get logged as batch)
   await logAuditEvent({
     projectId,
-    actorId: result.type === "service" ? result.tokenId : userId,
-    actorType: result.type === "service" ? "machine" : "user",
+    actorId: result.type !== "user" ? result.tokenId : userId,
+    actorType: result.type !== "user" ? "machine" : "user",
     action: "secret.read_batch",
     targetResourceId: projectId,
     metadata: {
       count: finalSecrets.length,
       environment: resolvedEnvironment.environment.slug,
       source: "cli",
-      ...(result.type === "user" ? { beneficiary_user_id: userId } : {}),
+      ...(result.type === "user"
+        ? { beneficiary_user_id: userId }
+        : { token_kind: result.type }),
     },
   });
 
@@ -453,9 +455,9 @@ export async function POST(
 
   // Bifurcated Rate Limiting
   const ip = (await headers()).get("x-forwarded-for") || "unknown";
-  if (result.type === "service") {
+  if (result.type !== "user") {
     const { success } = await machineApiLimit.limit(
-      `cli_machine_${result.tokenId}`,
+      `cli_machine_${result.type}_${result.tokenId}`,
     );
     if (!success)
       return NextResponse.json(
@@ -522,7 +524,7 @@ export async function POST(
   let actorAttributionName: string | null = null;
   let actorAttributionEmail: string | null = null;
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     return NextResponse.json(
       {
         error: "All Service Tokens are strictly read-only and cannot be used to deploy or modify secrets.",
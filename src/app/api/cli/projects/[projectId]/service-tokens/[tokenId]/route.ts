@@ -0,0 +1,31 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import { authorizeServiceTokenOwner } from "../authorize";
+
+export async function DELETE(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string; tokenId: string }> },
+) {
+  const { projectId, tokenId } = await params;
+  const auth = await authorizeServiceTokenOwner(request, projectId);
+  if (auth instanceof NextResponse) return auth;
+
+  const { data, error } = await createAdminClient()
+    .from("service_tokens")
+    .delete()
+    .eq("id", tokenId)
+    .eq("project_id", projectId)
+    .select("id, name");
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+  if (!data || data.length === 0) {
+    return NextResponse.json(
+      { error: "Service token not found." },
+      { status: 404 },
+    );
+  }
+
+  return NextResponse.json({ success: true, token: data[0] });
+}
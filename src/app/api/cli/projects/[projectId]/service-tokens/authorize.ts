@@ -0,0 +1,43 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { validateCliToken } from "@/lib/auth/cli-auth";
+import { NextResponse } from "next/server";
+import { getProjectRole } from "@/lib/auth/permissions";
+import { humanApiLimit } from "@/lib/infra/ratelimit";
+
+// Service tokens are managed by the project owner with a personal CLI
+// session; machine tokens cannot mint or revoke other machine tokens. OIDC
+// trust policies, which mint federated tokens, are guarded the same way.
+export async function authorizeServiceTokenOwner(
+  request: Request,
+  projectId: string,
+  managed = "service tokens",
+): Promise<{ userId: string } | NextResponse> {
+  const result = await validateCliToken(request);
+  if ("status" in result) return result;
+
+  if (result.type !== "user") {
+    return NextResponse.json(
+      { error: `Service tokens cannot manage ${managed}.` },
+      { status: 403 },
+    );
+  }
+
+  const { success } = await humanApiLimit.limit(`cli_human_${result.userId}`);
+  if (!success) {
+    return NextResponse.json({ error: "Too many requests." }, { status: 429 });
+  }
+
+  const role = await getProjectRole(
+    createAdminClient(),
+    projectId,
+    result.userId,
+  );
+  if (role !== "owner") {
+    return NextResponse.json(
+      { error: `Only the project owner can manage ${managed}.` },
+      { status: 403 },
+    );
+  }
+
+  return { userId: result.userId };
+}
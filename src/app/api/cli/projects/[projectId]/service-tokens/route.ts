@@ -0,0 +1,92 @@
+import { createAdminClient } from "@/lib/supabase/admin";
+import { NextResponse } from "next/server";
+import { z } from "zod";
+import crypto from "crypto";
+import { authorizeServiceTokenOwner } from "./authorize";
+
+const TOKEN_COLUMNS =
+  "id, name, environment, created_at, expires_at, last_used_at";
+
+const MAX_TTL_SECONDS = 365 * 24 * 60 * 60;
+
+const CreateServiceTokenSchema = z.object({
+  name: z.string().trim().min(1, "Name is required").max(100),
+  environment: z.string().trim().min(1, "Environment is required"),
+  ttl_seconds: z
+    .number()
+    .int()
+    .positive()
+    .max(MAX_TTL_SECONDS, "TTL cannot exceed 365 days")
+    .optional(),
+});
+
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string }> },
+) {
+  const { projectId } = await params;
+  const auth = await authorizeServiceTokenOwner(request, projectId);
+  if (auth instanceof NextResponse) return auth;
+
+  const { data: tokens, error } = await createAdminClient()
+    .from("service_tokens")
+    .select(TOKEN_COLUMNS)
+    .eq("project_id", projectId)
+    .order("created_at", { ascending: false });
+
+  if (error) {
+    return NextResponse.json({ error: error.message }, { status: 500 });
+  }
+
+  return NextResponse.json({ tokens });
+}
+
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ projectId: string }> },
+) {
+  const { projectId } = await params;
+  const auth = await authorizeServiceTokenOwner(request, projectId);
+  if (auth instanceof NextResponse) return auth;
+
+  let payload: z.infer<typeof CreateServiceTokenSchema>;
+  try {
+    payload = CreateServiceTokenSchema.parse(await request.json());
+  } catch (error) {
+    const message =
+      error instanceof z.ZodError
+        ? error.issues[0]?.message || "Invalid request body"
+        : "Invalid request body";
+    return NextResponse.json({ error: message }, { status: 400 });
+  }
+
+  const rawToken = `envault_svc_${crypto.randomBytes(32).toString("hex")}`;
+  const tokenHash = crypto.createHash("sha256").update(rawToken).digest("hex");
+  const expiresAt = payload.ttl_seconds
+    ? new Date(Date.now() + payload.ttl_seconds * 1000).toISOString()
+    : null;
+
+  const { data, error } = await createAdminClient()
+    .from("service_tokens")
+    .insert({
+      project_id: projectId,
+      name: payload.name,
+      environment: payload.environment,
+      created_by: auth.userId,
+      token_hash: tokenHash,
+      expires_at: expiresAt,
+    })
+    .select(TOKEN_COLUMNS)
+    .single();
+
+  if (error) {
+    const status = error.code === "23505" ? 409 : 500;
+    const message =
+      status === 409
+        ? `A service token named "${payload.name}" already exists.`
+        : error.message;
+    return NextResponse.json({ error: message }, { status });
+  }
+
+  return NextResponse.json({ token: rawToken, tokenRecord: data });
+}
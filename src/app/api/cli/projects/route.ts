@@ -12,7 +12,7 @@ export async function GET(request: Request) {
 
   const supabase = createAdminClient();
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     // Service tokens only see their specific project
     const { data: project } = await supabase
       .from("projects")
@@ -144,7 +144,7 @@ export async function POST(request: Request) {
     return result;
   }
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     return NextResponse.json(
       { error: "Service tokens cannot create projects" },
       { status: 403 },
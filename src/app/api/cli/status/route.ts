@@ -25,12 +25,17 @@ export async function GET(request: Request) {
   let user = { email: "" };
   let userId = "";
 
-  if (result.type === "service") {
+  if (result.type !== "user") {
     if (projectId && projectId !== result.projectId) {
       return NextResponse.json({ error: "Unauthorized" }, { status: 403 });
     }
     projectId = result.projectId;
-    user = { email: "Service Token (CI)" };
+    user = {
+      email:
+        result.type === "federated"
+          ? "Federated Token (CI)"
+          : "Service Token (CI)",
+    };
   } else {
     userId = result.userId;
     const { data: userData, error } =
@@ -48,7 +53,7 @@ export async function GET(request: Request) {
   }
 
   let role: string | null = null;
-  if (result.type === "service") {
+  if (result.type !== "user") {
     role = "owner"; // Service tokens have read/write access to their linked project
   } else {
     role = await getProjectRole(supabase, projectId, userId);
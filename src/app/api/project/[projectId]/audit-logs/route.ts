@@ -24,6 +24,9 @@ const ALLOWED_AUDIT_ACTIONS = new Set([
   "github.repo_unlinked",
   "AGENT_APPROVED_CHANGE",
   "AGENT_REJECTED_CHANGE",
+  "AGENT_GRANT_REVOKED",
+  "AGENT_TOKEN_ISSUED",
+  "AGENT_TOKEN_REVOKED",
 ]);
 
 type EnvAccessState = "none" | "some" | "all" | "unknown";
@@ -2,6 +2,13 @@ import { NextResponse } from "next/server";
 import type { NextRequest } from "next/server";
 import { createClient } from "@supabase/supabase-js";
 import { verifySdkAuth } from "@/lib/sdk/auth";
+import { validateCliToken } from "@/lib/auth/cli-auth";
+import {
+  APPROVAL_COLUMNS,
+  canReviewApproval,
+  summarizeApproval,
+  type ApprovalRow,
+} from "@/lib/auth/approvals";
 
 const supabase = createClient(
   process.env.NEXT_PUBLIC_SUPABASE_URL!,
@@ -18,6 +25,12 @@ export async function GET(
     return NextResponse.json({ error: "Missing approval ID" }, { status: 400 });
   }
 
+  // Reviewers follow a request with their CLI session (`envault approvals
+  // wait`). They never receive the payload and their reads do not burn it.
+  if (req.headers.get("authorization")?.startsWith("Bearer envault_at_")) {
+    return reviewerStatus(req, approvalId);
+  }
+
   // 1. Initial check to determine project context and ownership
   const { data: current } = await supabase
     .from("pending_approvals")
@@ -99,3 +112,42 @@ export async function GET(
     payload: burnedApproval.payload_data,
   });
 }
+
+const REVIEWER_HTTP_STATUS = {
+  pending: 202,
+  approved: 200,
+  rejected: 403,
+  expired: 410,
+} as const;
+
+async function reviewerStatus(req: NextRequest, approvalId: string) {
+  const cliAuth = await validateCliToken(req);
+  if (cliAuth instanceof NextResponse) {
+    return cliAuth;
+  }
+  if (cliAuth.type !== "user") {
+    return NextResponse.json(
+      { error: "Only user-bound CLI access tokens can follow approvals" },
+      { status: 403 },
+    );
+  }
+
+  const { data } = await supabase
+    .from("pending_approvals")
+    .select(APPROVAL_COLUMNS)
+    .eq("id", approvalId)
+    .maybeSingle();
+
+  if (
+    !data ||
+    !(await canReviewApproval(supabase, data.project_id, cliAuth.userId))
+  ) {
+    return NextResponse.json({ error: "Approval not found" }, { status: 404 });
+  }
+
+  const approval = summarizeApproval(data as ApprovalRow, null);
+  return NextResponse.json(
+    { status: approval.status, approval },
+    { status: REVIEWER_HTTP_STATUS[approval.status] },
+  );
+}
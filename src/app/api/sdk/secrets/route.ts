@@ -3,6 +3,13 @@ import type { NextRequest } from "next/server";
 import { createClient } from "@supabase/supabase-js";
 import crypto from "node:crypto";
 import { verifySdkAuth } from "@/lib/sdk/auth";
+import { applyApprovedMutations } from "@/lib/auth/approval-mutations";
+import {
+  canReviewApproval,
+  findCoveringGrant,
+  requestedEnvironment,
+  requestedKeys,
+} from "@/lib/auth/approvals";
 
 // Initialize Supabase Service Role client to bypass RLS for trusted backend operations
 const supabase = createClient(
@@ -45,7 +52,8 @@ export async function POST(req: NextRequest) {
   }
 
   const body = await req.json();
-  const { projectId, payload } = body;
+  const { projectId } = body;
+  let { payload } = body;
 
   if (!projectId || !payload) {
     return NextResponse.json(
@@ -60,7 +68,35 @@ export async function POST(req: NextRequest) {
     return authResult;
   }
 
-  const { agentId } = authResult;
+  const { agentId, userId } = authResult;
+
+  // Agent tokens issued with --env or --keys may only propose changes inside
+  // those fences; a request that names no environment targets the token's.
+  const environment = requestedEnvironment(payload);
+  if (authResult.environment && !environment) {
+    payload = { ...payload, environment: authResult.environment };
+  } else if (
+    authResult.environment &&
+    environment !== authResult.environment
+  ) {
+    return NextResponse.json(
+      {
+        error: `Context-Boundary Fenced: Token is limited to the ${authResult.environment} environment`,
+      },
+      { status: 403 },
+    );
+  }
+  const outsideKeys = authResult.keys
+    ? requestedKeys(payload).filter((key) => !authResult.keys!.includes(key))
+    : [];
+  if (outsideKeys.length > 0) {
+    return NextResponse.json(
+      {
+        error: `Context-Boundary Fenced: Token does not cover ${outsideKeys.join(", ")}`,
+      },
+      { status: 403 },
+    );
+  }
   const baseUrl = resolveSdkApprovalBaseUrl();
 
   // 2. Hash the payload for cryptographic sign-off
@@ -76,6 +112,7 @@ export async function POST(req: NextRequest) {
     .insert({
       project_id: projectId,
       agent_id: agentId,
+      requested_by: userId,
       payload_hash: payloadHash,
       payload_data: payload,
       idempotency_key: idempotencyKey,
@@ -114,6 +151,19 @@ export async function POST(req: NextRequest) {
     );
   }
 
+  // 3.5 A time-boxed grant from an earlier approval (`envault approve --for`)
+  // applies the request right away on behalf of the user who granted it.
+  const granted = await applyUnderGrant(
+    approval.id,
+    projectId,
+    agentId,
+    userId,
+    payload,
+  );
+  if (granted) {
+    return granted;
+  }
+
   // 4. Trigger Web UI Notification (Supabase WebSockets)
   await supabase.from("notifications").insert({
     project_id: projectId,
@@ -135,3 +185,115 @@ export async function POST(req: NextRequest) {
     { status: 202 },
   );
 }
+
+// Returns the response for a request covered by an active grant, or null to
+// leave it pending for a human. A grant that cannot be applied also falls
+// back to review.
+async function applyUnderGrant(
+  approvalId: string,
+  projectId: string,
+  agentId: string,
+  requestedBy: string,
+  payload: unknown,
+): Promise<NextResponse | null> {
+  const { data: project } = await supabase
+    .from("projects")
+    .select("default_environment_slug")
+    .eq("id", projectId)
+    .single();
+  const environment =
+    requestedEnvironment(payload) ?? project?.default_environment_slug;
+  if (typeof environment !== "string" || !environment) {
+    return null;
+  }
+
+  const keys = requestedKeys(payload);
+  const grant = await findCoveringGrant(supabase, {
+    projectId,
+    agentId,
+    requestedBy,
+    environment,
+    keys,
+  });
+  if (!grant?.decided_by) {
+    return null;
+  }
+
+  // Roles may have changed since the grant was given: both the user the
+  // agent acts for and the grantor must still be able to write secrets.
+  const [requesterCanWrite, grantorCanWrite] = await Promise.all([
+    canReviewApproval(supabase, projectId, requestedBy),
+    canReviewApproval(supabase, projectId, grant.decided_by),
+  ]);
+  if (!requesterCanWrite || !grantorCanWrite) {
+    return null;
+  }
+
+  const { data: grantor } = await supabase.auth.admin.getUserById(
+    grant.decided_by,
+  );
+  const result = await applyApprovedMutations(
+    supabase,
+    {
+      project_id: projectId,
+      payload_data: { ...(payload as Record<string, unknown>), environment },
+    },
+    project,
+    {
+      id: grant.decided_by,
+      email: grantor?.user?.email || "",
+      username:
+        (typeof grantor?.user?.user_metadata?.username === "string"
+          ? grantor.user.user_metadata.username
+          : "") || "Unknown User",
+    },
+  );
+  if ("error" in result) {
+    console.error("[Agent SDK] Grant could not apply request:", result.error);
+    return null;
+  }
+
+  // Recorded as approved and already consumed, like a burned approval.
+  await supabase
+    .from("pending_approvals")
+    .update({
+      status: "expired",
+      decided_by: grant.decided_by,
+      decided_at: new Date().toISOString(),
+      decision_reason: `Covered by the grant from approval ${grant.id}`,
+      grant_id: grant.id,
+    })
+    .eq("id", approvalId)
+    .eq("status", "pending");
+
+  const { error: auditError } = await supabase.from("audit_logs").insert({
+    project_id: projectId,
+    actor_id: grant.decided_by,
+    actor_type: "agent",
+    agent_id: agentId,
+    delegator_user_id: requestedBy,
+    action: "AGENT_APPROVED_CHANGE",
+    metadata: {
+      source: "agent_grant",
+      approval_id: approvalId,
+      grant_id: grant.id,
+      result: "approved",
+      environment: result.environment,
+      mutation_count: keys.length,
+      keys,
+      agent_id: agentId,
+      agent_label: "Envault Agent",
+    },
+  });
+  if (auditError) {
+    console.error("[Agent SDK] Failed to write audit log:", auditError);
+  }
+
+  return NextResponse.json({
+    message: "Applied under an active approval grant.",
+    approval_id: approvalId,
+    grant_id: grant.id,
+    grant_expires_at: grant.grant_expires_at,
+    status: "approved",
+  });
+}
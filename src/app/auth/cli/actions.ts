@@ -0,0 +1,88 @@
+"use server";
+
+import crypto from "crypto";
+import { createClient } from "@/lib/supabase/server";
+import { createAdminClient } from "@/lib/supabase/admin";
+import {
+  parseCliAuthorizationRequest,
+  type CliAuthorizationRequest,
+} from "@/lib/auth/cli-loopback";
+
+const AUTHORIZATION_CODE_TTL_MS = 5 * 60 * 1000; // 5 minutes
+
+// Approves or denies a `envault login --browser` request and returns the
+// loopback URL to send the browser to.
+export async function completeCliAuthorization(
+  request: CliAuthorizationRequest,
+  approve: boolean,
+) {
+  const supabase = await createClient();
+  const {
+    data: { user },
+  } = await supabase.auth.getUser();
+
+  if (!user) {
+    return { error: "You must be logged in to authorize the CLI." };
+  }
+
+  // Re-validate: the request object comes back from the client.
+  const checked = parseCliAuthorizationRequest({
+    response_type: "code",
+    redirect_uri: request.redirectUri,
+    code_challenge: request.codeChallenge,
+    code_challenge_method: "S256",
+    state: request.state,
+    hostname: request.hostname,
+  });
+  if ("error" in checked) {
+    return { error: checked.error };
+  }
+
+  const callback = new URL(checked.redirectUri);
+  callback.searchParams.set("state", checked.state);
+
+  if (!approve) {
+    callback.searchParams.set("error", "access_denied");
+    return { redirectTo: callback.toString() };
+  }
+
+  const code = crypto.randomBytes(32).toString("base64url");
+  const admin = createAdminClient();
+
+  // Opportunistic cleanup of unused codes
+  await admin
+    .from("cli_authorization_codes")
+    .delete()
+    .lt("expires_at", new Date().toISOString());
+
+  const deviceInfo = { hostname: checked.hostname, platform: "cli-go" };
+  const { error } = await admin.from("cli_authorization_codes").insert({
+    code_hash: crypto.createHash("sha256").update(code).digest("hex"),
+    user_id: user.id,
+    code_challenge: checked.codeChallenge,
+    redirect_uri: checked.redirectUri,
+    device_info: deviceInfo,
+    expires_at: new Date(Date.now() + AUTHORIZATION_CODE_TTL_MS).toISOString(),
+  });
+
+  if (error) {
+    console.error("Error creating CLI authorization code:", error);
+    return { error: "Failed to authorize the CLI. Please try again." };
+  }
+
+  const { createNewDeviceNotification } =
+    await import("@/lib/system/notifications");
+  await createNewDeviceNotification(user.id, checked.hostname, deviceInfo);
+
+  try {
+    if (user.email) {
+      const { sendNewDeviceEmail } = await import("@/lib/infra/email");
+      await sendNewDeviceEmail(user.email, checked.hostname, user.id);
+    }
+  } catch (emailError) {
+    console.error("Failed to send device email notification:", emailError);
+  }
+
+  callback.searchParams.set("code", code);
+  return { redirectTo: callback.toString() };
+}
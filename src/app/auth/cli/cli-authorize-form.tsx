@@ -0,0 +1,85 @@
+'use client'
+
+import { useState } from 'react'
+import { completeCliAuthorization } from './actions'
+import type { CliAuthorizationRequest } from '@/lib/auth/cli-loopback'
+import { Button } from '@/components/ui/button'
+import { Card, CardHeader, CardTitle, CardDescription, CardFooter } from '@/components/ui/card'
+import { toast } from 'sonner'
+import { Loader2 } from 'lucide-react'
+import { AuthLayout } from '@/components/auth/auth-layout'
+
+export function CliAuthorizeForm({
+    request,
+    email,
+}: {
+    request: CliAuthorizationRequest | { error: string }
+    email: string
+}) {
+    const [pending, setPending] = useState<'approve' | 'deny' | null>(null)
+
+    if ('error' in request) {
+        return (
+            <AuthLayout>
+                <div className="w-[95vw] sm:w-full sm:max-w-md mx-auto px-4">
+                    <Card className="border-muted/40 shadow-2xl backdrop-blur-sm bg-background/80">
+                        <CardHeader className="text-center">
+                            <CardTitle className="text-2xl font-bold tracking-tight">Invalid request</CardTitle>
+                            <CardDescription>
+                                {request.error} Run <code>envault login</code> again.
+                            </CardDescription>
+                        </CardHeader>
+                    </Card>
+                </div>
+            </AuthLayout>
+        )
+    }
+
+    const handleDecision = async (approve: boolean) => {
+        setPending(approve ? 'approve' : 'deny')
+        const result = await completeCliAuthorization(request, approve)
+        if (result?.error || !result?.redirectTo) {
+            setPending(null)
+            toast.error(result?.error ?? 'Failed to authorize the CLI.')
+            return
+        }
+        // Hand the code to the CLI listening on this computer.
+        window.location.href = result.redirectTo
+    }
+
+    return (
+        <AuthLayout>
+            <div className="w-[95vw] sm:w-full sm:max-w-md mx-auto px-4">
+                <Card className="border-muted/40 shadow-2xl backdrop-blur-sm bg-background/80">
+                    <CardHeader className="text-center">
+                        <CardTitle className="text-2xl font-bold tracking-tight">Authorize CLI</CardTitle>
+                        <CardDescription>
+                            Sign in to the Envault CLI on <span className="font-medium">{request.hostname}</span>
+                            {email ? <> as <span className="font-medium">{email}</span></> : null}.
+                            Only continue if you just ran <code>envault login --browser</code>.
+                        </CardDescription>
+                    </CardHeader>
+                    <CardFooter className="flex gap-2">
+                        <Button
+                            variant="outline"
+                            className="w-full"
+                            disabled={pending !== null}
+                            onClick={() => handleDecision(false)}
+                        >
+                            {pending === 'deny' ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : null}
+                            Deny
+                        </Button>
+                        <Button
+                            className="w-full"
+                            disabled={pending !== null}
+                            onClick={() => handleDecision(true)}
+                        >
+                            {pending === 'approve' ? <Loader2 className="mr-2 h-4 w-4 animate-spin" /> : null}
+                            Authorize
+                        </Button>
+                    </CardFooter>
+                </Card>
+            </div>
+        </AuthLayout>
+    )
+}
@@ -0,0 +1,37 @@
+import { createClient } from "@/lib/supabase/server";
+import { redirect } from "next/navigation";
+import { parseCliAuthorizationRequest } from "@/lib/auth/cli-loopback";
+import { CliAuthorizeForm } from "./cli-authorize-form";
+import type { Metadata } from "next";
+
+export const metadata: Metadata = {
+  title: "Authorize CLI",
+  description: "Sign in to the Envault CLI on this computer.",
+  openGraph: {
+    siteName: "Envault",
+    images: ["/open-graph/Login%20OG.png"],
+  },
+};
+
+export default async function CliAuthorizePage(props: {
+  searchParams: Promise<{ [key: string]: string | string[] | undefined }>;
+}) {
+  const searchParams = await props.searchParams;
+  const request = parseCliAuthorizationRequest(searchParams);
+
+  const supabase = await createClient();
+  const {
+    data: { user },
+  } = await supabase.auth.getUser();
+
+  if (!user) {
+    const query = new URLSearchParams(
+      Object.entries(searchParams).filter(
+        (entry): entry is [string, string] => typeof entry[1] === "string",
+      ),
+    );
+    redirect(`/login?next=${encodeURIComponent(`/auth/cli?${query}`)}`);
+  }
+
+  return <CliAuthorizeForm request={request} email={user.email ?? ""} />;
+}
@@ -156,6 +156,9 @@ const ACTION_OPTIONS = [
   { value: "github.repo_unlinked", label: "GitHub Repo Unlinked" },
   { value: "AGENT_APPROVED_CHANGE", label: "Agent Approved Change" },
   { value: "AGENT_REJECTED_CHANGE", label: "Agent Rejected Change" },
+  { value: "AGENT_GRANT_REVOKED", label: "Agent Grant Revoked" },
+  { value: "AGENT_TOKEN_ISSUED", label: "Agent Token Issued" },
+  { value: "AGENT_TOKEN_REVOKED", label: "Agent Token Revoked" },
 ];
 
 function formatDiffValue(value: unknown): string {
@@ -341,7 +344,13 @@ function getActionIcon(action: string) {
   if (action.startsWith("github.")) return <Github className="h-3.5 w-3.5" />;
   if (action === "AGENT_APPROVED_CHANGE")
     return <ShieldCheck className="h-3.5 w-3.5" />;
-  if (action === "AGENT_REJECTED_CHANGE")
+  if (action === "AGENT_TOKEN_ISSUED")
+    return <ShieldCheck className="h-3.5 w-3.5" />;
+  if (
+    action === "AGENT_REJECTED_CHANGE" ||
+    action === "AGENT_GRANT_REVOKED" ||
+    action === "AGENT_TOKEN_REVOKED"
+  )
     return <ShieldOff className="h-3.5 w-3.5" />;
   return <AlertCircle className="h-3.5 w-3.5" />;
 }
@@ -439,6 +448,9 @@ function getActionBadgeClass(action: string): string {
   if (action === "AGENT_REJECTED_CHANGE") {
     return "border-rose-500/25 bg-rose-500/10 text-rose-700 dark:text-rose-300";
   }
+  if (action === "AGENT_GRANT_REVOKED" || action === "AGENT_TOKEN_REVOKED") {
+    return "border-amber-500/25 bg-amber-500/10 text-amber-700 dark:text-amber-300";
+  }
   if (action === "transfer.requested") {
     return "border-amber-500/25 bg-amber-500/10 text-amber-700 dark:text-amber-300";
   }
@@ -712,6 +724,27 @@ function getNonDiffContextSummary(
     return `Rejected agent change from ${agentLabel}${envPart}`;
   }
 
+  if (action === "AGENT_GRANT_REVOKED") {
+    const agentLabel = getFriendlyAgentLabel(metadata);
+    const environment = metadata?.environment
+      ? String(metadata.environment)
+      : null;
+    const envPart = environment ? ` in ${environment}` : "";
+
+    return `Revoked approval grant for ${agentLabel}${envPart}`;
+  }
+
+  if (action === "AGENT_TOKEN_ISSUED" || action === "AGENT_TOKEN_REVOKED") {
+    const agentLabel = getFriendlyAgentLabel(metadata);
+    const environment = metadata?.environment
+      ? String(metadata.environment)
+      : null;
+    const envPart = environment ? ` for ${environment}` : "";
+    const verb = action === "AGENT_TOKEN_ISSUED" ? "Issued" : "Revoked";
+
+    return `${verb} agent token for ${agentLabel}${envPart}`;
+  }
+
   return null;
 }
 
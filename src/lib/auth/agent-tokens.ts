@@ -0,0 +1,106 @@
+import type { SupabaseClient } from "@supabase/supabase-js";
+import jwt from "jsonwebtoken";
+import { z } from "zod";
+
+// Agent tokens are meant to be rotated, so they never outlive a day.
+export const MAX_AGENT_TOKEN_SECONDS = 24 * 60 * 60;
+export const DEFAULT_AGENT_TOKEN_SECONDS = 60 * 60;
+
+export const AGENT_TOKEN_COLUMNS =
+  "id, project_id, agent_id, environment, keys, created_by, created_at, expires_at, revoked_at, last_used_at";
+
+export const IssueAgentTokenSchema = z
+  .object({
+    agent_id: z
+      .string()
+      .trim()
+      .min(1, "--agent is required")
+      .max(100)
+      .regex(
+        /^[A-Za-z0-9][A-Za-z0-9._:-]*$/,
+        "--agent may only contain letters, digits, '.', '_', ':' and '-'",
+      ),
+    environment: z.string().trim().min(1).optional(),
+    keys: z
+      .array(z.string().trim().min(1))
+      .min(1, "--keys needs at least one key")
+      .max(100)
+      .optional(),
+    ttl_seconds: z
+      .number()
+      .int()
+      .min(60, "--ttl must be at least a minute")
+      .max(MAX_AGENT_TOKEN_SECONDS, "--ttl cannot exceed 24h")
+      .optional(),
+    // Revokes the agent's other live tokens once the new one is issued.
+    rotate: z.boolean().optional(),
+  })
+  .strict();
+
+export type AgentTokenRow = {
+  id: string;
+  project_id: string;
+  agent_id: string;
+  environment: string | null;
+  keys: string[] | null;
+  created_by: string;
+  created_at: string;
+  expires_at: string;
+  revoked_at: string | null;
+  last_used_at: string | null;
+};
+
+export type AgentTokenSummary = AgentTokenRow & {
+  status: "active" | "expired" | "revoked";
+};
+
+export function agentTokenSecret(): string | undefined {
+  return process.env.ENVAULT_AGENT_SECRET;
+}
+
+export function summarizeAgentToken(row: AgentTokenRow): AgentTokenSummary {
+  let status: AgentTokenSummary["status"] = "active";
+  if (row.revoked_at) {
+    status = "revoked";
+  } else if (new Date(row.expires_at) <= new Date()) {
+    status = "expired";
+  }
+  return { ...row, status };
+}
+
+// Signs an envault_agt_ token for a recorded agent token. The row id is the
+// jti, which verifySdkAuth looks up to honour revocation and the fences.
+export function signAgentToken(row: AgentTokenRow, secret: string): string {
+  const signed = jwt.sign(
+    {
+      sub: row.created_by,
+      act: row.agent_id,
+      projects: [row.project_id],
+      env: row.environment ?? undefined,
+      keys: row.keys ?? undefined,
+    },
+    secret,
+    {
+      jwtid: row.id,
+      expiresIn: Math.max(
+        1,
+        Math.floor((new Date(row.expires_at).getTime() - Date.now()) / 1000),
+      ),
+    },
+  );
+  return `envault_agt_${signed}`;
+}
+
+// Looks up the record behind a token's jti. Tokens minted before agent
+// tokens were recorded carry no jti and are not checked.
+export async function findAgentToken(
+  supabase: SupabaseClient,
+  jti: string,
+): Promise<AgentTokenRow | null> {
+  const { data } = await supabase
+    .from("agent_tokens")
+    .select(AGENT_TOKEN_COLUMNS)
+    .eq("id", jti)
+    .maybeSingle();
+  return (data as AgentTokenRow | null) ?? null;
+}
@@ -0,0 +1,354 @@
+import type { SupabaseClient } from "@supabase/supabase-js";
+
+type PendingMutation = {
+  key: string;
+  value?: string;
+  action: "upsert" | "delete";
+};
+
+type PendingPayload = {
+  mutations: PendingMutation[];
+};
+
+type ProjectEnvironment = {
+  id: string;
+  slug: string;
+  is_default: boolean | null;
+};
+
+type ActorSnapshot = {
+  id: string;
+  name: string;
+  email: string;
+};
+
+export type MutationActor = {
+  id: string;
+  email: string;
+  username: string;
+};
+
+// Applies the secret mutations of an approved agent request to the target
+// environment, attributed to the approving user. It returns the slug of the
+// environment it changed, or the error to report.
+// NOTE: This is best-effort with compensating rollback, but not fully crash-atomic.
+// Future hardening: move this step into a single Postgres RPC transaction.
+export async function applyApprovedMutations(
+  supabase: SupabaseClient,
+  pendingApproval: { project_id: string; payload_data: unknown },
+  project: { default_environment_slug?: string | null } | null,
+  actor: MutationActor,
+): Promise<{ environment: string } | { error: string; status: number }> {
+  const payload = pendingApproval.payload_data as PendingPayload;
+  const mutations = Array.isArray(payload?.mutations)
+    ? payload.mutations
+    : [];
+
+  if (mutations.length === 0) {
+    return { error: "Invalid pending payload: missing mutations", status: 400 };
+  }
+
+  const payloadAny = pendingApproval.payload_data as Record<string, unknown>;
+  const requestedEnvironmentSlug =
+    typeof payloadAny.environment === "string"
+      ? payloadAny.environment
+      : typeof payloadAny.environmentSlug === "string"
+        ? payloadAny.environmentSlug
+        : typeof project?.default_environment_slug === "string"
+          ? project.default_environment_slug
+          : null;
+
+  const { data: projectEnvironments, error: envError } = await supabase
+    .from("project_environments")
+    .select("id, slug, is_default")
+    .eq("project_id", pendingApproval.project_id)
+    .order("is_default", { ascending: false })
+    .order("slug", { ascending: true });
+
+  if (envError || !projectEnvironments || projectEnvironments.length === 0) {
+    return {
+      error: "Failed to resolve target environment for approved mutation",
+      status: 500,
+    };
+  }
+
+  const environments = projectEnvironments as ProjectEnvironment[];
+  const hasRequestedEnvironment =
+    typeof requestedEnvironmentSlug === "string" &&
+    requestedEnvironmentSlug.length > 0;
+
+  if (
+    hasRequestedEnvironment &&
+    !environments.some((env) => env.slug === requestedEnvironmentSlug)
+  ) {
+    return {
+      error:
+        `Requested environment '${requestedEnvironmentSlug}' was not found in this project`,
+      status: 400,
+    };
+  }
+
+  const targetEnvironment =
+    environments.find((env) => env.slug === requestedEnvironmentSlug) ||
+    environments.find((env) => env.slug === project?.default_environment_slug) ||
+    environments.find((env) => env.is_default) ||
+    environments[0];
+
+  if (!targetEnvironment) {
+    return {
+      error: "No project environment available for approved mutation",
+      status: 500,
+    };
+  }
+
+
+  const { data: actorProfile } = await supabase
+    .from("profiles")
+    .select("username")
+    .eq("id", actor.id)
+    .maybeSingle();
+
+  const actorSnapshot: ActorSnapshot = {
+    id: actor.id,
+    name:
+      actorProfile?.username ||
+      actor.username ||
+      "Unknown User",
+    email: actor.email,
+  };
+
+  const agentAttributionName = `Envault Agent via ${actorSnapshot.name}`;
+
+  const keys = mutations.map((m) => m.key);
+  const { data: existingSecrets, error: existingError } =
+    await supabase
+      .from("secrets")
+      .select("id, key, value, user_id, project_id, environment_id")
+      .eq("project_id", pendingApproval.project_id)
+      .in("key", keys);
+
+  if (existingError) {
+    return {
+      error: "Failed to load current secrets for rollback planning",
+      status: 500,
+    };
+  }
+
+  const beforeMap = new Map<
+    string,
+    {
+      id: string;
+      key: string;
+      value: string;
+      user_id: string | null;
+      project_id: string;
+      environment_id: string | null;
+    }
+  >();
+  for (const secret of existingSecrets || []) {
+    if (secret.environment_id === targetEnvironment.id) {
+      beforeMap.set(secret.key, secret);
+      continue;
+    }
+
+    // Legacy rows may still carry a null environment_id. Reuse them to avoid duplicate inserts.
+    if (!beforeMap.has(secret.key) && secret.environment_id === null) {
+      beforeMap.set(secret.key, secret);
+    }
+  }
+  const createdKeys = new Set<string>();
+  const touchedKeys = new Set<string>();
+
+  for (const mutation of mutations) {
+    touchedKeys.add(mutation.key);
+    if (!beforeMap.has(mutation.key) && mutation.action === "upsert") {
+      createdKeys.add(mutation.key);
+    }
+
+    if (mutation.action === "upsert") {
+      if (typeof mutation.value !== "string") {
+        await rollbackMutations(
+          supabase,
+          pendingApproval.project_id,
+          targetEnvironment.id,
+          actor.id,
+          beforeMap,
+          createdKeys,
+          touchedKeys,
+        );
+        return {
+          error: `Invalid upsert mutation for key ${mutation.key}`,
+          status: 400,
+        };
+      }
+
+      const existingSecret = beforeMap.get(mutation.key);
+
+      if (existingSecret) {
+        const isSameEnvironment =
+          existingSecret.environment_id === targetEnvironment.id;
+        const isSameValue = existingSecret.value === mutation.value;
+
+        // No-op update: same key/value already present in the target environment.
+        // Keep the operation idempotent and avoid rewriting attribution/timestamps.
+        if (isSameEnvironment && isSameValue) {
+          continue;
+        }
+
+        const { error: updateSecretError } = await supabase
+          .from("secrets")
+          .update({
+            environment_id: targetEnvironment.id,
+            value: mutation.value,
+            key_id: (mutation.value as string).split(":")[1] || null,
+            last_updated_by: actor.id,
+            last_updated_by_user_id_snapshot: actorSnapshot.id,
+            last_updated_by_name: agentAttributionName,
+            last_updated_by_email: actorSnapshot.email,
+            last_updated_at: new Date().toISOString(),
+          })
+          .eq("id", existingSecret.id)
+          .eq("project_id", pendingApproval.project_id);
+
+        if (updateSecretError) {
+          await rollbackMutations(
+            supabase,
+            pendingApproval.project_id,
+            targetEnvironment.id,
+            actor.id,
+            beforeMap,
+            createdKeys,
+            touchedKeys,
+          );
+          return {
+            error: "Failed to execute approved secret mutation",
+            status: 500,
+          };
+        }
+        continue;
+      }
+
+      const { error: insertSecretError } = await supabase
+        .from("secrets")
+        .insert({
+          project_id: pendingApproval.project_id,
+          environment_id: targetEnvironment.id,
+          user_id: actor.id,
+          key: mutation.key,
+          value: mutation.value,
+          key_id: (mutation.value as string).split(":")[1] || null,
+          created_by_user_id_snapshot: actorSnapshot.id,
+          created_by_name: agentAttributionName,
+          created_by_email: actorSnapshot.email,
+          last_updated_by: actor.id,
+          last_updated_by_user_id_snapshot: actorSnapshot.id,
+          last_updated_by_name: agentAttributionName,
+          last_updated_by_email: actorSnapshot.email,
+          last_updated_at: new Date().toISOString(),
+        });
+
+      if (insertSecretError) {
+        await rollbackMutations(
+          supabase,
+          pendingApproval.project_id,
+          targetEnvironment.id,
+          actor.id,
+          beforeMap,
+          createdKeys,
+          touchedKeys,
+        );
+        return {
+          error: "Failed to execute approved secret mutation",
+          status: 500,
+        };
+      }
+    }
+
+    if (mutation.action === "delete") {
+      const { error: deleteError } = await supabase
+        .from("secrets")
+        .delete()
+        .eq("project_id", pendingApproval.project_id)
+        .eq("environment_id", targetEnvironment.id)
+        .eq("key", mutation.key);
+
+      if (deleteError) {
+        await rollbackMutations(
+          supabase,
+          pendingApproval.project_id,
+          targetEnvironment.id,
+          actor.id,
+          beforeMap,
+          createdKeys,
+          touchedKeys,
+        );
+        return {
+          error: "Failed to execute approved secret mutation",
+          status: 500,
+        };
+      }
+    }
+  }
+
+  return { environment: targetEnvironment.slug };
+}
+
+async function rollbackMutations(
+  supabase: SupabaseClient,
+  projectId: string,
+  environmentId: string,
+  actingUserId: string,
+  beforeMap: Map<
+    string,
+    {
+      id: string;
+      key: string;
+      value: string;
+      user_id: string | null;
+      project_id: string;
+      environment_id: string | null;
+    }
+  >,
+  createdKeys: Set<string>,
+  touchedKeys: Set<string>,
+) {
+  // Remove rows created during this attempt.
+  if (createdKeys.size > 0) {
+    await supabase
+      .from("secrets")
+      .delete()
+      .eq("project_id", projectId)
+      .eq("environment_id", environmentId)
+      .in("key", Array.from(createdKeys));
+  }
+
+  // Restore previous values for rows that existed before.
+  const restoreRows = Array.from(touchedKeys)
+    .map((key) => beforeMap.get(key))
+    .filter(
+      (
+        row,
+      ): row is {
+        id: string;
+        key: string;
+        value: string;
+        user_id: string | null;
+        project_id: string;
+        environment_id: string | null;
+      } => Boolean(row),
+    )
+    .map((row) => ({
+      id: row.id,
+      key: row.key,
+      value: row.value,
+      project_id: row.project_id,
+      environment_id: row.environment_id || environmentId,
+      user_id: row.user_id || actingUserId,
+    }));
+
+  if (restoreRows.length > 0) {
+    await supabase.from("secrets").upsert(restoreRows, {
+      onConflict: "id",
+    });
+  }
+}
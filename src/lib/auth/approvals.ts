@@ -0,0 +1,199 @@
+import type { SupabaseClient } from "@supabase/supabase-js";
+import { z } from "zod";
+import { getProjectRole } from "@/lib/auth/permissions";
+
+// Agents stop polling an approval after 15 minutes (see pollForApproval in
+// the SDK), so a request still pending after that can no longer take effect.
+export const APPROVAL_TTL_MS = 15 * 60 * 1000;
+
+// Grants from `envault approve --for` last at most a day.
+export const MAX_GRANT_SECONDS = 24 * 60 * 60;
+
+export const APPROVAL_COLUMNS =
+  "id, project_id, agent_id, requested_by, status, payload_data, created_at, decided_by, decided_at, decision_reason, grant_expires_at, grant_keys, grant_environment, revoked_at, grant_id";
+
+// Constraints a reviewer can attach when approving from the CLI.
+export const ApprovalConstraintsSchema = z
+  .object({
+    duration_seconds: z
+      .number()
+      .int()
+      .min(60, "--for must be at least a minute")
+      .max(MAX_GRANT_SECONDS, "--for cannot exceed 24h")
+      .optional(),
+    keys: z
+      .array(z.string().trim().min(1))
+      .min(1, "--keys needs at least one key")
+      .max(100)
+      .optional(),
+    environment: z.string().trim().min(1).optional(),
+  })
+  .strict();
+
+export type ApprovalConstraints = z.infer<typeof ApprovalConstraintsSchema>;
+
+export type ApprovalRow = {
+  id: string;
+  project_id: string;
+  agent_id: string;
+  // The user the requesting agent token was delegated by.
+  requested_by: string | null;
+  status: "pending" | "approved" | "rejected" | "expired";
+  payload_data: unknown;
+  created_at: string;
+  decided_by: string | null;
+  decided_at: string | null;
+  decision_reason: string | null;
+  grant_expires_at: string | null;
+  grant_keys: string[] | null;
+  grant_environment: string | null;
+  revoked_at: string | null;
+  grant_id: string | null;
+};
+
+export type ApprovalGrant = {
+  expires_at: string;
+  keys: string[];
+  environment: string | null;
+  revoked_at: string | null;
+  active: boolean;
+};
+
+export type ApprovalSummary = {
+  id: string;
+  project_id: string;
+  project_name: string | null;
+  agent_id: string;
+  status: "pending" | "approved" | "rejected" | "expired";
+  // Approved requests are consumed by the agent's first status read.
+  consumed: boolean;
+  environment: string | null;
+  changes: { key: string; action: string }[];
+  created_at: string;
+  expires_at: string;
+  decided_by: string | null;
+  decided_at: string | null;
+  reason: string | null;
+  grant: ApprovalGrant | null;
+  // Set on requests applied automatically under another approval's grant.
+  grant_id: string | null;
+};
+
+// Owners and editors review agent requests, matching /api/approve/[id].
+export async function canReviewApproval(
+  supabase: SupabaseClient,
+  projectId: string,
+  userId: string,
+): Promise<boolean> {
+  const role = await getProjectRole(supabase, projectId, userId);
+  return role === "owner" || role === "editor";
+}
+
+// The environment a request targets, when the agent named one.
+export function requestedEnvironment(payloadData: unknown): string | null {
+  const payload = (payloadData || {}) as Record<string, unknown>;
+  if (typeof payload.environment === "string") return payload.environment;
+  if (typeof payload.environmentSlug === "string") {
+    return payload.environmentSlug;
+  }
+  return null;
+}
+
+// The keys a request changes.
+export function requestedKeys(payloadData: unknown): string[] {
+  const payload = (payloadData || {}) as Record<string, unknown>;
+  const mutations = Array.isArray(payload.mutations) ? payload.mutations : [];
+  return mutations
+    .map((m: { key?: unknown }) => m.key)
+    .filter((key): key is string => typeof key === "string");
+}
+
+// Finds an unexpired, unrevoked grant from an earlier approval that covers
+// every key of a new request by the same agent, acting for the same user, in
+// the same environment. Agent labels alone are not identities: any member
+// can issue a token under any label.
+export async function findCoveringGrant(
+  supabase: SupabaseClient,
+  request: {
+    projectId: string;
+    agentId: string;
+    requestedBy: string;
+    environment: string;
+    keys: string[];
+  },
+): Promise<ApprovalRow | null> {
+  if (request.keys.length === 0) return null;
+
+  const { data } = await supabase
+    .from("pending_approvals")
+    .select(APPROVAL_COLUMNS)
+    .eq("project_id", request.projectId)
+    .eq("agent_id", request.agentId)
+    .eq("requested_by", request.requestedBy)
+    .eq("grant_environment", request.environment)
+    .is("revoked_at", null)
+    .gt("grant_expires_at", new Date().toISOString())
+    .order("grant_expires_at", { ascending: false });
+
+  return (
+    ((data || []) as ApprovalRow[]).find((row) =>
+      request.keys.every((key) => (row.grant_keys || []).includes(key)),
+    ) ?? null
+  );
+}
+
+// Describes a request for the CLI without the proposed secret values.
+export function summarizeApproval(
+  row: ApprovalRow,
+  projectName: string | null,
+): ApprovalSummary {
+  const payload = (row.payload_data || {}) as Record<string, unknown>;
+  const mutations = Array.isArray(payload.mutations)
+    ? (payload.mutations as { key?: unknown; action?: unknown }[])
+    : [];
+  const expiresAt = new Date(
+    new Date(row.created_at).getTime() + APPROVAL_TTL_MS,
+  );
+
+  let status = row.status;
+  let consumed = false;
+  if (status === "pending" && expiresAt < new Date()) {
+    status = "expired";
+  } else if (status === "expired" && row.decided_at) {
+    // The status route burns approved requests to "expired" on first read.
+    status = "approved";
+    consumed = true;
+  }
+
+  return {
+    id: row.id,
+    project_id: row.project_id,
+    project_name: projectName,
+    agent_id: row.agent_id,
+    status,
+    consumed,
+    environment: requestedEnvironment(row.payload_data),
+    changes: mutations
+      .filter((m) => typeof m.key === "string")
+      .map((m) => ({
+        key: m.key as string,
+        action: typeof m.action === "string" ? m.action : "upsert",
+      })),
+    created_at: row.created_at,
+    expires_at: expiresAt.toISOString(),
+    decided_by: row.decided_by,
+    decided_at: row.decided_at,
+    reason: row.decision_reason,
+    grant: row.grant_expires_at
+      ? {
+          expires_at: row.grant_expires_at,
+          keys: row.grant_keys || [],
+          environment: row.grant_environment,
+          revoked_at: row.revoked_at,
+          active:
+            !row.revoked_at && new Date(row.grant_expires_at) > new Date(),
+        }
+      : null,
+    grant_id: row.grant_id,
+  };
+}
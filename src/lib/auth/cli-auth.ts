@@ -2,11 +2,15 @@ import { createAdminClient } from "@/lib/supabase/admin";
 import { NextResponse } from "next/server";
 import crypto from "crypto";
 import { apiRateLimit } from "@/lib/infra/ratelimit";
+import { FEDERATED_TOKEN_PREFIX } from "@/lib/auth/oidc-federation";
 
+// "service" and "federated" identities are both machine access to one
+// project; they are kept apart because their tokenIds point at different
+// tables (service_tokens and federated_tokens).
 export type AuthIdentity =
   | { type: "user"; userId: string; tokenId: string }
   | {
-      type: "service";
+      type: "service" | "federated";
       projectId: string;
       tokenId: string;
       environment?: string | null;
@@ -36,6 +40,41 @@ export async function validateCliToken(
 
   const supabase = createAdminClient();
 
+  // Tokens from `envault login --oidc` read the environment of the trust
+  // policy they were exchanged under, like a service token, but keep their
+  // own identity type so audit logs and rate limits can tell them apart.
+  if (token.startsWith(FEDERATED_TOKEN_PREFIX)) {
+    const { data, error } = await supabase
+      .from("federated_tokens")
+      .select("id, project_id, environment, expires_at")
+      .eq("token_hash", tokenHash)
+      .single();
+
+    if (error || !data) {
+      return NextResponse.json(
+        { error: "Invalid federated token" },
+        { status: 401 },
+      );
+    }
+
+    if (new Date(data.expires_at) < new Date()) {
+      return NextResponse.json({ error: "token_expired" }, { status: 401 });
+    }
+
+    supabase
+      .from("federated_tokens")
+      .update({ last_used_at: new Date().toISOString() })
+      .eq("token_hash", tokenHash)
+      .then();
+
+    return {
+      type: "federated",
+      projectId: data.project_id,
+      tokenId: data.id,
+      environment: data.environment,
+    };
+  }
+
   // Prefix-based routing: if it starts with envault_svc_, check service_tokens
   if (token.startsWith("envault_svc_")) {
     const { data, error } = await supabase
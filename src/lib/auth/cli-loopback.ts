@@ -0,0 +1,46 @@
+// Validation shared by the `envault login --browser` authorization page and
+// the token exchange. The CLI listens on 127.0.0.1 only, so any other
+// redirect target is refused rather than trusted.
+const LOOPBACK_REDIRECT = /^http:\/\/127\.0\.0\.1:(\d{1,5})\/callback$/;
+const CODE_CHALLENGE = /^[A-Za-z0-9_-]{43}$/;
+
+export type CliAuthorizationRequest = {
+  redirectUri: string;
+  codeChallenge: string;
+  state: string;
+  hostname: string;
+};
+
+export function parseCliAuthorizationRequest(params: {
+  [key: string]: string | string[] | undefined;
+}): CliAuthorizationRequest | { error: string } {
+  const get = (key: string) =>
+    typeof params[key] === "string" ? (params[key] as string) : "";
+
+  if (get("response_type") !== "code") {
+    return { error: "Unsupported response_type; expected code." };
+  }
+  const redirectUri = get("redirect_uri");
+  const port = Number(LOOPBACK_REDIRECT.exec(redirectUri)?.[1] ?? 0);
+  if (port < 1024 || port > 65535) {
+    return { error: "redirect_uri must be http://127.0.0.1:<port>/callback." };
+  }
+  if (get("code_challenge_method") !== "S256") {
+    return { error: "Unsupported code_challenge_method; expected S256." };
+  }
+  const codeChallenge = get("code_challenge");
+  if (!CODE_CHALLENGE.test(codeChallenge)) {
+    return { error: "Invalid code_challenge." };
+  }
+  const state = get("state");
+  if (state.length < 16 || state.length > 128) {
+    return { error: "Invalid state." };
+  }
+
+  return {
+    redirectUri,
+    codeChallenge,
+    state,
+    hostname: get("hostname").slice(0, 255) || "Unknown Device",
+  };
+}
@@ -0,0 +1,79 @@
+import crypto from "crypto";
+import { createAdminClient } from "@/lib/supabase/admin";
+
+type AdminClient = ReturnType<typeof createAdminClient>;
+
+export type CliSession = {
+  access_token: string;
+  refresh_token: string;
+  expires_in: number;
+  token_type: "Bearer";
+};
+
+const ACCESS_TOKEN_TTL_SECONDS = 60 * 60; // 1 hour
+const REFRESH_TOKEN_TTL_MS = 30 * 24 * 60 * 60 * 1000; // 1 month
+
+function hashToken(token: string) {
+  return crypto.createHash("sha256").update(token).digest("hex");
+}
+
+// Issues the refresh/access token pair of a new CLI session for a device.
+// Tokens are named per device, so logging in again on the same device
+// replaces its previous session.
+export async function issueCliSession(
+  supabase: AdminClient,
+  userId: string,
+  deviceInfo: Record<string, unknown> | null | undefined,
+): Promise<CliSession> {
+  const deviceName = (deviceInfo?.hostname as string) || "Unknown Device";
+  const metadata = deviceInfo || {};
+
+  // 1. Generate Refresh Token (1 month)
+  const secretRefreshToken = "envault_rt_" + crypto.randomUUID();
+  const { error: rtError } = await supabase
+    .from("personal_access_tokens")
+    .upsert(
+      {
+        user_id: userId,
+        name: `CLI Refresh Token on ${deviceName}`,
+        token_hash: hashToken(secretRefreshToken),
+        last_used_at: new Date().toISOString(),
+        expires_at: new Date(Date.now() + REFRESH_TOKEN_TTL_MS).toISOString(),
+        metadata,
+      },
+      { onConflict: "user_id, name" },
+    );
+  if (rtError) {
+    console.error("Error creating Refresh Token:", rtError);
+    throw new Error("Failed to generate refresh token");
+  }
+
+  // 2. Generate Access Token (1 hour)
+  const secretAccessToken = "envault_at_" + crypto.randomUUID();
+  const { error: atError } = await supabase
+    .from("personal_access_tokens")
+    .upsert(
+      {
+        user_id: userId,
+        name: `CLI Access Token on ${deviceName}`,
+        token_hash: hashToken(secretAccessToken),
+        last_used_at: new Date().toISOString(),
+        expires_at: new Date(
+          Date.now() + ACCESS_TOKEN_TTL_SECONDS * 1000,
+        ).toISOString(),
+        metadata,
+      },
+      { onConflict: "user_id, name" },
+    );
+  if (atError) {
+    console.error("Error creating Access Token:", atError);
+    throw new Error("Failed to generate access token");
+  }
+
+  return {
+    access_token: secretAccessToken,
+    refresh_token: secretRefreshToken,
+    expires_in: ACCESS_TOKEN_TTL_SECONDS,
+    token_type: "Bearer",
+  };
+}
@@ -0,0 +1,141 @@
+import crypto from "crypto";
+import jwt from "jsonwebtoken";
+import { z } from "zod";
+
+export const FEDERATED_TOKEN_PREFIX = "envault_oidc_";
+
+// Exchanged tokens cover one CI job, so they last an hour at most and never
+// outlive the identity token they were exchanged for.
+export const FEDERATED_TOKEN_SECONDS = 60 * 60;
+
+export const TRUST_POLICY_COLUMNS =
+  "id, project_id, issuer, audience, subject_pattern, environment, created_by, created_at";
+
+export const CreateTrustPolicySchema = z
+  .object({
+    issuer: z
+      .string()
+      .trim()
+      .url("--issuer must be a URL")
+      .refine((value) => value.startsWith("https://"), {
+        message: "--issuer must use https",
+      }),
+    audience: z.string().trim().min(1, "--audience is required").max(200),
+    subject_pattern: z
+      .string()
+      .trim()
+      .min(1, "--subject is required")
+      .max(300)
+      .refine((value) => value.replace(/\*/g, "").length > 0, {
+        message: "--subject must name more than a wildcard",
+      }),
+    environment: z.string().trim().min(1, "--env is required"),
+  })
+  .strict();
+
+export type TrustPolicyRow = {
+  id: string;
+  project_id: string;
+  issuer: string;
+  audience: string;
+  subject_pattern: string;
+  environment: string;
+  created_by: string | null;
+  created_at: string;
+};
+
+// Subject patterns match literally except for "*", which matches any run
+// of characters, e.g. "repo:acme/shop:ref:refs/heads/*".
+export function subjectMatches(pattern: string, subject: string): boolean {
+  const source = pattern
+    .split("*")
+    .map((part) => part.replace(/[.*+?^${}()|[\]\\]/g, "\\$&"))
+    .join(".*");
+  return new RegExp(`^${source}$`).test(subject);
+}
+
+// The issuer a token claims, read before it is verified so the matching
+// trust policies can be found. Nothing else is trusted until verification.
+export function claimedIssuer(token: string): string | null {
+  const decoded = jwt.decode(token, { json: true });
+  return typeof decoded?.iss === "string" ? decoded.iss : null;
+}
+
+const JWKS_CACHE_MS = 10 * 60 * 1000;
+const FETCH_TIMEOUT_MS = 5000;
+
+type Jwk = crypto.JsonWebKey & { kid?: string };
+
+const jwksCache = new Map<string, { keys: Jwk[]; fetchedAt: number }>();
+
+// Fetches the issuer's signing keys through OpenID discovery. Only issuers
+// named by a trust policy are ever fetched.
+async function issuerKeys(issuer: string, refresh: boolean): Promise<Jwk[]> {
+  const cached = jwksCache.get(issuer);
+  if (!refresh && cached && Date.now() - cached.fetchedAt < JWKS_CACHE_MS) {
+    return cached.keys;
+  }
+
+  const discovery = await fetch(
+    `${issuer.replace(/\/$/, "")}/.well-known/openid-configuration`,
+    { signal: AbortSignal.timeout(FETCH_TIMEOUT_MS) },
+  );
+  if (!discovery.ok) {
+    throw new Error(`OIDC discovery failed for ${issuer}`);
+  }
+  const { jwks_uri: jwksUri } = (await discovery.json()) as {
+    jwks_uri?: unknown;
+  };
+  if (typeof jwksUri !== "string" || !jwksUri.startsWith("https://")) {
+    throw new Error(`${issuer} publishes no https jwks_uri`);
+  }
+
+  const response = await fetch(jwksUri, {
+    signal: AbortSignal.timeout(FETCH_TIMEOUT_MS),
+  });
+  if (!response.ok) {
+    throw new Error(`Fetching the signing keys of ${issuer} failed`);
+  }
+  const { keys } = (await response.json()) as { keys?: unknown };
+  const parsed = Array.isArray(keys) ? (keys as Jwk[]) : [];
+  jwksCache.set(issuer, { keys: parsed, fetchedAt: Date.now() });
+  return parsed;
+}
+
+// Verifies an identity token's signature against the keys its issuer
+// publishes, and its issuer, audience and expiry against the policy.
+export async function verifyIdentityToken(
+  token: string,
+  policy: Pick<TrustPolicyRow, "issuer" | "audience">,
+): Promise<jwt.JwtPayload> {
+  const decoded = jwt.decode(token, { complete: true });
+  const kid = decoded?.header.kid;
+  if (!kid) {
+    throw new Error("The identity token names no signing key");
+  }
+
+  // An unknown kid may be a freshly rotated key, so look once more.
+  let jwk = (await issuerKeys(policy.issuer, false)).find(
+    (k) => k.kid === kid,
+  );
+  if (!jwk) {
+    jwk = (await issuerKeys(policy.issuer, true)).find((k) => k.kid === kid);
+  }
+  if (!jwk) {
+    throw new Error("The identity token's signing key is not published");
+  }
+
+  const payload = jwt.verify(
+    token,
+    crypto.createPublicKey({ key: jwk, format: "jwk" }),
+    {
+      algorithms: ["RS256", "ES256"],
+      issuer: policy.issuer,
+      audience: policy.audience,
+    },
+  );
+  if (typeof payload === "string") {
+    throw new Error("The identity token has no claims");
+  }
+  return payload;
+}
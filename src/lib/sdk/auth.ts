@@ -5,6 +5,7 @@ import type { SupabaseClient } from "@supabase/supabase-js";
 import { NextResponse } from "next/server";
 import type { NextRequest } from "next/server";
 import jwt from "jsonwebtoken";
+import { findAgentToken } from "@/lib/auth/agent-tokens";
 
 let cachedRedis: Redis | null | undefined;
 let cachedRatelimit: Ratelimit | null | undefined;
@@ -216,5 +217,27 @@ export async function verifySdkAuth(req: NextRequest, projectId: string) {
     );
   }
 
-  return { userId, agentId, authorized: true };
+  // 4. Recorded agent tokens (`envault agent-token issue`) can be revoked
+  // before they expire. Delegated tokens without a jti are not recorded.
+  if (payload.jti) {
+    const record = await findAgentToken(supabase, payload.jti);
+    if (!record || record.revoked_at) {
+      return NextResponse.json(
+        { error: "Agent token has been revoked" },
+        { status: 401 },
+      );
+    }
+    await supabase
+      .from("agent_tokens")
+      .update({ last_used_at: new Date().toISOString() })
+      .eq("id", record.id);
+  }
+
+  return {
+    userId,
+    agentId,
+    authorized: true,
+    environment: typeof payload.env === "string" ? payload.env : null,
+    keys: Array.isArray(payload.keys) ? (payload.keys as string[]) : null,
+  };
 }
@@ -0,0 +1,18 @@
+-- Authorization codes for `envault login --browser` (OAuth 2.0 authorization
+-- code flow with PKCE and a loopback redirect). Codes are single use, live
+-- for a few minutes and are only read and written with the service role.
+create table if not exists public.cli_authorization_codes (
+    code_hash text not null primary key, -- sha256 of the code sent to the CLI
+    user_id uuid not null references auth.users(id) on delete cascade,
+    code_challenge text not null, -- base64url(sha256(code_verifier))
+    redirect_uri text not null,
+    device_info jsonb not null default '{}'::jsonb,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null
+);
+
+create index if not exists idx_cli_authorization_codes_user_id on public.cli_authorization_codes(user_id);
+create index if not exists idx_cli_authorization_codes_expires_at on public.cli_authorization_codes(expires_at);
+
+alter table public.cli_authorization_codes enable row level security;
+-- No policies: only the service role reaches this table.
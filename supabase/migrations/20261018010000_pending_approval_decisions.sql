@@ -0,0 +1,8 @@
+-- Record who decided an agent approval request, when and why, so the CLI can
+-- show the outcome after the SDK has consumed (expired) an approved request.
+alter table public.pending_approvals
+    add column if not exists decided_by uuid references auth.users(id) on delete set null,
+    add column if not exists decided_at timestamptz,
+    add column if not exists decision_reason text;
+
+create index if not exists idx_pending_approvals_project_status on public.pending_approvals(project_id, status);
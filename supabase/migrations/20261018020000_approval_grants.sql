@@ -0,0 +1,15 @@
+-- Time-boxed grants: an approval given with `envault approve --for` keeps
+-- covering the same agent's follow-up requests for the approved keys and
+-- environment until it expires or is revoked.
+alter table public.pending_approvals
+    add column if not exists grant_expires_at timestamptz,
+    add column if not exists grant_keys text[],
+    add column if not exists grant_environment text,
+    add column if not exists revoked_at timestamptz,
+    add column if not exists revoked_by uuid references auth.users(id) on delete set null,
+    -- Requests applied under a grant point at the approval that granted them.
+    add column if not exists grant_id uuid references public.pending_approvals(id) on delete set null;
+
+create index if not exists idx_pending_approvals_active_grants
+    on public.pending_approvals(project_id, agent_id, grant_expires_at)
+    where grant_expires_at is not null and revoked_at is null;
@@ -0,0 +1,23 @@
+-- Agent tokens issued with `envault agent-token issue`. The signed
+-- envault_agt_ JWT carries the row id as its jti, so each agent has its own
+-- auditable identity and a token can be revoked before it expires.
+create table if not exists public.agent_tokens (
+    id uuid default gen_random_uuid() primary key,
+    project_id uuid references public.projects on delete cascade not null,
+    agent_id text not null,
+    environment text,
+    keys text[],
+    created_by uuid references auth.users(id) on delete cascade not null,
+    created_at timestamptz default timezone('utc'::text, now()) not null,
+    expires_at timestamptz not null,
+    revoked_at timestamptz,
+    revoked_by uuid references auth.users(id) on delete set null,
+    last_used_at timestamptz
+);
+
+create index if not exists idx_agent_tokens_project_agent
+    on public.agent_tokens(project_id, agent_id, expires_at);
+
+-- Only the service role reads or writes agent tokens; the CLI routes
+-- authorize callers themselves.
+alter table public.agent_tokens enable row level security;
@@ -0,0 +1,11 @@
+-- Agent labels are free-form and shared between members (every MCP server
+-- is `mcp-cli`), so a grant only covers requests from agents acting for
+-- the same user. Requests filed before this column existed have no
+-- requester and are never covered by a grant.
+alter table public.pending_approvals
+    add column if not exists requested_by uuid references auth.users(id) on delete set null;
+
+drop index if exists public.idx_pending_approvals_active_grants;
+create index if not exists idx_pending_approvals_active_grants
+    on public.pending_approvals(project_id, agent_id, requested_by, grant_expires_at)
+    where grant_expires_at is not null and revoked_at is null;
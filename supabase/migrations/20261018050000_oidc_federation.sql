@@ -0,0 +1,39 @@
+-- Workload identity federation for `envault login --oidc`. A project owner
+-- trusts CI identity tokens from an issuer for one audience and a subject
+-- pattern; a matching token is exchanged for a short-lived envault_oidc_
+-- token that reads the policy's environment.
+create table if not exists public.oidc_trust_policies (
+    id uuid default gen_random_uuid() primary key,
+    project_id uuid references public.projects on delete cascade not null,
+    issuer text not null,
+    audience text not null,
+    subject_pattern text not null,
+    environment text not null,
+    created_by uuid references auth.users(id) on delete set null,
+    created_at timestamptz default timezone('utc'::text, now()) not null
+);
+
+create index if not exists idx_oidc_trust_policies_project_issuer
+    on public.oidc_trust_policies(project_id, issuer);
+
+-- Tokens issued by the exchange. Only the hash is kept, and removing the
+-- policy revokes every token issued under it.
+create table if not exists public.federated_tokens (
+    id uuid default gen_random_uuid() primary key,
+    project_id uuid references public.projects on delete cascade not null,
+    policy_id uuid references public.oidc_trust_policies(id) on delete cascade not null,
+    environment text not null,
+    subject text not null,
+    token_hash text not null unique,
+    created_at timestamptz default timezone('utc'::text, now()) not null,
+    expires_at timestamptz not null,
+    last_used_at timestamptz
+);
+
+create index if not exists idx_federated_tokens_policy
+    on public.federated_tokens(policy_id);
+
+-- Only the service role reads or writes these; the CLI routes authorize
+-- callers themselves.
+alter table public.oidc_trust_policies enable row level security;
+alter table public.federated_tokens enable row level security;